@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/getopt"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/shellwords"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// deferredSaves holds, while a batch runs, the lists saved by its commands
+// keyed by file name, so that each is written once when the batch ends. It
+// is nil otherwise.
+var deferredSaves map[string]*todo.List
+
+// deferredWrites holds, while a batch runs, the writes its commands make to
+// other files, such as Markdown documents and the config file, keyed by
+// file name. They are made when the batch ends, after the lists are saved.
+// It is nil otherwise.
+var deferredWrites map[string]func() error
+
+// writeLater holds back a write of the named file until the batch ends, if
+// one is running, and reports whether it did. A later write of the same
+// file replaces it.
+func writeLater(filename string, write func() error) bool {
+	if deferredWrites == nil {
+		return false
+	}
+	deferredWrites[filename] = write
+	return true
+}
+
+// writeFile writes data to a file other than a todo list. During a batch
+// the write is held back until the batch ends.
+func writeFile(filename string, data []byte) error {
+	write := func() error {
+		if err := os.WriteFile(filename, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filename, err)
+		}
+		return nil
+	}
+	if writeLater(filename, write) {
+		return nil
+	}
+	return write()
+}
+
+// handleBatch runs the commands in a file, or stdin for "-" or no file
+func handleBatch(e *env, opts *getopt.Result) error {
+	name := "-"
+	if len(opts.Args) > 0 {
+		name = opts.Args[0]
+	}
+	in := io.Reader(os.Stdin)
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer f.Close()
+		in = f
+	}
+	return runBatch(e, in, opts.Bool("stop-on-error"))
+}
+
+// runBatch runs commands read from r, one per line, with the grammar of
+// interactive mode. Blank lines and lines starting with # are skipped, and
+// a backslash at the end of a line continues the command on the next.
+// Errors are reported with their line number. The changed lists, and any
+// other files the commands write, are saved once at the end; with
+// stopOnError the first error ends the batch and nothing is written.
+func runBatch(e *env, r io.Reader, stopOnError bool) error {
+	deferredSaves = make(map[string]*todo.List)
+	deferredWrites = make(map[string]func() error)
+	defer func() { deferredSaves, deferredWrites = nil, nil }()
+	e.batch = true
+
+	failed, total := 0, 0
+	fail := func(line int, err error) error {
+		failed++
+		fmt.Fprintf(os.Stderr, "line %d: %v\n", line, err)
+		if stopOnError {
+			return fmt.Errorf("stopped at line %d; no files were written", line)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNo, start := 0, 0
+	pending := ""
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if pending != "" {
+			line = pending + "\n" + line
+		} else {
+			start = lineNo
+			if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+		}
+
+		words, err := shellwords.Split(line)
+		if errors.Is(err, shellwords.ErrTrailingBackslash) {
+			pending = line
+			continue
+		}
+		pending = ""
+		if err == nil && len(words) == 0 {
+			continue
+		}
+
+		total++
+		if err == nil {
+			words, err = expandAlias(words)
+		}
+		if err == nil {
+			err = runCommand(e, words)
+		}
+		if errors.Is(err, errQuit) {
+			break
+		}
+		if err != nil {
+			if err := fail(start, err); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read commands: %w", err)
+	}
+	if pending != "" {
+		total++
+		if err := fail(start, shellwords.ErrTrailingBackslash); err != nil {
+			return err
+		}
+	}
+
+	// Write the lists, then the other files, in a fixed order, now that
+	// writes are no longer held
+	saves, writes := deferredSaves, deferredWrites
+	deferredSaves, deferredWrites = nil, nil
+	filenames := make([]string, 0, len(saves))
+	for filename := range saves {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		if err := saveTodos(saves[filename], filename); err != nil {
+			return err
+		}
+	}
+	filenames = filenames[:0]
+	for filename := range writes {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		if err := writes[filename](); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d commands failed", failed, total)
+	}
+	return nil
+}
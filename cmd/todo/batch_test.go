@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/config"
+)
+
+func TestBatchStopOnErrorWritesNothing(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	saved := settings
+	t.Cleanup(func() { settings = saved })
+	settings = config.Default()
+
+	filename := filepath.Join(dir, "todos.json")
+	doc := filepath.Join(dir, "doc.md")
+	if err := os.WriteFile(doc, []byte("# Doc\n"), 0644); err != nil {
+		t.Fatalf("Failed to write document: %v", err)
+	}
+
+	commands := strings.Join([]string{
+		"add 'Buy milk'",
+		"sync-md " + doc,
+		"export " + filepath.Join(dir, "out.csv"),
+		"config set color never",
+		"bogus",
+	}, "\n")
+	e := &env{list: newList(), filename: filename}
+	if err := runBatch(e, strings.NewReader(commands), true); err == nil {
+		t.Fatal("Expected the unknown command to stop the batch")
+	}
+
+	if data, _ := os.ReadFile(doc); string(data) != "# Doc\n" {
+		t.Errorf("Expected the document to be unchanged, got %q", data)
+	}
+	for _, name := range []string{filename, filepath.Join(dir, "out.csv"), filepath.Join(dir, "config")} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be written", name)
+		}
+	}
+
+	// Without the failing command, everything is written at the end
+	commands = strings.TrimSuffix(commands, "\nbogus")
+	e = &env{list: newList(), filename: filename}
+	if err := runBatch(e, strings.NewReader(commands), true); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if data, _ := os.ReadFile(doc); !strings.Contains(string(data), "- [ ] Buy milk") {
+		t.Errorf("Expected the document to be synced, got %q", data)
+	}
+	path, _ := config.Path()
+	if loaded, err := config.Load(path); err != nil || loaded.Color != "never" {
+		t.Errorf("Expected the config to be saved, got %+v, %v", loaded, err)
+	}
+}
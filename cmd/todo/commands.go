@@ -0,0 +1,641 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/config"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/getopt"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// mode selects where a command is available
+type mode int
+
+const (
+	modeCLI mode = 1 << iota
+	modeInteractive
+	modeAll = modeCLI | modeInteractive
+)
+
+// env is the state a command runs with
+type env struct {
+	// list is the loaded todo list; nil for noList commands run from the
+	// command line
+	list        *todo.List
+	filename    string
+	source      config.Source
+	interactive bool
+	// batch is set while commands are read from a file by 'todo batch'
+	batch bool
+}
+
+// command describes a command: how it is invoked, its help and the function
+// that runs it
+type command struct {
+	name    string
+	aliases []string
+	// args is the argument synopsis shown in help, e.g. "<n> <text>"
+	args string
+	// minArgs and maxArgs bound the number of arguments after the flags;
+	// maxArgs < 0 means no limit
+	minArgs int
+	maxArgs int
+	flags   []getopt.Option
+	// rawArgs commands receive their arguments without flag parsing
+	rawArgs bool
+	summary string
+	// details is shown by 'todo help <command>' below the summary
+	details string
+	// noList commands run before the todo list is loaded
+	noList bool
+	modes  mode
+	run    func(e *env, opts *getopt.Result) error
+}
+
+// usage returns the command synopsis as shown in help
+func (c *command) usage() string {
+	usage := c.name
+	if len(c.flags) > 0 {
+		usage += " [flags]"
+	}
+	if c.args != "" {
+		usage += " " + c.args
+	}
+	return usage
+}
+
+// checkArgs validates the number of arguments
+func (c *command) checkArgs(args []string, prefix string) error {
+	if len(args) < c.minArgs || (c.maxArgs >= 0 && len(args) > c.maxArgs) {
+		return fmt.Errorf("usage: %s%s", prefix, c.usage())
+	}
+	return nil
+}
+
+// errQuit is returned by the quit command to end interactive mode or a
+// batch
+var errQuit = errors.New("quit")
+
+// commands holds the registered commands in the order help lists them
+var commands []*command
+
+// registerCommand adds a command to the registry. It panics if the name or
+// an alias is already taken.
+func registerCommand(c command) {
+	if c.modes == 0 {
+		c.modes = modeAll
+	}
+	for _, name := range append([]string{c.name}, c.aliases...) {
+		if _, ok := lookupCommand(name); ok {
+			panic(fmt.Sprintf("todo: command %q registered twice", name))
+		}
+	}
+	commands = append(commands, &c)
+}
+
+// lookupCommand returns the command with the given name or alias
+func lookupCommand(name string) (*command, bool) {
+	name = strings.ToLower(name)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// isBuiltinCommand reports whether name is a built-in command or alias
+func isBuiltinCommand(name string) bool {
+	_, ok := lookupCommand(name)
+	return ok
+}
+
+// runCommand looks up the command named by args[0], parses its flags and
+// runs it. With -h or --help the command's help is shown instead. On the
+// command line, names that are not built-in commands run plugins.
+func runCommand(e *env, args []string) error {
+	cmd, ok := lookupCommand(args[0])
+	if !ok && e.mode() == modeCLI {
+		if path, found := findPlugin(args[0]); found {
+			return runPlugin(e, path, args[1:])
+		}
+	}
+	if !ok || cmd.modes&e.mode() == 0 {
+		return unknownCommandError(e, args[0])
+	}
+
+	opts := &getopt.Result{Args: args[1:]}
+	if !cmd.rawArgs {
+		var err error
+		set := &getopt.Set{Options: cmd.flags}
+		opts, err = set.Parse(args[1:])
+		if errors.Is(err, getopt.ErrHelp) {
+			printCommandHelp(cmd, e.prefix())
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", cmd.name, err)
+		}
+	}
+
+	if err := cmd.checkArgs(opts.Args, e.prefix()); err != nil {
+		return err
+	}
+	return cmd.run(e, opts)
+}
+
+// unknownCommandError reports a command that does not exist, suggesting
+// the closest command or alias
+func unknownCommandError(e *env, name string) error {
+	var names []string
+	for _, cmd := range commands {
+		if cmd.modes&e.mode() != 0 {
+			names = append(names, cmd.name)
+			names = append(names, cmd.aliases...)
+		}
+	}
+	for alias := range settings.Aliases {
+		names = append(names, alias)
+	}
+	if e.mode() == modeCLI {
+		for _, p := range findPlugins() {
+			names = append(names, p.name)
+		}
+	}
+	sort.Strings(names)
+
+	msg := "unknown command: " + name
+	if suggestion := getopt.Suggest(name, names); suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %s?)", suggestion)
+	}
+	if e.interactive {
+		return fmt.Errorf("%s. Type 'help' for available commands", msg)
+	}
+	if e.batch {
+		return errors.New(msg)
+	}
+	return fmt.Errorf("%s\nRun 'todo help' for usage information", msg)
+}
+
+// mode returns the mode the command runs in. Batches use the commands of
+// interactive mode.
+func (e *env) mode() mode {
+	if e.interactive || e.batch {
+		return modeInteractive
+	}
+	return modeCLI
+}
+
+// prefix returns what the user types before a command
+func (e *env) prefix() string {
+	if e.interactive || e.batch {
+		return ""
+	}
+	return "todo "
+}
+
+// globalFlags are the flags accepted before the command
+var globalFlags = []getopt.Option{
+	{Name: "help", Short: 'h', Help: "Show this help message"},
+	{Name: "version", Short: 'v', Help: "Show version information"},
+	{Name: "interactive", Short: 'i', Help: "Run in interactive mode"},
+	{Name: "file", Short: 'f', Value: "<path>", Help: "Specify todo file path (see \"Todo file\" below)"},
+	{Name: "list", Short: 'l', Value: "<name>", Help: "Use a named list"},
+}
+
+// transferFlags are the flags shared by export and import
+var transferFlags = []getopt.Option{
+	{Name: "format", Value: "<fmt>", Help: "json, csv, markdown (md), ics or taskwarrior; defaults to the file extension, or the format setting (csv) for stdin/stdout"},
+	{Name: "map", Value: "<mapping>", Help: "CSV column mapping, e.g. text=Title,done=Status"},
+	{Name: "group", Short: 'g', Help: "Markdown export: group items under project headings"},
+}
+
+// whereFlag selects items for the commands acting on several items
+var whereFlag = getopt.Option{Name: "where", Short: 'w', Value: "<filter>", Help: "Act on the items matching the filter instead of item numbers"}
+
+const filterDetails = `A filter is a list of terms that must all match, such as "pending",
+"done", "tag:work", "project=home", "priority>=medium", "due<=today",
+"text~report" or "<field>=<value>" for custom fields.`
+
+func init() {
+	registerCommand(command{
+		name: "add", aliases: []string{"a"}, args: "<text>",
+		minArgs: 1, maxArgs: -1,
+		flags: []getopt.Option{
+			{Name: "priority", Short: 'p', Value: "<level>", Help: "Priority: high, medium or low"},
+			{Name: "project", Short: 'P', Value: "<name>", Help: "Project the item belongs to"},
+			{Name: "due", Short: 'd', Value: "<date>", Help: "Due date, e.g. 2024-05-01, today or tomorrow"},
+			{Name: "tag", Short: 't', Value: "<tag>", Help: "Add a tag; may be repeated"},
+			{Name: "set", Short: 's', Value: "<name=value>", Help: "Set a custom field; may be repeated"},
+		},
+		summary: "Add a new todo item",
+		details: "Flags may come before or after the text. Use -- before text that starts\nwith a dash.",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleAdd(e.list, e.filename, opts)
+		},
+	})
+	registerCommand(command{
+		name: "list", aliases: []string{"ls", "l"}, args: "[filter]",
+		maxArgs: -1,
+		flags: []getopt.Option{
+			{Name: "where", Short: 'w', Value: "<filter>", Help: "Only show items matching the filter"},
+			{Name: "sort", Short: 's', Value: "<keys>", Help: "Comma-separated sort keys; prefix with - to reverse"},
+			{Name: "all-lists", Short: 'a', Help: "Show items from every named list"},
+		},
+		summary: "List todo items (default when no command given)",
+		details: "Items keep their numbers when filtered or sorted. Other arguments are\nadded to the --where filter.\n\n" + filterDetails,
+		run: func(e *env, opts *getopt.Result) error {
+			// Interactive mode shows the list before every prompt
+			if e.interactive && len(opts.Args) == 0 && !opts.Has("where") && !opts.Has("sort") && !opts.Has("all-lists") {
+				return nil
+			}
+			return handleList(e.list, opts)
+		},
+	})
+	registerCommand(command{
+		name: "lists", maxArgs: 0, noList: true,
+		summary: "Show the named lists",
+		details: "The list in use is marked with *.",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleLists(e.filename)
+		},
+	})
+	registerCommand(command{
+		name: "use", args: "[name]", maxArgs: 1, noList: true,
+		summary: "Select the named list to use by default",
+		details: "Without a name, show the selected list.",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleUse(opts.Args)
+		},
+	})
+	registerCommand(command{
+		name: "move-to", args: "<list> <n>", minArgs: 2, maxArgs: 2,
+		summary: "Move item n to another named list",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleMoveTo(e.list, e.filename, opts.Args)
+		},
+	})
+	registerCommand(command{
+		name: "set", args: "<n> <name=value>...", minArgs: 2, maxArgs: -1,
+		summary: "Set fields of item n (empty value clears)",
+		details: "Fields are text, project, priority, due, tags, recurrence and the custom\nfields declared with 'field add' or in the config.",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleSet(e.list, e.filename, opts.Args)
+		},
+	})
+	registerCommand(command{
+		name: "field", aliases: []string{"fields"}, args: "add <name> <type> [values] | list | rm <name>",
+		maxArgs: 4,
+		summary: "Manage custom fields",
+		details: "Types are string, number, date and enum; enum fields take their allowed\nvalues as a comma-separated list, which also sets their sort order.",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleField(e.list, e.filename, opts.Args)
+		},
+	})
+	registerCommand(command{
+		name: "complete", aliases: []string{"done", "c"}, args: "<n>...",
+		maxArgs: -1, flags: []getopt.Option{whereFlag},
+		summary: "Mark items as completed",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleComplete(e.list, e.filename, opts)
+		},
+	})
+	registerCommand(command{
+		name: "uncomplete", aliases: []string{"undo", "u"}, args: "<n>...",
+		maxArgs: -1, flags: []getopt.Option{whereFlag},
+		summary: "Mark items as not completed",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleUncomplete(e.list, e.filename, opts)
+		},
+	})
+	registerCommand(command{
+		name: "delete", aliases: []string{"remove", "rm", "d"}, args: "<n>...",
+		maxArgs: -1, flags: []getopt.Option{whereFlag},
+		summary: "Delete items",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleDelete(e.list, e.filename, opts)
+		},
+	})
+	registerCommand(command{
+		name: "edit", aliases: []string{"e"}, args: "<n> <text>",
+		minArgs: 2, maxArgs: -1,
+		summary: "Edit item n with new text",
+		details: "Use -- before text that starts with a dash.",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleEdit(e.list, e.filename, opts.Args)
+		},
+	})
+	registerCommand(command{
+		name: "clear", maxArgs: 0,
+		summary: "Clear all items",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleClear(e.list, e.filename)
+		},
+	})
+	registerCommand(command{
+		name: "export", args: "[file]", maxArgs: 1, flags: transferFlags,
+		summary: "Export items (stdout when no file is given)",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleExport(e.list, opts)
+		},
+	})
+	registerCommand(command{
+		name: "import", args: "[file]", maxArgs: 1,
+		flags: append(append([]getopt.Option(nil), transferFlags...),
+			getopt.Option{Name: "dry-run", Short: 'n', Help: "Report what would be imported without saving"}),
+		summary: "Import items (stdin when no file is given)",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleImport(e.list, e.filename, opts)
+		},
+	})
+	registerCommand(command{
+		name: "sync-md", args: "<file>", minArgs: 1, maxArgs: 1,
+		flags:   []getopt.Option{{Name: "group", Short: 'g', Help: "Group items under project headings"}},
+		summary: "Sync the todo section of a Markdown file",
+		details: "Items checked off in the file are completed and new items are added,\nthen the section between the todo markers is rewritten.",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleSyncMarkdown(e.list, e.filename, opts)
+		},
+	})
+	registerCommand(command{
+		name: "migrate", maxArgs: 0, noList: true,
+		flags:   []getopt.Option{{Name: "check", Help: "Report whether migration is needed without changing anything"}},
+		summary: "Upgrade the todo file to the current schema",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleMigrate(e.filename, opts.Bool("check"))
+		},
+	})
+	registerCommand(command{
+		name: "config", args: "[list | get <key> | set <key> [value] | path | file]",
+		maxArgs: -1, noList: true, rawArgs: true,
+		summary: "Show or change settings and aliases",
+		details: "'set' without a value restores the default, or removes an alias or field. The\nvalue is taken as is, so alias definitions may contain flags.\n'file' shows which todo file is used and why.",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleConfig(e.filename, e.source, opts.Args)
+		},
+	})
+	registerCommand(command{
+		name: "batch", args: "[file]", maxArgs: 1, modes: modeCLI,
+		flags:   []getopt.Option{{Name: "stop-on-error", Short: 'e', Help: "Stop at the first failing command and write nothing"}},
+		summary: "Run commands from a file, or stdin for - or no file",
+		details: "Each line is a command as typed in interactive mode; blank lines and lines\nstarting with # are skipped, and a backslash at the end of a line continues\nit. The todo file, and any other file the commands write, is saved once,\nafter the last command. Failing commands are reported with their line\nnumber and make the exit status non-zero.",
+		run:     handleBatch,
+	})
+	registerCommand(command{
+		name: "tui", maxArgs: 0, noList: true, modes: modeCLI,
+		summary: "Open the full-screen interface",
+		details: "Move with j and k, toggle with x, delete with dd, edit with e, add with a,\nsearch with / and filter with f; press ? for all keys. Changes made to the\ntodo file by other programs are picked up while it runs.",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleTUI(e.filename)
+		},
+	})
+	registerCommand(command{
+		name: "serve", maxArgs: 0, noList: true, modes: modeCLI,
+		flags:   []getopt.Option{{Name: "addr", Short: 'a', Value: "<host:port>", Help: "Address to listen on (default " + defaultServeAddr + ")"}},
+		summary: "Serve a REST API for the todo list",
+		details: "Endpoints: GET and POST /items, GET, PATCH and DELETE /items/{n}, and\nPOST /items/{n}/complete, with JSON bodies. GET /items filters with the\nwhere and sort query parameters, or field=value parameters. Responses\ncarry an ETag; send it in If-Match to make a change fail with 412 if the\nitem was changed meanwhile. GET /events streams changes, including those\nmade by other commands to the todo file, as server-sent events.",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleServe(e.filename, opts.Get("addr"))
+		},
+	})
+	registerCommand(command{
+		name: "completion", args: "<shell>", minArgs: 1, maxArgs: 1,
+		noList: true, modes: modeCLI,
+		summary: "Print a completion script for bash, zsh or fish",
+		details: "Commands, flags, item numbers, tags, projects, field values and list\nnames are completed. Aliases are completed with their definition; those\nthat expand to a list command are saved views, marked as such.",
+		run: func(e *env, opts *getopt.Result) error {
+			return handleCompletion(opts.Args)
+		},
+	})
+	registerCommand(command{
+		name: "help", aliases: []string{"h"}, args: "[command]", maxArgs: 1,
+		noList:  true,
+		summary: "Show help, or the help of a command",
+		run:     handleHelp,
+	})
+	registerCommand(command{
+		name: "quit", aliases: []string{"exit", "q"}, maxArgs: 0,
+		modes:   modeInteractive,
+		summary: "Exit interactive mode",
+		run: func(e *env, opts *getopt.Result) error {
+			return errQuit
+		},
+	})
+}
+
+// handleHelp shows the general help, or the help of one command or alias
+func handleHelp(e *env, opts *getopt.Result) error {
+	args := opts.Args
+	if len(args) == 0 {
+		if e.interactive {
+			printInteractiveHelp()
+		} else {
+			printHelp()
+		}
+		return nil
+	}
+
+	cmd, ok := lookupCommand(args[0])
+	if !ok || cmd.modes&e.mode() == 0 {
+		if definition, ok := settings.Aliases[args[0]]; ok {
+			fmt.Printf("%s is an alias for: %s\n", args[0], definition)
+			return nil
+		}
+		if path, ok := findPlugin(args[0]); ok && e.mode() == modeCLI {
+			fmt.Printf("%s is a plugin: %s\n", args[0], path)
+			return nil
+		}
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+	printCommandHelp(cmd, e.prefix())
+	return nil
+}
+
+// printCommandHelp shows the usage, description and flags of a command
+func printCommandHelp(cmd *command, prefix string) {
+	fmt.Printf("Usage: %s%s\n\n%s\n", prefix, cmd.usage(), cmd.summary)
+	if cmd.details != "" {
+		fmt.Printf("\n%s\n", cmd.details)
+	}
+	if len(cmd.aliases) > 0 {
+		fmt.Printf("\nAliases: %s\n", strings.Join(cmd.aliases, ", "))
+	}
+	if len(cmd.flags) > 0 {
+		fmt.Println("\nFlags:")
+		for _, f := range cmd.flags {
+			printHelpLine(f.String(), f.Help)
+		}
+	}
+}
+
+// helpColumn is the width of the first column of help listings, and
+// helpWidth the width descriptions are wrapped to
+const (
+	helpColumn = 20
+	helpWidth  = 79
+)
+
+// printHelpLine prints a help entry, moving the description to the next
+// line when the name does not fit in the first column and wrapping long
+// descriptions
+func printHelpLine(name, help string) {
+	indent := strings.Repeat(" ", helpColumn+3)
+	if len(name) > helpColumn {
+		fmt.Printf("  %s\n", name)
+		name = ""
+	}
+
+	line := fmt.Sprintf("  %-*s", helpColumn, name)
+	for i, word := range strings.Fields(help) {
+		if i > 0 && len(line)+1+len(word) > helpWidth {
+			fmt.Println(line)
+			line = indent + word
+			continue
+		}
+		line += " " + word
+	}
+	fmt.Println(line)
+}
+
+// printCommands lists the commands available in mode
+func printCommands(m mode) {
+	for _, cmd := range commands {
+		if cmd.modes&m == 0 {
+			continue
+		}
+		names := append([]string{cmd.name}, cmd.aliases...)
+		synopsis := strings.Join(names, ", ")
+		if cmd.args != "" {
+			synopsis += " " + cmd.args
+		}
+		printHelpLine(synopsis, cmd.summary)
+	}
+}
+
+// printHelp shows the command line help
+func printHelp() {
+	fmt.Print(`Todo - A simple and efficient command line task manager
+
+Usage:
+  todo [flags] [command] [arguments]
+
+Flags:
+`)
+	for _, f := range globalFlags {
+		printHelpLine(f.String(), f.Help)
+	}
+
+	fmt.Println("\nCommands:")
+	printCommands(modeCLI)
+
+	if plugins := findPlugins(); len(plugins) > 0 {
+		fmt.Println("\nPlugins:")
+		for _, p := range plugins {
+			printHelpLine(p.name, p.path)
+		}
+	}
+
+	fmt.Printf(`
+Run 'todo help <command>' for the flags and details of a command.
+Other commands run a plugin: an executable named todo-<command> on PATH.
+It is passed the todo file in $TODO_FILE and the settings in $TODO_<KEY>.
+
+Todo file:
+  The first of: --file, --list, $TODO_FILE, a %s in the current
+  directory or one of its parents, the list selected with 'use', the
+  "file" setting, %s in the current directory, and the "default"
+  list, %s in $XDG_DATA_HOME/todo (~/.local/share/todo). Named lists
+  are stored in $XDG_DATA_HOME/todo/lists.
+
+Hooks:
+  Executables named on-add, on-complete, on-modify and on-delete in
+  $XDG_CONFIG_HOME/todo/hooks run before a change is saved. They read the
+  item as JSON on stdin (on-modify: the old and new item on two lines),
+  veto the change by exiting non-zero and may print a changed item.
+
+Settings (stored in $XDG_CONFIG_HOME/todo/config.json):
+`, config.LocalFilename, config.DefaultFilename, config.DefaultFilename)
+	for _, key := range config.Keys {
+		printHelpLine(key, settingHelp[key])
+	}
+	printHelpLine("alias.<name>", "Command line that <name> expands to; an alias of list is a saved view")
+	printHelpLine("field.<name>", "Custom field declared for every list, as <type> [values]")
+
+	fmt.Print(`
+Examples:
+  todo add "Learn Go testing"     # Add a new task
+  todo list                       # List all tasks
+  todo complete 2                 # Mark task 2 as completed
+  todo edit 1 "Updated task"       # Edit task 1
+  todo delete 3                   # Delete task 3
+  todo -i                         # Start interactive mode
+  todo -f my-tasks.json list      # Use custom file
+  todo help export                # Show the flags of export
+  todo field add ticket string                  # Declare a custom field
+  todo field add sprint enum s1,s2,s3           # Enum values sort in order
+  todo set 2 ticket=OPS-123 sprint=s2           # Set fields on task 2
+  todo list --where "pending ticket~OPS" --sort sprint,-due
+  todo --list work add "Send report"            # Add to the "work" list
+  todo use work                                 # Make "work" the default list
+  todo move-to personal 3                       # Move task 3 to "personal"
+  todo list --all-lists --where pending         # Pending tasks of every list
+  source <(todo completion bash)                # Enable tab completion
+  todo config set alias.t list --where pending  # Then run 'todo t'
+  todo config set date_format "Jan 2"           # Show dates as "Mar 1"
+  todo export tasks.csv                         # Export to a spreadsheet
+  todo import --map text=Title --dry-run in.csv # Preview a CSV import
+  todo export --format md --group               # Markdown checklist by project
+  todo sync-md README.md                        # Update README's todo section
+  todo export --format ics tasks.ics            # Load tasks into a calendar app
+  task export | todo import --format taskwarrior # Migrate from Taskwarrior
+
+For more information, visit: https://github.com/kai-xlr/CLI-Task-Manager
+`)
+}
+
+// settingHelp describes the settings listed by printHelp
+var settingHelp = map[string]string{
+	"list":        "Named list selected with 'use'",
+	"file":        "Default todo file",
+	"format":      "Default export/import format for stdin/stdout",
+	"date_format": "Go time layout for dates in 'list' (default " + todo.DateLayout + ")",
+	"color":       "auto, always or never",
+}
+
+// printInteractiveHelp shows help specific to interactive mode
+func printInteractiveHelp() {
+	fmt.Println("Available commands in interactive mode:")
+	fmt.Println()
+	printCommands(modeInteractive)
+
+	if len(settings.Aliases) > 0 {
+		fmt.Println("\nAliases:")
+		names := make([]string, 0, len(settings.Aliases))
+		for name := range settings.Aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			printHelpLine(name, settings.Aliases[name])
+		}
+	}
+
+	fmt.Print(`
+Type 'help <command>' for the flags and details of a command.
+
+Examples:
+  add Buy milk
+  complete 1
+  edit 2 Updated task text
+  delete 3
+  list --where pending --sort -priority
+`)
+}
@@ -0,0 +1,607 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/config"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/getopt"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// completeFiles is printed by __complete to ask the shell for file names
+const completeFiles = ":files"
+
+// candidate is a completion value with an optional description
+type candidate struct {
+	value string
+	desc  string
+}
+
+// handleCompletion prints the completion script for a shell
+func handleCompletion(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: todo completion bash|zsh|fish")
+	}
+
+	scripts := map[string]string{
+		"bash": bashCompletion,
+		"zsh":  zshCompletion,
+		"fish": fishCompletion,
+	}
+	script, ok := scripts[strings.ToLower(args[0])]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q (expected bash, zsh or fish)", args[0])
+	}
+	_, err := os.Stdout.WriteString(script)
+	return err
+}
+
+// handleCompletionCandidates implements the hidden __complete command used by the
+// completion scripts. The arguments are the words after "todo", the last
+// being the word under the cursor. Each candidate is printed on its own
+// line, followed by a tab and a description if it has one.
+func handleCompletionCandidates(args []string) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+
+	candidates, files := completeWords(args[:len(args)-1], args[len(args)-1])
+	for _, c := range candidates {
+		if c.desc != "" {
+			fmt.Printf("%s\t%s\n", c.value, c.desc)
+		} else {
+			fmt.Println(c.value)
+		}
+	}
+	if files {
+		fmt.Println(completeFiles)
+	}
+}
+
+// completion holds the state used to complete a command line
+type completion struct {
+	flags Config
+	list  *todo.List
+	mode  mode
+}
+
+// todoList loads the todo list selected by the command line, or returns an
+// empty list if it cannot be loaded
+func (c *completion) todoList() *todo.List {
+	if c.list != nil {
+		return c.list
+	}
+	c.list = newList()
+	dir, err := os.Getwd()
+	if err != nil {
+		return c.list
+	}
+	if filename, _, err := resolveTodoFile(&c.flags, dir); err == nil {
+		loadTodosIfExists(c.list, filename)
+	}
+	return c.list
+}
+
+// completeWords returns the candidates for cur given the preceding words,
+// and whether file names should be offered as well
+func completeWords(words []string, cur string) ([]candidate, bool) {
+	c := &completion{mode: modeCLI}
+
+	// Global flags come before the command
+	i := 0
+	for ; i < len(words) && strings.HasPrefix(words[i], "-"); i++ {
+		switch words[i] {
+		case "-f", "--file", "-l", "--list":
+			if i+1 < len(words) {
+				if words[i] == "-f" || words[i] == "--file" {
+					c.flags.TodoFile = words[i+1]
+				} else {
+					c.flags.List = words[i+1]
+				}
+				i++
+				continue
+			}
+			// The flag's value is being completed
+			if words[i] == "-f" || words[i] == "--file" {
+				return nil, true
+			}
+			return filter(listCandidates(), cur), false
+		}
+		if name, value, ok := strings.Cut(words[i], "="); ok {
+			switch name {
+			case "-f", "--file":
+				c.flags.TodoFile = value
+			case "-l", "--list":
+				c.flags.List = value
+			}
+		}
+	}
+
+	if i == len(words) && strings.HasPrefix(cur, "-") {
+		return filter(optionCandidates(globalFlags), cur), false
+	}
+	return c.completeLine(words[i:], cur)
+}
+
+// completeLine returns the candidates for cur given the command and
+// arguments before it
+func (c *completion) completeLine(words []string, cur string) ([]candidate, bool) {
+	if len(words) == 0 {
+		return filter(c.commandCandidates(), cur), false
+	}
+
+	args, err := expandAlias(words)
+	if err != nil || len(args) == 0 {
+		return nil, false
+	}
+	return c.completeCommand(args, cur)
+}
+
+// completeCommand completes the arguments of a command
+func (c *completion) completeCommand(args []string, cur string) ([]candidate, bool) {
+	cmd, ok := lookupCommand(args[0])
+	if !ok {
+		// Plugins complete file names
+		_, plugin := findPlugin(args[0])
+		return nil, plugin && c.mode == modeCLI
+	}
+	args = args[1:]
+	prev := ""
+	if len(args) > 0 {
+		prev = cmd.longFlag(args[len(args)-1])
+	}
+
+	// Flags and their values
+	switch {
+	case prev == "--where":
+		return filter(c.filterCandidates(cur), cur), false
+	case prev == "--priority" || prev == "--project":
+		return filter(c.valueCandidates(prev[2:]), cur), false
+	case prev == "--tag":
+		return filter(c.valueCandidates("tags"), cur), false
+	case prev == "--due":
+		return filter(c.valueCandidates("due"), cur), false
+	case prev == "--set":
+		return filter(c.assignmentCandidates(cur), cur), false
+	case strings.HasPrefix(cur, "-") && len(cmd.flags) > 0:
+		return filter(cmd.flagCandidates(), cur), false
+	}
+
+	switch cmd.name {
+	case "complete":
+		return filter(c.itemCandidates(func(item todo.Item) bool { return !item.Done }), cur), false
+	case "uncomplete":
+		return filter(c.itemCandidates(func(item todo.Item) bool { return item.Done }), cur), false
+	case "delete", "edit":
+		if len(args) == 0 {
+			return filter(c.itemCandidates(nil), cur), false
+		}
+	case "set":
+		if len(args) == 0 {
+			return filter(c.itemCandidates(nil), cur), false
+		}
+		return filter(c.assignmentCandidates(cur), cur), false
+	case "move-to":
+		switch len(args) {
+		case 0:
+			return filter(listCandidates(), cur), false
+		case 1:
+			return filter(c.itemCandidates(nil), cur), false
+		}
+	case "use":
+		if len(args) == 0 {
+			return filter(listCandidates(), cur), false
+		}
+	case "list":
+		if prev == "--sort" {
+			return filter(c.sortCandidates(cur), cur), false
+		}
+		return filter(c.filterCandidates(cur), cur), false
+	case "export", "import":
+		if prev == "--format" {
+			return filter(formatCandidates(), cur), false
+		}
+		if prev == "--map" {
+			return nil, false
+		}
+		return nil, true
+	case "sync-md", "batch":
+		return nil, true
+	case "migrate":
+		return filter(cmd.flagCandidates(), cur), false
+	case "field":
+		if len(args) == 0 {
+			return filter(words("add", "list", "rm"), cur), false
+		}
+		switch {
+		case args[0] == "rm" && len(args) == 1:
+			return filter(c.fieldCandidates(), cur), false
+		case args[0] == "add" && len(args) == 2:
+			return filter(words("string", "number", "date", "enum"), cur), false
+		}
+	case "config":
+		if len(args) == 0 {
+			return filter(words("list", "get", "set", "path", "file"), cur), false
+		}
+		if (args[0] == "get" || args[0] == "set") && len(args) == 1 {
+			return filter(configKeyCandidates(), cur), false
+		}
+		if args[0] == "set" && len(args) == 2 {
+			return filter(configValueCandidates(args[1]), cur), false
+		}
+	case "completion":
+		if len(args) == 0 {
+			return filter(words("bash", "zsh", "fish"), cur), false
+		}
+	case "help":
+		if len(args) == 0 {
+			return filter(c.commandCandidates(), cur), false
+		}
+	}
+	return nil, false
+}
+
+// itemCandidates returns the numbers of the items accepted by keep, with
+// their text as description
+func (c *completion) itemCandidates(keep func(todo.Item) bool) []candidate {
+	var result []candidate
+	for i, item := range c.todoList().Items {
+		if keep == nil || keep(item) {
+			result = append(result, candidate{fmt.Sprint(i + 1), item.Text})
+		}
+	}
+	return result
+}
+
+// assignmentCandidates completes name=value arguments of the set command
+func (c *completion) assignmentCandidates(cur string) []candidate {
+	name, _, ok := strings.Cut(cur, "=")
+	if !ok {
+		var result []candidate
+		for _, n := range []string{"text", "project", "priority", "due", "tags", "recurrence"} {
+			result = append(result, candidate{value: n + "="})
+		}
+		for _, def := range c.todoList().FieldDefs() {
+			result = append(result, candidate{def.Name + "=", string(def.Type)})
+		}
+		return result
+	}
+	return prefixed(name+"=", c.valueCandidates(name))
+}
+
+// filterCandidates completes terms of a --where filter
+func (c *completion) filterCandidates(cur string) []candidate {
+	name, _, ok := strings.Cut(cur, "=")
+	if !ok {
+		name, _, ok = strings.Cut(cur, ":")
+	}
+	if ok {
+		return prefixed(cur[:len(name)+1], c.valueCandidates(name))
+	}
+
+	result := words("done", "pending", "tag=", "project=", "priority=", "text~", "due<=")
+	for _, def := range c.todoList().FieldDefs() {
+		result = append(result, candidate{def.Name + "=", string(def.Type)})
+	}
+	return result
+}
+
+// sortCandidates completes the comma-separated keys of --sort
+func (c *completion) sortCandidates(cur string) []candidate {
+	keys := []string{"text", "done", "priority", "project", "due", "created", "completed"}
+	for _, def := range c.todoList().FieldDefs() {
+		keys = append(keys, def.Name)
+	}
+
+	prefix := ""
+	if i := strings.LastIndex(cur, ","); i >= 0 {
+		prefix = cur[:i+1]
+	}
+	var result []candidate
+	for _, key := range keys {
+		result = append(result, candidate{value: prefix + key}, candidate{value: prefix + "-" + key})
+	}
+	return result
+}
+
+// valueCandidates returns known values of an item property or custom field
+func (c *completion) valueCandidates(name string) []candidate {
+	list := c.todoList()
+	switch name {
+	case "tag", "tags":
+		return words(collect(list.Items, func(item todo.Item) []string { return item.Tags })...)
+	case "project":
+		return words(collect(list.Items, func(item todo.Item) []string { return []string{item.Project} })...)
+	case "priority":
+		return words("high", "medium", "low")
+	case "done":
+		return words("true", "false")
+	case "due":
+		return words("today", "tomorrow")
+	}
+
+	def, ok := list.FieldDef(name)
+	if !ok {
+		return nil
+	}
+	if def.Type == todo.FieldEnum {
+		return words(def.Values...)
+	}
+	return words(collect(list.Items, func(item todo.Item) []string { return []string{item.Fields[name]} })...)
+}
+
+// fieldCandidates returns the custom fields of the list
+func (c *completion) fieldCandidates() []candidate {
+	var result []candidate
+	for _, def := range c.todoList().FieldDefs() {
+		result = append(result, candidate{def.Name, string(def.Type)})
+	}
+	return result
+}
+
+// commandCandidates returns the built-in commands available in the mode
+// being completed, the user-defined aliases and, on the command line, the
+// plugins
+func (c *completion) commandCandidates() []candidate {
+	var result []candidate
+	for _, cmd := range commands {
+		if cmd.modes&c.mode == 0 {
+			continue
+		}
+		result = append(result, candidate{cmd.name, cmd.summary})
+		for _, alias := range cmd.aliases {
+			result = append(result, candidate{alias, cmd.summary})
+		}
+	}
+
+	names := make([]string, 0, len(settings.Aliases))
+	for name := range settings.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		description := settings.Aliases[name]
+		if isView(name) {
+			description = "View: " + description
+		}
+		result = append(result, candidate{name, description})
+	}
+
+	if c.mode == modeCLI {
+		for _, p := range findPlugins() {
+			result = append(result, candidate{p.name, "Plugin " + p.path})
+		}
+	}
+	return result
+}
+
+// isView reports whether an alias expands to a list command, making it a
+// saved view
+func isView(alias string) bool {
+	args, err := expandAlias([]string{alias})
+	if err != nil || len(args) == 0 {
+		return false
+	}
+	cmd, ok := lookupCommand(args[0])
+	return ok && cmd.name == "list"
+}
+
+// listCandidates returns the names of the named lists
+func listCandidates() []candidate {
+	names, _ := config.ListNames()
+	return words(names...)
+}
+
+// formatCandidates returns the registered format names
+func formatCandidates() []candidate {
+	return words(todo.FormatNames()...)
+}
+
+// configKeyCandidates returns the config keys and defined aliases
+func configKeyCandidates() []candidate {
+	result := words(config.Keys...)
+	for _, pair := range settings.List() {
+		if strings.HasPrefix(pair[0], "alias.") {
+			result = append(result, candidate{pair[0], pair[1]})
+		}
+	}
+	return append(result, candidate{value: "alias."})
+}
+
+// configValueCandidates returns the possible values of a config key
+func configValueCandidates(key string) []candidate {
+	switch key {
+	case "list":
+		return listCandidates()
+	case "format":
+		return formatCandidates()
+	case "color":
+		return words(config.ColorAuto, config.ColorAlways, config.ColorNever)
+	}
+	return nil
+}
+
+// flagCandidates returns the flags of a command, described by their help
+func (c *command) flagCandidates() []candidate {
+	return optionCandidates(c.flags)
+}
+
+// longFlag returns the long form of a short flag of the command, so that
+// completion of its value need only check one name
+func (c *command) longFlag(arg string) string {
+	if len(arg) != 2 || arg[0] != '-' {
+		return arg
+	}
+	for _, f := range c.flags {
+		if f.Short == rune(arg[1]) {
+			return "--" + f.Name
+		}
+	}
+	return arg
+}
+
+// optionCandidates returns the long and short names of options
+func optionCandidates(opts []getopt.Option) []candidate {
+	var result []candidate
+	for _, opt := range opts {
+		result = append(result, candidate{"--" + opt.Name, opt.Help})
+		if opt.Short != 0 {
+			result = append(result, candidate{"-" + string(opt.Short), opt.Help})
+		}
+	}
+	return result
+}
+
+// words returns candidates without descriptions, skipping empty values
+func words(values ...string) []candidate {
+	var result []candidate
+	for _, v := range values {
+		if v != "" {
+			result = append(result, candidate{value: v})
+		}
+	}
+	return result
+}
+
+// prefixed prepends prefix to the value of every candidate
+func prefixed(prefix string, candidates []candidate) []candidate {
+	for i := range candidates {
+		candidates[i].value = prefix + candidates[i].value
+	}
+	return candidates
+}
+
+// collect returns the distinct, sorted values extracted from items and
+// their subtasks
+func collect(items []todo.Item, values func(todo.Item) []string) []string {
+	seen := make(map[string]bool)
+	var walk func(items []todo.Item)
+	walk = func(items []todo.Item) {
+		for _, item := range items {
+			for _, v := range values(item) {
+				seen[v] = true
+			}
+			walk(item.Subtasks)
+		}
+	}
+	walk(items)
+
+	result := make([]string, 0, len(seen))
+	for v := range seen {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// filter keeps the candidates starting with prefix, dropping duplicates
+func filter(candidates []candidate, prefix string) []candidate {
+	seen := make(map[string]bool)
+	var result []candidate
+	for _, c := range candidates {
+		if strings.HasPrefix(c.value, prefix) && !seen[c.value] {
+			seen[c.value] = true
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+const bashCompletion = `# bash completion for todo
+# Load with: source <(todo completion bash)
+
+_todo() {
+    local cur words cword
+    if declare -F _get_comp_words_by_ref >/dev/null 2>&1; then
+        _get_comp_words_by_ref -n =: cur words cword
+    else
+        # Split the line ourselves, as COMP_WORDS is broken up at '=' and ':'
+        local line=${COMP_LINE:0:COMP_POINT}
+        read -ra words <<< "$line"
+        [[ -z $line || $line == *[[:space:]] ]] && words+=("")
+        cword=$(( ${#words[@]} - 1 ))
+        cur=${words[cword]}
+    fi
+
+    local line files=0
+    COMPREPLY=()
+    while IFS= read -r line; do
+        if [[ $line == ":files" ]]; then
+            files=1
+            continue
+        fi
+        COMPREPLY+=("${line%%$'\t'*}")
+    done < <("${words[0]}" __complete "${words[@]:1:cword-1}" "$cur" 2>/dev/null)
+
+    if (( files )); then
+        compopt -o default 2>/dev/null
+    fi
+
+    # bash splits words at '=' and ':', so drop the part it already shows
+    if [[ $cur == *[=:]* ]]; then
+        local prefix=${cur%"${cur##*[=:]}"}
+        COMPREPLY=("${COMPREPLY[@]#"$prefix"}")
+    fi
+    if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == *= ]]; then
+        compopt -o nospace 2>/dev/null
+    fi
+}
+
+complete -F _todo todo
+`
+
+const zshCompletion = `#compdef todo
+# zsh completion for todo
+# Load with: source <(todo completion zsh), or save as _todo in $fpath
+
+_todo() {
+    local -a candidates
+    local line value files=0
+    for line in "${(@f)$(${words[1]} __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}"; do
+        [[ -z $line ]] && continue
+        if [[ $line == :files ]]; then
+            files=1
+            continue
+        fi
+        value=${line%%$'\t'*}
+        value=${value//:/\\:}
+        if [[ $line == *$'\t'* ]]; then
+            candidates+=("$value:${line#*$'\t'}")
+        else
+            candidates+=("$value")
+        fi
+    done
+
+    if (( files )); then
+        _files
+        return
+    fi
+    _describe -t values todo candidates
+}
+
+if [[ $funcstack[1] == _todo ]]; then
+    _todo "$@"
+else
+    compdef _todo todo
+fi
+`
+
+const fishCompletion = `# fish completion for todo
+# Load with: todo completion fish | source
+
+function __todo_complete
+    set -l tokens (commandline -opc) (commandline -ct | string collect --allow-empty)
+    set -l out (command $tokens[1] __complete $tokens[2..-1] 2>/dev/null)
+    if contains -- :files $out
+        __fish_complete_path (commandline -ct)
+        return
+    end
+    printf '%s\n' $out
+end
+
+complete -c todo -e
+complete -c todo -f -a '(__todo_complete)'
+`
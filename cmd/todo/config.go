@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/config"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/shellwords"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// settings holds the user configuration loaded at startup
+var settings = config.Default()
+
+// loadSettings reads the user config file, if there is one
+func loadSettings() error {
+	path, err := config.Path()
+	if err != nil {
+		return err
+	}
+	settings, err = config.Load(path)
+	return err
+}
+
+// saveSettings writes the settings to the config file at path. During a
+// batch the write is held back until the batch ends, and then writes the
+// settings as they are by then.
+func saveSettings(path string) error {
+	if writeLater(path, func() error { return settings.Save(path) }) {
+		return nil
+	}
+	return settings.Save(path)
+}
+
+// newList returns an empty list with the custom fields declared in the
+// config
+func newList() *todo.List {
+	list := todo.NewList()
+	// The declarations were validated when the config was loaded
+	list.Declare(settings.Fields...)
+	return list
+}
+
+// newFileStore returns a store for the todo file whose lists have the
+// custom fields declared in the config
+func newFileStore(filename string) *todo.FileStore {
+	store := todo.NewFileStore(filename)
+	store.Fields = settings.Fields
+	return store
+}
+
+// expandAlias replaces a user-defined alias in the first argument with the
+// command line it stands for. Built-in commands cannot be overridden.
+func expandAlias(args []string) ([]string, error) {
+	return settings.ExpandAlias(args, isBuiltinCommand)
+}
+
+// handleConfig shows and changes the user configuration. It does not need
+// the todo list, so it runs before the list is loaded.
+func handleConfig(filename string, source config.Source, args []string) error {
+	path, err := config.Path()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "list", "ls":
+		fmt.Printf("# %s\n", path)
+		for _, pair := range settings.List() {
+			fmt.Printf("%s = %s\n", pair[0], pair[1])
+		}
+		return nil
+
+	case "get":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: todo config get <key>")
+		}
+		value, err := settings.Get(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+
+	case "set":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: todo config set <key> [value]")
+		}
+		name, isAlias := strings.CutPrefix(args[1], "alias.")
+		if isAlias && isBuiltinCommand(name) {
+			return fmt.Errorf("cannot define alias %q: it is a built-in command", name)
+		}
+		// An alias is split into words when it is expanded, so words given
+		// separately are quoted to keep them apart; a single argument is
+		// the definition as written
+		value := strings.Join(args[2:], " ")
+		if isAlias && len(args) > 3 {
+			value = shellwords.Join(args[2:])
+		}
+		if err := settings.Set(args[1], value); err != nil {
+			return err
+		}
+		if err := saveSettings(path); err != nil {
+			return err
+		}
+		value, _ = settings.Get(args[1])
+		fmt.Printf("%s = %s\n", args[1], value)
+		return nil
+
+	case "path":
+		fmt.Println(path)
+		return nil
+
+	case "file":
+		fmt.Printf("%s (from %s)\n", filename, source)
+		return nil
+	}
+
+	return fmt.Errorf("unknown config command: %s (expected list, get, set, path or file)", args[0])
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/config"
+)
+
+func TestConfigSetAlias(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	saved := settings
+	t.Cleanup(func() { settings = saved })
+	settings = config.Default()
+
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{
+			// Words the shell already split keep their boundaries
+			[]string{"done", "--where", "tag:release priority=high"},
+			[]string{"done", "--where", "tag:release priority=high"},
+		},
+		{
+			// A single argument is the definition as written
+			[]string{"list --where 'due<=today' --sort -priority"},
+			[]string{"list", "--where", "due<=today", "--sort", "-priority"},
+		},
+	}
+	for _, tc := range tests {
+		args := append([]string{"set", "alias.ship"}, tc.args...)
+		if err := handleConfig("todos.json", config.SourceDefault, args); err != nil {
+			t.Fatalf("Failed to set alias: %v", err)
+		}
+
+		// Read the alias back from the file, as the next command would
+		if err := loadSettings(); err != nil {
+			t.Fatalf("Failed to load settings: %v", err)
+		}
+		expanded, err := expandAlias([]string{"ship"})
+		if err != nil {
+			t.Fatalf("Failed to expand alias: %v", err)
+		}
+		if !reflect.DeepEqual(expanded, tc.expected) {
+			t.Errorf("Expected %q, got %q", tc.expected, expanded)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/getopt"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// handleList displays the todo list, or with --where and --sort the matching
+// items in the requested order, numbered by their position in the list.
+// With --all-lists the items of every named list are shown.
+func handleList(todoList *todo.List, opts *getopt.Result) error {
+	where := strings.TrimSpace(opts.Get("where") + " " + strings.Join(opts.Args, " "))
+	sortBy := opts.Get("sort")
+
+	if opts.Bool("all-lists") {
+		return listAllLists(where, sortBy)
+	}
+
+	p := newPrinter(todoList)
+	if where == "" && sortBy == "" {
+		p.printList()
+		return nil
+	}
+
+	indices, err := todoList.Query(where, sortBy)
+	if err != nil {
+		return err
+	}
+	if len(indices) == 0 {
+		fmt.Println("No matching items")
+		return nil
+	}
+
+	fmt.Printf("%d of %d item(s):\n", len(indices), todoList.Count())
+	for _, i := range indices {
+		p.printItem(fmt.Sprintf("%d. ", i+1), todoList.Items[i])
+	}
+	return nil
+}
+
+// handleSet assigns fields of an item from name=value arguments
+func handleSet(todoList *todo.List, filename string, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: todo set <n> <name=value>...")
+	}
+
+	index, err := parseItemNumber(args[0])
+	if err != nil {
+		return err
+	}
+
+	err = todoList.Transaction(func(tx *todo.Tx) error {
+		if index >= tx.Count() {
+			return fmt.Errorf("item index out of range")
+		}
+		before := tx.Items[index].Clone()
+		for _, arg := range args[1:] {
+			name, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				return fmt.Errorf("invalid assignment %q (expected name=value)", arg)
+			}
+			if err := tx.Set(index, name, value); err != nil {
+				return err
+			}
+		}
+		if err := runHook(tx.List, filename, hookModify, index, &before); err != nil {
+			return err
+		}
+		return saveTodos(tx.List, filename)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated item #%d\n", index+1)
+	return nil
+}
+
+// handleField manages the custom field definitions of the list
+func handleField(todoList *todo.List, filename string, args []string) error {
+	if len(args) == 0 {
+		args = []string{"list"}
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "list", "ls":
+		defs := todoList.FieldDefs()
+		if len(defs) == 0 {
+			fmt.Println("No custom fields defined")
+			return nil
+		}
+		for _, def := range defs {
+			if todoList.Declared(def.Name) {
+				fmt.Printf("%s (config)\n", def)
+			} else {
+				fmt.Println(def)
+			}
+		}
+		return nil
+
+	case "add":
+		if len(args) < 3 || len(args) > 4 {
+			return fmt.Errorf("usage: todo field add <name> <type> [values]")
+		}
+		values := ""
+		if len(args) == 4 {
+			values = args[3]
+		}
+		def, err := todo.ParseFieldDef(args[1], args[2], values)
+		if err != nil {
+			return err
+		}
+		if err := todoList.DefineField(def); err != nil {
+			return err
+		}
+		if err := saveTodos(todoList, filename); err != nil {
+			return err
+		}
+		fmt.Printf("Defined field %s\n", def)
+		return nil
+
+	case "rm", "remove", "delete":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: todo field rm <name>")
+		}
+		if err := todoList.RemoveField(strings.ToLower(args[1])); err != nil {
+			return err
+		}
+		if err := saveTodos(todoList, filename); err != nil {
+			return err
+		}
+		fmt.Printf("Removed field %s\n", args[1])
+		return nil
+	}
+
+	return fmt.Errorf("unknown field command: %s (expected add, list or rm)", args[0])
+}
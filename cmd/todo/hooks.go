@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/config"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// Hook names, which are the names of the executables in the hooks directory
+const (
+	hookAdd      = "on-add"
+	hookComplete = "on-complete"
+	hookModify   = "on-modify"
+	hookDelete   = "on-delete"
+)
+
+// hookEnv names the hook being run. It is set for hooks, and todo commands
+// they run skip hooks, so that a hook cannot trigger itself.
+const hookEnv = "TODO_HOOK"
+
+// hookStderr receives what hooks that succeed write to stderr
+var hookStderr io.Writer = os.Stderr
+
+// listHook returns the hook function given to code that changes the list
+// in filename itself, such as the server and the full-screen interface. It
+// runs the same hooks as the commands: on-complete for items marked as
+// done and on-modify for other changes.
+func listHook(filename string) todo.HookFunc {
+	return func(list *todo.List, change todo.EventType, index int, before *todo.Item) error {
+		switch change {
+		case todo.ItemAdded:
+			return runHook(list, filename, hookAdd, index, nil)
+		case todo.ItemCompleted:
+			return runHook(list, filename, hookComplete, index, nil)
+		case todo.ItemDeleted:
+			return runHook(list, filename, hookDelete, index, nil)
+		}
+		return runHook(list, filename, hookModify, index, before)
+	}
+}
+
+// runHook runs the named hook for the item at index, if the hooks directory
+// has an executable of that name. The hook reads the item as JSON on stdin;
+// on-modify reads the item before the change on one line and after it on
+// the next. A non-zero exit status vetoes the change, with what the hook
+// wrote to stderr as the error. Except for on-delete, the hook may write
+// the item as JSON on stdout to change it.
+func runHook(list *todo.List, filename, name string, index int, before *todo.Item) error {
+	path, ok := findHook(name)
+	if !ok {
+		return nil
+	}
+
+	var stdin bytes.Buffer
+	enc := json.NewEncoder(&stdin)
+	enc.SetEscapeHTML(false)
+	if before != nil {
+		if err := enc.Encode(before); err != nil {
+			return fmt.Errorf("failed to encode item for %s hook: %w", name, err)
+		}
+	}
+	if err := enc.Encode(list.Items[index]); err != nil {
+		return fmt.Errorf("failed to encode item for %s hook: %w", name, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Stdin = &stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(),
+		hookEnv+"="+name,
+		config.FileEnv+"="+filename,
+		"TODO_ITEM="+strconv.Itoa(index+1),
+	)
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String()); msg != "" && errors.As(err, &exitErr) {
+			return fmt.Errorf("%s hook: %s", name, msg)
+		}
+		return fmt.Errorf("%s hook failed: %w", name, err)
+	}
+	hookStderr.Write(stderr.Bytes())
+
+	if name == hookDelete || len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil
+	}
+	var item todo.Item
+	if err := json.Unmarshal(stdout.Bytes(), &item); err != nil {
+		return fmt.Errorf("%s hook printed an invalid item: %w", name, err)
+	}
+	if err := list.Replace(index, item); err != nil {
+		return fmt.Errorf("%s hook printed an invalid item: %w", name, err)
+	}
+	return nil
+}
+
+// findHook returns the path of the named hook if it exists and is
+// executable. Hooks are skipped in todo commands run by a hook.
+func findHook(name string) (string, bool) {
+	if os.Getenv(hookEnv) != "" {
+		return "", false
+	}
+	dir, err := config.HooksDir()
+	if err != nil {
+		return "", false
+	}
+	path := filepath.Join(dir, name)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+		return "", false
+	}
+	return path, true
+}
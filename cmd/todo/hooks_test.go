@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// setupHooks points the config directory at a temporary one and returns a
+// function that writes a hook script into its hooks directory.
+func setupHooks(t *testing.T) func(name, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are shell scripts in these tests")
+	}
+
+	base := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", base)
+	t.Setenv(hookEnv, "")
+	dir := filepath.Join(base, "todo", "hooks")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create hooks directory: %v", err)
+	}
+
+	return func(name, script string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+			t.Fatalf("Failed to write hook: %v", err)
+		}
+	}
+}
+
+func TestHookVeto(t *testing.T) {
+	writeHook := setupHooks(t)
+	writeHook(hookAdd, "echo 'tasks need a project' >&2\nexit 1\n")
+	writeHook(hookDelete, "exit 3\n")
+
+	list := todo.NewList()
+	list.Add("Buy milk")
+	filename := filepath.Join(t.TempDir(), "todos.json")
+
+	err := list.Transaction(func(tx *todo.Tx) error {
+		index := tx.Add("Write report")
+		return runHook(tx.List, filename, hookAdd, index, nil)
+	})
+	if err == nil || err.Error() != "on-add hook: tasks need a project" {
+		t.Errorf("Expected the hook's message as the error, got %v", err)
+	}
+	if list.Count() != 1 {
+		t.Errorf("Expected the vetoed item not to be added, got %d items", list.Count())
+	}
+
+	// The server and the full-screen interface run hooks through listHook
+	err = listHook(filename)(list, todo.ItemDeleted, 0, nil)
+	if err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("Expected the exit status as the error, got %v", err)
+	}
+}
+
+func TestHookModify(t *testing.T) {
+	writeHook := setupHooks(t)
+	// Replace the text of the item after the change, checking the item
+	// before it is on the first line
+	writeHook(hookModify, `read before
+read after
+case "$before" in *'"text":"Buy milk"'*) ;; *) exit 1 ;; esac
+echo "$after" | sed 's/"text":"[^"]*"/"text":"Buy oat milk"/'
+`)
+	writeHook(hookComplete, "exit 0\n")
+
+	list := todo.NewList()
+	list.Add("Buy milk")
+	filename := filepath.Join(t.TempDir(), "todos.json")
+
+	before := list.Items[0].Clone()
+	if err := list.Set(0, "project", "home"); err != nil {
+		t.Fatalf("Failed to set project: %v", err)
+	}
+	if err := runHook(list, filename, hookModify, 0, &before); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if item := list.Items[0]; item.Text != "Buy oat milk" || item.Project != "home" {
+		t.Errorf("Expected the item printed by the hook, got %+v", item)
+	}
+
+	// A hook that prints nothing leaves the item alone
+	list.Complete(0)
+	if err := listHook(filename)(list, todo.ItemCompleted, 0, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if item := list.Items[0]; item.Text != "Buy oat milk" || !item.Done {
+		t.Errorf("Expected the item to be unchanged, got %+v", item)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/config"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/lineedit"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/shellwords"
+)
+
+// historyFilename is the name of the interactive history file in the data
+// directory
+const historyFilename = "history"
+
+// runInteractive reads commands until quit or end of input, showing the
+// list before each prompt. On a terminal, lines can be edited, earlier
+// commands recalled and words completed with Tab.
+func runInteractive(e *env) {
+	editor := lineedit.New(os.Stdin, os.Stdout)
+	editor.Complete = interactiveCompleter(e)
+	if dir, err := config.DataDir(); err == nil && editor.Editing() {
+		if err := editor.History.Load(filepath.Join(dir, historyFilename)); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	fmt.Printf("Todo Interactive Mode (v%s)\n", version)
+	fmt.Println("Type 'help' for available commands or 'quit' to exit.")
+
+	for {
+		fmt.Printf("\n%s\n", e.list)
+
+		line, err := editor.ReadLine("> ")
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			continue
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Printf("Error reading input: %v\n", err)
+			break
+		}
+
+		words, err := shellwords.Split(line)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
+		if len(words) == 0 {
+			continue
+		}
+		if editor.Editing() {
+			if err := editor.History.Add(line); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}
+
+		parts, err := expandAlias(words)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
+
+		err = runCommand(e, parts)
+		if errors.Is(err, errQuit) {
+			fmt.Println("Goodbye!")
+			return
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
+
+// interactiveCompleter completes commands and their arguments from the
+// list being edited
+func interactiveCompleter(e *env) lineedit.Completer {
+	return func(head string) (int, []lineedit.Candidate) {
+		start := shellwords.WordStart(head)
+		words, err := shellwords.Split(head[:start])
+		if err != nil {
+			return 0, nil
+		}
+
+		// Match the partly typed word without its quotes, closing an open one
+		cur := head[start:]
+		for _, closing := range []string{"", `"`, "'"} {
+			if unquoted, err := shellwords.Split(cur + closing); err == nil && len(unquoted) == 1 {
+				cur = unquoted[0]
+				break
+			}
+		}
+
+		c := &completion{list: e.list, mode: modeInteractive}
+		candidates, files := c.completeLine(words, cur)
+		if files {
+			candidates = append(candidates, fileCandidates(cur)...)
+		}
+
+		result := make([]lineedit.Candidate, len(candidates))
+		for i, cand := range candidates {
+			text := cand.value
+			if !strings.HasSuffix(text, "/") {
+				text = shellwords.Quote(text)
+			}
+			result[i] = lineedit.Candidate{Text: text, Help: cand.desc}
+		}
+		return start, result
+	}
+}
+
+// fileCandidates returns the files and directories starting with prefix,
+// directories ending in a slash
+func fileCandidates(prefix string) []candidate {
+	matches, _ := filepath.Glob(globEscape(prefix) + "*")
+	var result []candidate
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			match += string(filepath.Separator)
+		}
+		result = append(result, candidate{value: match})
+	}
+	return result
+}
+
+// globEscape escapes the characters filepath.Match treats specially
+func globEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/config"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// handleLists shows the named lists with their item counts, marking the
+// list in use
+func handleLists(filename string) error {
+	names, err := config.ListNames()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Println("No named lists yet; create one with 'todo --list <name> add <text>'")
+		return nil
+	}
+
+	for _, name := range names {
+		list, path, err := loadNamedList(name)
+		if err != nil {
+			return err
+		}
+		marker := " "
+		if sameFile(path, filename) {
+			marker = "*"
+		}
+		fmt.Printf("%s %s (%d pending, %d total)\n", marker, name, list.CountPending(), list.Count())
+	}
+	return nil
+}
+
+// handleUse selects the named list used when no file or list is given, or
+// shows the current selection. It only changes the config file.
+func handleUse(args []string) error {
+	path, err := config.Path()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		if settings.CurrentList == "" {
+			fmt.Println("No list selected; using the default todo file")
+		} else {
+			fmt.Println(settings.CurrentList)
+		}
+		return nil
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("usage: todo use <list>")
+	}
+
+	name := args[0]
+	if err := settings.Set("list", name); err != nil {
+		return err
+	}
+	if err := saveSettings(path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Now using list %s\n", name)
+	return nil
+}
+
+// handleMoveTo moves an item from the current list to another named list
+func handleMoveTo(todoList *todo.List, filename string, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: todo move-to <list> <n>")
+	}
+
+	index, err := parseItemNumber(args[1])
+	if err != nil {
+		return err
+	}
+	if index >= todoList.Count() {
+		return fmt.Errorf("item index out of range")
+	}
+
+	target, targetFile, err := loadNamedList(args[0])
+	if err != nil {
+		return err
+	}
+	if sameFile(targetFile, filename) {
+		return fmt.Errorf("item #%d is already in list %s", index+1, args[0])
+	}
+
+	// Save the target first so a failure never loses the item; if saving
+	// the source then fails, the item is left in both lists
+	item := todoList.Items[index].Clone()
+	err = todoList.Transaction(func(tx *todo.Tx) error {
+		if err := runHook(tx.List, filename, hookDelete, index, nil); err != nil {
+			return err
+		}
+		if err := tx.Delete(index); err != nil {
+			return err
+		}
+		err := target.Transaction(func(targetTx *todo.Tx) error {
+			targetTx.Append(item)
+			if err := runHook(targetTx.List, targetFile, hookAdd, targetTx.Count()-1, nil); err != nil {
+				return err
+			}
+			return saveTodos(targetTx.List, targetFile)
+		})
+		if err != nil {
+			return err
+		}
+		return saveTodos(tx.List, filename)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Moved %q to list %s (item #%d)\n", item.Text, args[0], target.Count())
+	return nil
+}
+
+// listAllLists prints the items of every named list matching the filter,
+// each prefixed with its list name
+func listAllLists(where, sortBy string) error {
+	names, err := config.ListNames()
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, name := range names {
+		list, _, err := loadNamedList(name)
+		if err != nil {
+			return err
+		}
+		indices, err := list.Query(where, sortBy)
+		if err != nil {
+			return fmt.Errorf("list %s: %w", name, err)
+		}
+
+		p := newPrinter(list)
+		for _, i := range indices {
+			p.printItem(fmt.Sprintf("%s: %d. ", name, i+1), list.Items[i])
+		}
+		total += len(indices)
+	}
+
+	if total == 0 {
+		fmt.Println("No matching items")
+	}
+	return nil
+}
+
+// loadNamedList loads a named list, which is empty if it does not exist yet.
+// During a batch, a list changed earlier in the batch is returned as it is
+// in memory.
+func loadNamedList(name string) (*todo.List, string, error) {
+	path, err := config.ListFile(name)
+	if err != nil {
+		return nil, "", err
+	}
+	if list, ok := deferredSaves[path]; ok {
+		return list, path, nil
+	}
+
+	list := newList()
+	if err := loadTodosIfExists(list, path); err != nil {
+		return nil, "", fmt.Errorf("list %s: %w", name, err)
+	}
+	return list, path, nil
+}
+
+// sameFile reports whether two paths refer to the same file
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA == nil && errB == nil && absA == absB {
+		return true
+	}
+
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
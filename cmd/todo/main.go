@@ -1,19 +1,20 @@
 package main
 
 import (
-	"bufio"
-	"flag"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
+	"github.com/kai-xlr/CLI-Task-Manager/internal/config"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/getopt"
 	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
 )
 
 const (
-	// Default filename for storing todos
-	todoFile = "todos.json"
 	// Version of the application
 	version = "1.0.0"
 )
@@ -21,13 +22,18 @@ const (
 // Configuration holds the application configuration
 type Config struct {
 	TodoFile    string
+	List        string
 	Interactive bool
 	Help        bool
 	Version     bool
 }
 
 func main() {
-	config := parseFlags()
+	config, args, err := parseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\nRun 'todo help' for usage information\n", err)
+		os.Exit(2)
+	}
 
 	// Handle version flag
 	if config.Version {
@@ -41,49 +47,122 @@ func main() {
 		return
 	}
 
+	if err := loadSettings(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Completion candidates do not need the todo file resolved
+	if len(args) > 0 && args[0] == "__complete" {
+		handleCompletionCandidates(args[1:])
+		return
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	filename, source, err := resolveTodoFile(config, dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	config.TodoFile = filename
+
+	args, err = expandAlias(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	e := &env{filename: config.TodoFile, source: source}
+
+	// Some commands, such as config and migrate, do not need the todo list,
+	// and neither do plugins
+	if cmd, ok := lookupCommand(firstArg(args)); (!ok && len(args) > 0 || ok && cmd.noList) && !config.Interactive {
+		if err := runCommand(e, args); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+
 	// Initialize and load todo list
-	todoList := todo.NewList()
-	if err := loadTodosIfExists(todoList, config.TodoFile); err != nil {
+	e.list = newList()
+	if err := loadTodosIfExists(e.list, config.TodoFile); err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading todos: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Handle interactive mode
+	// Handle interactive mode. Commands piped in run as a batch, without the
+	// list and prompt before each one.
 	if config.Interactive {
-		runInteractive(todoList, config.TodoFile)
+		if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice == 0 {
+			if err := runBatch(e, os.Stdin, false); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		e.interactive = true
+		runInteractive(e)
 		return
 	}
 
 	// Handle command line arguments
-	args := flag.Args()
 	if len(args) == 0 {
 		// Default action: print the todo list
-		fmt.Print(todoList)
+		newPrinter(e.list).printList()
 		return
 	}
 
 	// Execute the specified command
-	if err := executeCommand(todoList, config.TodoFile, args); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	if err := runCommand(e, args); err != nil {
+		exitWithError(err)
 	}
 }
 
-// parseFlags parses command line flags and returns configuration
-func parseFlags() *Config {
-	config := &Config{}
-	
-	flag.BoolVar(&config.Interactive, "i", false, "Run in interactive mode")
-	flag.BoolVar(&config.Interactive, "interactive", false, "Run in interactive mode")
-	flag.BoolVar(&config.Help, "h", false, "Show help message")
-	flag.BoolVar(&config.Help, "help", false, "Show help message")
-	flag.BoolVar(&config.Version, "v", false, "Show version information")
-	flag.BoolVar(&config.Version, "version", false, "Show version information")
-	flag.StringVar(&config.TodoFile, "f", todoFile, "Todo file path")
-	flag.StringVar(&config.TodoFile, "file", todoFile, "Todo file path")
-
-	flag.Parse()
-	return config
+// exitWithError reports the error of a command and exits, with the exit
+// status of a failed plugin or 1
+func exitWithError(err error) {
+	var status exitStatus
+	if errors.As(err, &status) {
+		os.Exit(int(status))
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
+
+// parseFlags parses the flags before the command and returns the
+// configuration and the remaining arguments
+func parseFlags(args []string) (*Config, []string, error) {
+	set := &getopt.Set{Options: globalFlags, StopAtArg: true}
+	opts, err := set.Parse(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := &Config{
+		TodoFile:    opts.Get("file"),
+		List:        opts.Get("list"),
+		Interactive: opts.Bool("interactive"),
+		Help:        opts.Bool("help"),
+		Version:     opts.Bool("version"),
+	}
+	return config, opts.Args, nil
+}
+
+// resolveTodoFile determines the todo file from the --file and --list flags
+// and the user configuration
+func resolveTodoFile(flags *Config, dir string) (string, config.Source, error) {
+	if flags.List == "" {
+		return settings.ResolveFile(flags.TodoFile, dir)
+	}
+	if flags.TodoFile != "" {
+		return "", "", fmt.Errorf("--file and --list cannot be used together")
+	}
+	path, err := config.ListFile(flags.List)
+	return path, config.SourceListFlag, err
 }
 
 // loadTodosIfExists loads todos from file if it exists
@@ -94,138 +173,175 @@ func loadTodosIfExists(todoList *todo.List, filename string) error {
 	return nil
 }
 
-// executeCommand executes the specified command with arguments
-func executeCommand(todoList *todo.List, filename string, args []string) error {
-	command := strings.ToLower(args[0])
-
-	switch command {
-
-	case "add", "a":
-		return handleAdd(todoList, filename, args[1:])
-		
-	case "list", "ls", "l":
-		return handleList(todoList)
-		
-	case "complete", "done", "c":
-		return handleComplete(todoList, filename, args[1:])
-		
-	case "uncomplete", "undo", "u":
-		return handleUncomplete(todoList, filename, args[1:])
-		
-	case "delete", "remove", "rm", "d":
-		return handleDelete(todoList, filename, args[1:])
-		
-	case "edit", "e":
-		return handleEdit(todoList, filename, args[1:])
-		
-	case "clear":
-		return handleClear(todoList, filename)
-		
-	case "help", "h":
-		printHelp()
-		return nil
-		
-	default:
-		return fmt.Errorf("unknown command: %s\nRun 'todo help' for usage information", command)
+// firstArg returns the first argument, or "" if there is none
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
 	}
+	return args[0]
 }
 
 // Command handlers
 
-// handleAdd adds a new todo item
-func handleAdd(todoList *todo.List, filename string, args []string) error {
-	if len(args) == 0 {
+// handleAdd adds a new todo item, applying the properties given as flags
+func handleAdd(todoList *todo.List, filename string, opts *getopt.Result) error {
+	if len(opts.Args) == 0 {
 		return fmt.Errorf("missing todo text")
 	}
 
-	text := strings.Join(args, " ")
-	index := todoList.Add(text)
-	if err := saveTodos(todoList, filename); err != nil {
-		return err
+	var assignments [][2]string
+	for _, name := range []string{"priority", "project", "due"} {
+		if opts.Has(name) {
+			assignments = append(assignments, [2]string{name, opts.Get(name)})
+		}
+	}
+	if tags := opts.All("tag"); len(tags) > 0 {
+		assignments = append(assignments, [2]string{"tags", strings.Join(tags, ",")})
+	}
+	for _, arg := range opts.All("set") {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("invalid assignment %q (expected name=value)", arg)
+		}
+		assignments = append(assignments, [2]string{name, value})
 	}
 
-	fmt.Printf("Added: %s (item #%d)\n", text, index+1)
-	return nil
-}
+	text := strings.Join(opts.Args, " ")
+	var index int
+	err := todoList.Transaction(func(tx *todo.Tx) error {
+		index = tx.Add(text)
+		for _, a := range assignments {
+			if err := tx.Set(index, a[0], a[1]); err != nil {
+				return err
+			}
+		}
+		if err := runHook(tx.List, filename, hookAdd, index, nil); err != nil {
+			return err
+		}
+		return saveTodos(tx.List, filename)
+	})
+	if err != nil {
+		return err
+	}
 
-// handleList displays the todo list
-func handleList(todoList *todo.List) error {
-	fmt.Print(todoList)
+	fmt.Printf("Added: %s (item #%d)\n", todoList.Items[index].Text, index+1)
 	return nil
 }
 
-// handleComplete marks an item as completed
-func handleComplete(todoList *todo.List, filename string, args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("missing item number")
-	}
-
-	index, err := parseItemNumber(args[0])
+// handleComplete marks items as completed
+func handleComplete(todoList *todo.List, filename string, opts *getopt.Result) error {
+	indices, err := selectItems(todoList, opts)
 	if err != nil {
 		return err
 	}
 
-	if err := todoList.Complete(index); err != nil {
-		return err
+	// Items already done are left alone, and reported as such
+	done := make(map[int]bool)
+	for _, index := range indices {
+		done[index] = todoList.Items[index].Done
 	}
-
-	if err := saveTodos(todoList, filename); err != nil {
+	err = todoList.Transaction(func(tx *todo.Tx) error {
+		for _, index := range indices {
+			if done[index] {
+				continue
+			}
+			if err := tx.Complete(index); err != nil {
+				return err
+			}
+			if err := runHook(tx.List, filename, hookComplete, index, nil); err != nil {
+				return err
+			}
+		}
+		return saveTodos(tx.List, filename)
+	})
+	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Marked item #%d as completed\n", index+1)
+	for _, index := range indices {
+		if done[index] {
+			fmt.Printf("Item #%d is already completed\n", index+1)
+		} else {
+			fmt.Printf("Marked item #%d as completed\n", index+1)
+		}
+	}
 	return nil
 }
 
-// handleUncomplete marks an item as not completed
-func handleUncomplete(todoList *todo.List, filename string, args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("missing item number")
-	}
-
-	index, err := parseItemNumber(args[0])
+// handleUncomplete marks items as not completed
+func handleUncomplete(todoList *todo.List, filename string, opts *getopt.Result) error {
+	indices, err := selectItems(todoList, opts)
 	if err != nil {
 		return err
 	}
 
-	if err := todoList.Uncomplete(index); err != nil {
-		return err
+	// Items not done are left alone, and reported as such
+	pending := make(map[int]bool)
+	for _, index := range indices {
+		pending[index] = !todoList.Items[index].Done
 	}
-
-	if err := saveTodos(todoList, filename); err != nil {
+	err = todoList.Transaction(func(tx *todo.Tx) error {
+		for _, index := range indices {
+			if pending[index] {
+				continue
+			}
+			before := tx.Items[index].Clone()
+			if err := tx.Uncomplete(index); err != nil {
+				return err
+			}
+			if err := runHook(tx.List, filename, hookModify, index, &before); err != nil {
+				return err
+			}
+		}
+		return saveTodos(tx.List, filename)
+	})
+	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Marked item #%d as not completed\n", index+1)
+	for _, index := range indices {
+		if pending[index] {
+			fmt.Printf("Item #%d is not completed\n", index+1)
+		} else {
+			fmt.Printf("Marked item #%d as not completed\n", index+1)
+		}
+	}
 	return nil
 }
 
-// handleDelete removes an item from the list
-func handleDelete(todoList *todo.List, filename string, args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("missing item number")
-	}
-
-	index, err := parseItemNumber(args[0])
+// handleDelete removes items from the list
+func handleDelete(todoList *todo.List, filename string, opts *getopt.Result) error {
+	indices, err := selectItems(todoList, opts)
 	if err != nil {
 		return err
 	}
 
-	// Get the item text before deleting for confirmation message
-	if index >= todoList.Count() {
-		return fmt.Errorf("item index out of range")
+	// Get the item texts before deleting for confirmation messages, and
+	// delete from the end so earlier indices stay valid
+	texts := make([]string, len(indices))
+	for i, index := range indices {
+		texts[i] = todoList.Items[index].Text
 	}
-	itemText := todoList.Items[index].Text
-
-	if err := todoList.Delete(index); err != nil {
+	sorted := append([]int(nil), indices...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	err = todoList.Transaction(func(tx *todo.Tx) error {
+		for _, index := range sorted {
+			if err := runHook(tx.List, filename, hookDelete, index, nil); err != nil {
+				return err
+			}
+			if err := tx.Delete(index); err != nil {
+				return err
+			}
+		}
+		return saveTodos(tx.List, filename)
+	})
+	if err != nil {
 		return err
 	}
 
-	if err := saveTodos(todoList, filename); err != nil {
-		return err
+	for _, text := range texts {
+		fmt.Printf("Deleted: %s\n", text)
 	}
-
-	fmt.Printf("Deleted: %s\n", itemText)
 	return nil
 }
 
@@ -241,15 +357,24 @@ func handleEdit(todoList *todo.List, filename string, args []string) error {
 	}
 
 	newText := strings.Join(args[1:], " ")
-	if err := todoList.Edit(index, newText); err != nil {
-		return err
-	}
-
-	if err := saveTodos(todoList, filename); err != nil {
+	err = todoList.Transaction(func(tx *todo.Tx) error {
+		if index >= tx.Count() {
+			return fmt.Errorf("item index out of range")
+		}
+		before := tx.Items[index].Clone()
+		if err := tx.Edit(index, newText); err != nil {
+			return err
+		}
+		if err := runHook(tx.List, filename, hookModify, index, &before); err != nil {
+			return err
+		}
+		return saveTodos(tx.List, filename)
+	})
+	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Updated item #%d: %s\n", index+1, newText)
+	fmt.Printf("Updated item #%d: %s\n", index+1, todoList.Items[index].Text)
 	return nil
 }
 
@@ -261,8 +386,16 @@ func handleClear(todoList *todo.List, filename string) error {
 		return nil
 	}
 
-	todoList.Clear()
-	if err := saveTodos(todoList, filename); err != nil {
+	err := todoList.Transaction(func(tx *todo.Tx) error {
+		for index := count - 1; index >= 0; index-- {
+			if err := runHook(tx.List, filename, hookDelete, index, nil); err != nil {
+				return err
+			}
+		}
+		tx.Clear()
+		return saveTodos(tx.List, filename)
+	})
+	if err != nil {
 		return err
 	}
 
@@ -272,6 +405,45 @@ func handleClear(todoList *todo.List, filename string) error {
 
 // Helper functions
 
+// selectItems returns the indices of the items named by number, or matched
+// by a --where filter, for commands that act on several items
+func selectItems(todoList *todo.List, opts *getopt.Result) ([]int, error) {
+	if where := opts.Get("where"); where != "" {
+		if len(opts.Args) > 0 {
+			return nil, fmt.Errorf("give either item numbers or --where, not both")
+		}
+		indices, err := todoList.Query(where, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(indices) == 0 {
+			return nil, fmt.Errorf("no items match %q", where)
+		}
+		return indices, nil
+	}
+
+	if len(opts.Args) == 0 {
+		return nil, fmt.Errorf("missing item number")
+	}
+
+	var indices []int
+	seen := make(map[int]bool)
+	for _, arg := range opts.Args {
+		index, err := parseItemNumber(arg)
+		if err != nil {
+			return nil, err
+		}
+		if index >= todoList.Count() {
+			return nil, fmt.Errorf("item index out of range")
+		}
+		if !seen[index] {
+			seen[index] = true
+			indices = append(indices, index)
+		}
+	}
+	return indices, nil
+}
+
 // parseItemNumber parses and validates an item number from string
 func parseItemNumber(s string) (int, error) {
 	num, err := strconv.Atoi(s)
@@ -286,164 +458,18 @@ func parseItemNumber(s string) (int, error) {
 	return num - 1, nil // Convert to 0-based index
 }
 
-// saveTodos saves the todo list to file
+// saveTodos saves the todo list to file, creating its directory if needed.
+// During a batch the save is held back until the batch ends.
 func saveTodos(list *todo.List, filename string) error {
+	if deferredSaves != nil {
+		deferredSaves[filename] = list
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", filename, err)
+	}
 	if err := list.Save(filename); err != nil {
 		return fmt.Errorf("failed to save todos: %w", err)
 	}
 	return nil
 }
-
-func printHelp() {
-	helpText := fmt.Sprintf(`Todo - A simple and efficient command line task manager
-
-Usage:
-  todo [flags] [command] [arguments]
-
-Flags:
-  -h, --help           Show this help message
-  -v, --version        Show version information
-  -i, --interactive    Run in interactive mode
-  -f, --file <path>    Specify todo file path (default: %s)
-
-Commands:
-  add, a <text>        Add a new todo item
-  list, ls, l          List all todo items (default when no command given)
-  complete, done, c <n>    Mark item n as completed
-  uncomplete, undo, u <n>  Mark item n as not completed
-  delete, remove, rm, d <n>  Delete item n
-  edit, e <n> <text>   Edit item n with new text
-  clear                Clear all items
-  help, h              Show this help message
-
-Examples:
-  todo add "Learn Go testing"     # Add a new task
-  todo list                       # List all tasks
-  todo complete 2                 # Mark task 2 as completed
-  todo edit 1 "Updated task"       # Edit task 1
-  todo delete 3                   # Delete task 3
-  todo -i                         # Start interactive mode
-  todo -f my-tasks.json list      # Use custom file
-
-For more information, visit: https://github.com/kai-xlr/CLI-Task-Manager
-`, todoFile)
-	fmt.Print(helpText)
-}
-
-func runInteractive(list *todo.List, filename string) {
-	scanner := bufio.NewScanner(os.Stdin)
-
-	fmt.Printf("Todo Interactive Mode (v%s)\n", version)
-	fmt.Println("Type 'help' for available commands or 'quit' to exit.")
-
-	for {
-		fmt.Printf("\n%s\n", list)
-		fmt.Print("> ")
-
-		if !scanner.Scan() {
-			break
-		}
-
-		input := strings.TrimSpace(scanner.Text())
-		if input == "" {
-			continue
-		}
-
-		parts := strings.Fields(input)
-		cmd := strings.ToLower(parts[0])
-
-		switch cmd {
-		case "add", "a":
-			if len(parts) < 2 {
-				fmt.Println("Error: missing todo text")
-				continue
-			}
-			text := strings.Join(parts[1:], " ")
-			if err := handleAdd(list, filename, []string{text}); err != nil {
-				fmt.Printf("Error: %v\n", err)
-			}
-
-		case "list", "ls", "l":
-			// List is shown at the top of each loop, so just continue
-			continue
-
-		case "complete", "done", "c":
-			if len(parts) < 2 {
-				fmt.Println("Error: missing item number")
-				continue
-			}
-			if err := handleComplete(list, filename, parts[1:]); err != nil {
-				fmt.Printf("Error: %v\n", err)
-			}
-
-		case "uncomplete", "undo", "u":
-			if len(parts) < 2 {
-				fmt.Println("Error: missing item number")
-				continue
-			}
-			if err := handleUncomplete(list, filename, parts[1:]); err != nil {
-				fmt.Printf("Error: %v\n", err)
-			}
-
-		case "delete", "remove", "rm", "d":
-			if len(parts) < 2 {
-				fmt.Println("Error: missing item number")
-				continue
-			}
-			if err := handleDelete(list, filename, parts[1:]); err != nil {
-				fmt.Printf("Error: %v\n", err)
-			}
-
-		case "edit", "e":
-			if len(parts) < 3 {
-				fmt.Println("Error: missing item number and/or new text")
-				continue
-			}
-			if err := handleEdit(list, filename, parts[1:]); err != nil {
-				fmt.Printf("Error: %v\n", err)
-			}
-
-		case "clear":
-			if err := handleClear(list, filename); err != nil {
-				fmt.Printf("Error: %v\n", err)
-			}
-
-		case "help", "h":
-			printInteractiveHelp()
-
-		case "quit", "exit", "q":
-			fmt.Println("Goodbye!")
-			return
-
-		default:
-			fmt.Printf("Unknown command: %s. Type 'help' for available commands.\n", cmd)
-		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		fmt.Printf("Error reading input: %v\n", err)
-	}
-}
-
-// printInteractiveHelp shows help specific to interactive mode
-func printInteractiveHelp() {
-	helpText := `Available commands in interactive mode:
-
-  add, a <text>        Add a new todo item
-  complete, done, c <n>    Mark item n as completed
-  uncomplete, undo, u <n>  Mark item n as not completed
-  delete, remove, rm, d <n>  Delete item n
-  edit, e <n> <text>   Edit item n with new text
-  clear                Clear all items
-  list, ls, l          Show todo list (default view)
-  help, h              Show this help message
-  quit, exit, q        Exit interactive mode
-
-Examples:
-  add Buy milk
-  complete 1
-  edit 2 Updated task text
-  delete 3
-`
-	fmt.Print(helpText)
-}
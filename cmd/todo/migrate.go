@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// handleMigrate upgrades the todo file to the current schema version, or with
+// --check only reports whether an upgrade is needed. It works on the file
+// directly, so it runs before the list is loaded.
+func handleMigrate(filename string, check bool) error {
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		fmt.Printf("No todo file at %s; nothing to migrate\n", filename)
+		return nil
+	}
+
+	m, err := todo.CheckMigration(filename)
+	if err != nil {
+		return err
+	}
+
+	if !m.Needed() {
+		fmt.Printf("%s is up to date (schema version %d)\n", filename, m.From)
+		return nil
+	}
+
+	if check {
+		fmt.Printf("%s uses schema version %d; current version is %d\n", filename, m.From, m.To)
+		for _, step := range m.Steps {
+			fmt.Printf("  - %s\n", step)
+		}
+		return fmt.Errorf("migration needed; run 'todo migrate' to upgrade")
+	}
+
+	list := todo.NewList()
+	if err := list.Load(filename); err != nil {
+		return err
+	}
+	if err := saveTodos(list, filename); err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrated %s from schema version %d to %d (backup: %s)\n",
+		filename, m.From, m.To, todo.BackupFilename(filename, m.From))
+	return nil
+}
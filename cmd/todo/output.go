@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/config"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// ANSI escape sequences used when colour output is enabled
+const (
+	ansiReset = "\033[0m"
+	ansiDim   = "\033[2m"
+	ansiGreen = "\033[32m"
+	ansiRed   = "\033[31m"
+)
+
+// useColor reports whether output to f should be coloured
+func useColor(f *os.File) bool {
+	switch settings.Color {
+	case config.ColorAlways:
+		return true
+	case config.ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printer formats items for the list command using the configured date
+// format and colours
+type printer struct {
+	w     io.Writer
+	list  *todo.List
+	color bool
+	now   time.Time
+}
+
+// newPrinter creates a printer writing to stdout
+func newPrinter(list *todo.List) *printer {
+	return &printer{w: os.Stdout, list: list, color: useColor(os.Stdout), now: time.Now()}
+}
+
+// printList prints the whole list with a completion summary
+func (p *printer) printList() {
+	if p.list.Count() == 0 {
+		fmt.Fprintln(p.w, "No items in the todo list")
+		return
+	}
+
+	fmt.Fprintf(p.w, "Todo List (%d/%d completed):\n", p.list.CountCompleted(), p.list.Count())
+	for i, item := range p.list.Items {
+		p.printItem(fmt.Sprintf("%d. ", i+1), item)
+		p.printSubtasks(item.Subtasks, 1)
+	}
+}
+
+// printSubtasks prints nested subtasks indented below their parent
+func (p *printer) printSubtasks(items []todo.Item, depth int) {
+	for _, item := range items {
+		p.printItem(strings.Repeat("   ", depth)+"- ", item)
+		p.printSubtasks(item.Subtasks, depth+1)
+	}
+}
+
+// printItem prints a single item line with its due date and custom fields
+func (p *printer) printItem(prefix string, item todo.Item) {
+	var details []string
+	overdue := false
+	if item.Due != nil {
+		details = append(details, "due: "+item.Due.Local().Format(settings.DateFormat))
+		overdue = !item.Done && item.Due.Before(p.now)
+	}
+	for _, def := range p.list.FieldDefs() {
+		value, ok := item.Fields[def.Name]
+		if !ok {
+			continue
+		}
+		if def.Type == todo.FieldDate {
+			if t, err := time.ParseInLocation(todo.DateLayout, value, time.Local); err == nil {
+				value = t.Format(settings.DateFormat)
+			}
+		}
+		details = append(details, def.Name+": "+value)
+	}
+
+	line := prefix + item.String()
+	if len(details) > 0 {
+		line += " (" + strings.Join(details, ", ") + ")"
+	}
+
+	if p.color {
+		switch {
+		case item.Done:
+			line = ansiDim + ansiGreen + line + ansiReset
+		case overdue:
+			line = ansiRed + line + ansiReset
+		}
+	}
+	fmt.Fprintln(p.w, line)
+}
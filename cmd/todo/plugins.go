@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/config"
+)
+
+// pluginPrefix starts the names of plugin executables: the plugin for
+// 'todo foo' is todo-foo
+const pluginPrefix = "todo-"
+
+// exitStatus is returned when a plugin fails, so that todo exits with the
+// plugin's status without another message
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+// plugin is an executable on PATH providing a command
+type plugin struct {
+	name string
+	path string
+}
+
+// findPlugin returns the path of the plugin for a command name
+func findPlugin(name string) (string, bool) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, "-") {
+		return "", false
+	}
+	path, err := exec.LookPath(pluginPrefix + name)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// findPlugins returns the plugins on PATH, sorted by name. Plugins named
+// after built-in commands are left out, since they can never run, as are
+// later plugins with the name of an earlier one.
+func findPlugins() []plugin {
+	seen := make(map[string]bool)
+	var plugins []plugin
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			dir = "."
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), pluginPrefix)
+			if !ok || name == "" || seen[name] || isBuiltinCommand(name) {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if info, err := os.Stat(path); err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, plugin{name: name, path: path})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].name < plugins[j].name })
+	return plugins
+}
+
+// runPlugin runs a plugin with the arguments after the command name,
+// passing on the todo file and settings in environment variables
+func runPlugin(e *env, path string, args []string) error {
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), pluginEnv(e)...)
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitStatus(exitErr.ExitCode())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %w", path, err)
+	}
+	return nil
+}
+
+// pluginEnv returns the environment variables describing the todo file and
+// settings to a plugin: TODO_FILE, an absolute path, TODO_FILE_SOURCE,
+// TODO_CONFIG, TODO_VERSION and TODO_<KEY> for the other settings, such as
+// TODO_DATE_FORMAT
+func pluginEnv(e *env) []string {
+	filename := e.filename
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+	vars := []string{
+		config.FileEnv + "=" + filename,
+		"TODO_FILE_SOURCE=" + string(e.source),
+		"TODO_VERSION=" + version,
+	}
+	if path, err := config.Path(); err == nil {
+		vars = append(vars, "TODO_CONFIG="+path)
+	}
+	for _, key := range config.Keys {
+		if key == "file" {
+			continue
+		}
+		value, _ := settings.Get(key)
+		vars = append(vars, "TODO_"+strings.ToUpper(key)+"="+value)
+	}
+	return vars
+}
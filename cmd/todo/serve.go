@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/server"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// defaultServeAddr is where 'todo serve' listens without --addr
+const defaultServeAddr = "127.0.0.1:8080"
+
+// watchInterval is how often 'todo serve' checks the todo file for changes
+// made by other commands
+const watchInterval = time.Second
+
+// handleServe serves the REST API for the todo file until interrupted
+func handleServe(filename, addr string) error {
+	if addr == "" {
+		addr = defaultServeAddr
+	}
+
+	// Saving through the store keeps the server's own writes from being
+	// taken for changes by other commands
+	store := newFileStore(filename)
+	list, err := store.Load()
+	if err != nil {
+		return err
+	}
+	handler := server.New(todo.NewSyncList(list), store.Save)
+	handler.SetHook(listHook(filename))
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	srv.RegisterOnShutdown(handler.Close)
+
+	// Finish the requests in progress when interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	handler.Watch(ctx, store, watchInterval, func(err error) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	})
+	stopped := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stopped <- srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("Serving %s on http://%s (press Ctrl-C to stop)\n", filename, ln.Addr())
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-stopped
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/getopt"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// handleExport writes the todo list to a file or stdout in the requested format
+func handleExport(todoList *todo.List, opts *getopt.Result) error {
+	filename := firstArg(opts.Args)
+	format, err := resolveFormat(opts, filename)
+	if err != nil {
+		return err
+	}
+
+	enc, err := format.Encoder(formatOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	if filename == "" || filename == "-" {
+		return enc.Encode(os.Stdout, todoList)
+	}
+
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, todoList); err != nil {
+		return err
+	}
+	if err := writeFile(filename, buf.Bytes()); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d item(s) to %s as %s\n", todoList.Count(), filename, format.Name)
+	return nil
+}
+
+// handleImport appends items read from a file or stdin to the todo list
+func handleImport(todoList *todo.List, filename string, opts *getopt.Result) error {
+	input := firstArg(opts.Args)
+	format, err := resolveFormat(opts, input)
+	if err != nil {
+		return err
+	}
+
+	dec, err := format.Decoder(formatOptions(opts))
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if input != "" && input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", input, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	imported := todo.NewList()
+	imported.Declare(todoList.FieldDefs()...)
+	result, err := dec.Decode(r, imported)
+	if err != nil {
+		return err
+	}
+
+	for _, rowErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "Skipped %v\n", rowErr)
+	}
+	for _, skipped := range result.Skipped {
+		fmt.Fprintf(os.Stderr, "Note: %v\n", skipped)
+	}
+	if len(result.Unmapped) > 0 {
+		fmt.Fprintf(os.Stderr, "Unmapped attributes: %s\n", todo.UnmappedReport(result.Unmapped))
+	}
+
+	if opts.Bool("dry-run") {
+		for _, item := range imported.Items {
+			fmt.Printf("Would import: %s\n", item)
+		}
+		fmt.Printf("Dry run: %d item(s) would be imported, %d row(s) rejected\n",
+			imported.Count(), len(result.Errors))
+	} else {
+		err := todoList.Transaction(func(tx *todo.Tx) error {
+			start := tx.Count()
+			tx.Append(imported.Items...)
+			for index := start; index < tx.Count(); index++ {
+				if err := runHook(tx.List, filename, hookAdd, index, nil); err != nil {
+					return err
+				}
+			}
+			return saveTodos(tx.List, filename)
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported %d item(s)\n", imported.Count())
+	}
+
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%d row(s) could not be imported", len(result.Errors))
+	}
+	return nil
+}
+
+// formatOptions collects the format options given explicitly as flags
+func formatOptions(opts *getopt.Result) todo.Options {
+	formatOpts := make(todo.Options)
+	if opts.Has("map") {
+		formatOpts["map"] = opts.Get("map")
+	}
+	if opts.Has("group") {
+		formatOpts["group"] = opts.Get("group")
+	}
+	return formatOpts
+}
+
+// resolveFormat picks the format named by --format, or the one matching the
+// file's extension, falling back to the configured format for stdin/stdout
+func resolveFormat(opts *getopt.Result, filename string) (todo.Format, error) {
+	if name := opts.Get("format"); name != "" {
+		return todo.LookupFormat(name)
+	}
+	if filename != "" && filename != "-" {
+		return todo.FormatForFile(filename)
+	}
+	return todo.LookupFormat(settings.Format)
+}
+
+// handleSyncMarkdown keeps the marked todo section of a Markdown file in sync
+func handleSyncMarkdown(todoList *todo.List, filename string, opts *getopt.Result) error {
+	docFile := opts.Args[0]
+	doc, err := os.ReadFile(docFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", docFile, err)
+	}
+
+	var updated string
+	var sync todo.MarkdownSync
+	err = todoList.Transaction(func(tx *todo.Tx) error {
+		var err error
+		updated, sync, err = tx.SyncMarkdown(string(doc), todo.MarkdownOptions{
+			GroupByProject: opts.Bool("group"),
+			Hook:           listHook(filename),
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", docFile, err)
+		}
+		if sync.Added > 0 || sync.Updated > 0 {
+			return saveTodos(tx.List, filename)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeFile(docFile, []byte(updated)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Synced %s: %d item(s) added, %d updated from file\n", docFile, sync.Added, sync.Updated)
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/tui"
+)
+
+// handleTUI runs the full-screen interface. It loads the todo file itself
+// and reloads it when another program changes it, so it runs before the
+// list is loaded.
+func handleTUI(filename string) error {
+	// Output of hooks would mess up the screen
+	hookStderr = io.Discard
+	app, err := tui.New(newFileStore(filename), tui.Options{
+		DateFormat: settings.DateFormat,
+		Color:      useColor(os.Stdout),
+		Hook:       listHook(filename),
+	})
+	if err != nil {
+		return err
+	}
+	return app.Run(os.Stdin, os.Stdout)
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/shellwords"
+)
+
+// maxAliasDepth bounds how many aliases may expand into one another.
+const maxAliasDepth = 10
+
+var placeholderPattern = regexp.MustCompile(`\$(?:([1-9])|[@*]|\{([1-9])(?::-([^}]*))?\})`)
+
+// ExpandAlias replaces a user-defined alias at the start of args with its
+// definition, repeating while the result starts with another alias. Names
+// for which builtin returns true are never expanded.
+//
+// A definition is split into words like a shell command line, so quotes
+// keep filters together. Within each word, $1 to $9 are replaced by the
+// corresponding argument, ${1:-default} falls back to a default, and $*
+// is replaced by all arguments joined with spaces. A word consisting of $@
+// expands to all arguments as separate words. Arguments that no placeholder
+// refers to are appended. Expansion fails if a required argument is missing
+// or the aliases refer to each other in a loop.
+func (c Config) ExpandAlias(args []string, builtin func(string) bool) ([]string, error) {
+	var chain []string
+	for len(args) > 0 && !builtin(args[0]) {
+		definition, ok := c.Aliases[args[0]]
+		if !ok {
+			break
+		}
+
+		for _, name := range chain {
+			if name == args[0] {
+				return nil, fmt.Errorf("alias %s refers to itself: %s -> %s", args[0], strings.Join(chain, " -> "), args[0])
+			}
+		}
+		chain = append(chain, args[0])
+		if len(chain) > maxAliasDepth {
+			return nil, fmt.Errorf("alias %s: too many nested aliases", chain[0])
+		}
+
+		expanded, err := substitute(args[0], definition, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		args = expanded
+	}
+	return args, nil
+}
+
+// substitute expands the placeholders of an alias definition.
+func substitute(name, definition string, args []string) ([]string, error) {
+	words, err := shellwords.Split(definition)
+	if err != nil {
+		return nil, fmt.Errorf("alias %s: %w", name, err)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("alias %s is empty", name)
+	}
+
+	used := make([]bool, len(args))
+	allUsed := false
+	var missing error
+
+	var result []string
+	for _, word := range words {
+		if word == "$@" {
+			result = append(result, args...)
+			allUsed = true
+			continue
+		}
+
+		word = placeholderPattern.ReplaceAllStringFunc(word, func(m string) string {
+			sub := placeholderPattern.FindStringSubmatch(m)
+			if sub[1] == "" && sub[2] == "" {
+				// $* and $@ inside a word join the arguments
+				allUsed = true
+				return strings.Join(args, " ")
+			}
+
+			digits := sub[1] + sub[2]
+			n, _ := strconv.Atoi(digits)
+			if n <= len(args) {
+				used[n-1] = true
+				return args[n-1]
+			}
+			if strings.Contains(m, ":-") {
+				return sub[3]
+			}
+			if missing == nil {
+				missing = fmt.Errorf("alias %s needs at least %d argument(s)", name, n)
+			}
+			return ""
+		})
+		result = append(result, word)
+	}
+	if missing != nil {
+		return nil, missing
+	}
+
+	if !allUsed {
+		for i, arg := range args {
+			if !used[i] {
+				result = append(result, arg)
+			}
+		}
+	}
+	return result, nil
+}
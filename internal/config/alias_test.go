@@ -0,0 +1,73 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func isBuiltin(name string) bool {
+	return name == "list" || name == "set" || name == "done"
+}
+
+func TestExpandAlias(t *testing.T) {
+	cfg := Default()
+	cfg.Aliases = map[string]string{
+		"today": "list --where 'due<=today' --sort priority",
+		"ship":  "done --where tag:release",
+		"tag":   "set $1 tags=$2",
+		"note":  "set $1 note=${2:-todo}",
+		"all":   "list --where \"$*\"",
+		"t":     "today",
+		"list":  "set 1 text=hijacked",
+	}
+
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"today"}, "list|--where|due<=today|--sort|priority"},
+		{[]string{"today", "--all-lists"}, "list|--where|due<=today|--sort|priority|--all-lists"},
+		{[]string{"t"}, "list|--where|due<=today|--sort|priority"},
+		{[]string{"tag", "3", "work"}, "set|3|tags=work"},
+		{[]string{"tag", "3", "work", "due=today"}, "set|3|tags=work|due=today"},
+		{[]string{"note", "2"}, "set|2|note=todo"},
+		{[]string{"all", "pending", "tag=x"}, "list|--where|pending tag=x"},
+		{[]string{"list"}, "list"},
+		{[]string{"unknown", "x"}, "unknown|x"},
+	}
+	for _, tc := range tests {
+		got, err := cfg.ExpandAlias(tc.args, isBuiltin)
+		if err != nil {
+			t.Errorf("%v: expected no error, got %v", tc.args, err)
+			continue
+		}
+		if strings.Join(got, "|") != tc.expected {
+			t.Errorf("%v: expected %s, got %s", tc.args, tc.expected, strings.Join(got, "|"))
+		}
+	}
+}
+
+func TestExpandAliasErrors(t *testing.T) {
+	cfg := Default()
+	cfg.Aliases = map[string]string{
+		"a":    "b",
+		"b":    "c --flag",
+		"c":    "a",
+		"self": "self again",
+		"tag":  "set $1 tags=$2",
+		"bad":  "list 'unterminated",
+	}
+
+	for _, args := range [][]string{{"a"}, {"self"}, {"tag", "1"}, {"bad"}} {
+		if _, err := cfg.ExpandAlias(args, isBuiltin); err == nil {
+			t.Errorf("%v: expected error", args)
+		}
+	}
+}
+
+func TestSetAliasRejectsUnterminatedQuote(t *testing.T) {
+	cfg := Default()
+	if err := cfg.Set("alias.bad", `list --where "pending`); err == nil {
+		t.Error("Expected error for unterminated quote")
+	}
+}
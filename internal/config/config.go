@@ -0,0 +1,384 @@
+// Package config loads and stores the user settings of the todo command and
+// locates the todo file to use.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/shellwords"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+const (
+	// DefaultFilename is the name of the todo file in the data directory,
+	// and of the legacy file in the working directory.
+	DefaultFilename = "todos.json"
+	// LocalFilename is the name of a project-local todo file, found by
+	// walking up from the working directory.
+	LocalFilename = ".todo.json"
+	// FileEnv overrides the todo file location.
+	FileEnv = "TODO_FILE"
+)
+
+// Color modes.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// Config holds the user settings stored in the config file.
+type Config struct {
+	// CurrentList is the named list selected with 'todo use'.
+	CurrentList string `json:"list,omitempty"`
+	// File is the default todo file, used when no project-local file is found.
+	File string `json:"file,omitempty"`
+	// Format is the default export and import format for stdin and stdout.
+	Format string `json:"format,omitempty"`
+	// DateFormat is the Go time layout used to display dates.
+	DateFormat string `json:"date_format,omitempty"`
+	// Color is one of auto, always or never.
+	Color string `json:"color,omitempty"`
+	// Aliases maps a command name onto the command line it expands to.
+	Aliases map[string]string `json:"aliases,omitempty"`
+	// Fields declares custom fields for every list, besides those defined
+	// in the todo file.
+	Fields []todo.FieldDef `json:"fields,omitempty"`
+}
+
+// Default returns the settings used when the config file does not set them.
+func Default() Config {
+	return Config{
+		Format:     "csv",
+		DateFormat: todo.DateLayout,
+		Color:      ColorAuto,
+	}
+}
+
+// Keys lists the settings accepted by Get and Set, besides alias.<name>
+// and field.<name>.
+var Keys = []string{"list", "file", "format", "date_format", "color"}
+
+// Dir returns the directory holding the config file:
+// $XDG_CONFIG_HOME/todo, or ~/.config/todo.
+func Dir() (string, error) {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// DataDir returns the directory holding the default todo file:
+// $XDG_DATA_HOME/todo, or ~/.local/share/todo.
+func DataDir() (string, error) {
+	return xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+// Path returns the location of the config file.
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// HooksDir returns the directory holding the hook executables.
+func HooksDir() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "hooks"), nil
+}
+
+// xdgDir returns the todo directory below an XDG base directory, falling
+// back to a directory in the user's home.
+func xdgDir(env, fallback string) (string, error) {
+	if base := os.Getenv(env); filepath.IsAbs(base) {
+		return filepath.Join(base, "todo"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %w", err)
+	}
+	return filepath.Join(home, fallback, "todo"), nil
+}
+
+// Load reads the config file at path. A missing file yields the defaults.
+// Settings that are not present in the file keep their default values.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the config to path, creating its directory if needed.
+func (c Config) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write config %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate checks that every setting has an acceptable value.
+func (c Config) Validate() error {
+	for _, key := range Keys {
+		value, _ := c.Get(key)
+		if err := validate(key, value); err != nil {
+			return err
+		}
+	}
+	for name, definition := range c.Aliases {
+		if err := validateAliasName(name); err != nil {
+			return err
+		}
+		if _, err := shellwords.Split(definition); err != nil {
+			return fmt.Errorf("alias %s: %w", name, err)
+		}
+	}
+	return c.validateFields()
+}
+
+// Get returns the value of a setting.
+func (c Config) Get(key string) (string, error) {
+	if name, ok := strings.CutPrefix(key, "alias."); ok {
+		value, found := c.Aliases[name]
+		if !found {
+			return "", fmt.Errorf("alias %q is not defined", name)
+		}
+		return value, nil
+	}
+	if name, ok := strings.CutPrefix(key, fieldPrefix); ok {
+		return c.getField(name)
+	}
+
+	switch key {
+	case "list":
+		return c.CurrentList, nil
+	case "file":
+		return c.File, nil
+	case "format":
+		return c.Format, nil
+	case "date_format":
+		return c.DateFormat, nil
+	case "color":
+		return c.Color, nil
+	}
+	return "", unknownKeyError(key)
+}
+
+// Set validates and assigns a setting. Setting an alias or field to an
+// empty value removes it; other settings return to their default. A field
+// is given as its type followed, for enums, by the comma-separated values,
+// as in "enum s1,s2,s3".
+func (c *Config) Set(key, value string) error {
+	value = strings.TrimSpace(value)
+
+	if name, ok := strings.CutPrefix(key, "alias."); ok {
+		if err := validateAliasName(name); err != nil {
+			return err
+		}
+		if value == "" {
+			delete(c.Aliases, name)
+			return nil
+		}
+		if _, err := shellwords.Split(value); err != nil {
+			return fmt.Errorf("alias %s: %w", name, err)
+		}
+		if c.Aliases == nil {
+			c.Aliases = make(map[string]string)
+		}
+		c.Aliases[name] = value
+		return nil
+	}
+	if name, ok := strings.CutPrefix(key, fieldPrefix); ok {
+		return c.setField(name, value)
+	}
+
+	if value == "" {
+		defaults := Default()
+		value, _ = defaults.Get(key)
+	}
+	if err := validate(key, value); err != nil {
+		return err
+	}
+
+	switch key {
+	case "list":
+		c.CurrentList = value
+	case "file":
+		c.File = value
+	case "format":
+		c.Format = value
+	case "date_format":
+		c.DateFormat = value
+	case "color":
+		c.Color = value
+	default:
+		return unknownKeyError(key)
+	}
+	return nil
+}
+
+// List returns every setting as key/value pairs, followed by the aliases
+// sorted by name and the fields in the order they were declared.
+func (c Config) List() [][2]string {
+	var pairs [][2]string
+	for _, key := range Keys {
+		value, _ := c.Get(key)
+		pairs = append(pairs, [2]string{key, value})
+	}
+
+	names := make([]string, 0, len(c.Aliases))
+	for name := range c.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pairs = append(pairs, [2]string{"alias." + name, c.Aliases[name]})
+	}
+	for _, def := range c.Fields {
+		pairs = append(pairs, [2]string{fieldPrefix + def.Name, fieldValue(def)})
+	}
+	return pairs
+}
+
+// validate checks the value of a single setting.
+func validate(key, value string) error {
+	switch key {
+	case "list":
+		if value != "" {
+			return ValidateListName(value)
+		}
+	case "format":
+		if _, err := todo.LookupFormat(value); err != nil {
+			return err
+		}
+	case "date_format":
+		if value == "" || time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC).Format(value) == value {
+			return fmt.Errorf("invalid date_format %q (use a Go time layout such as 2006-01-02 or \"Jan 2\")", value)
+		}
+	case "color":
+		switch value {
+		case ColorAuto, ColorAlways, ColorNever:
+		default:
+			return fmt.Errorf("invalid color %q (expected auto, always or never)", value)
+		}
+	}
+	return nil
+}
+
+// validateAliasName checks that an alias name is a single word.
+func validateAliasName(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t\n\"'=") {
+		return fmt.Errorf("invalid alias name %q", name)
+	}
+	return nil
+}
+
+// unknownKeyError reports a setting that does not exist.
+func unknownKeyError(key string) error {
+	return fmt.Errorf("unknown config key %q (valid keys: %s, alias.<name>, field.<name>)", key, strings.Join(Keys, ", "))
+}
+
+// FindLocal walks up from dir looking for a project-local todo file and
+// returns its path, or "" if there is none.
+func FindLocal(dir string) string {
+	for {
+		path := filepath.Join(dir, LocalFilename)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Source describes where ResolveFile found the todo file.
+type Source string
+
+// Sources of the todo file location, in order of precedence.
+const (
+	SourceFlag     Source = "--file"
+	SourceListFlag Source = "--list"
+	SourceEnv      Source = FileEnv
+	SourceLocal    Source = LocalFilename
+	SourceList     Source = "list setting"
+	SourceConfig   Source = "file setting"
+	SourceLegacy   Source = "working directory"
+	SourceDefault  Source = "default"
+)
+
+// ResolveFile determines the todo file to use. In order of precedence it is
+// the flag value, the TODO_FILE environment variable, a .todo.json in dir
+// or one of its parents, the list selected with 'todo use', the file
+// setting, a todos.json in dir kept for compatibility with older releases,
+// and finally the default list in the data directory.
+func (c Config) ResolveFile(flagValue, dir string) (string, Source, error) {
+	if flagValue != "" {
+		return flagValue, SourceFlag, nil
+	}
+	if env := os.Getenv(FileEnv); env != "" {
+		return expandHome(env), SourceEnv, nil
+	}
+	if local := FindLocal(dir); local != "" {
+		return local, SourceLocal, nil
+	}
+	if c.CurrentList != "" {
+		path, err := ListFile(c.CurrentList)
+		return path, SourceList, err
+	}
+	if c.File != "" {
+		return expandHome(c.File), SourceConfig, nil
+	}
+
+	legacy := filepath.Join(dir, DefaultFilename)
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy, SourceLegacy, nil
+	}
+
+	path, err := ListFile(DefaultList)
+	return path, SourceDefault, err
+}
+
+// expandHome replaces a leading ~ with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadMissingFileReturnsDefaults(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Format != "csv" || cfg.Color != ColorAuto || cfg.DateFormat != "2006-01-02" {
+		t.Errorf("Expected defaults, got %+v", cfg)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo", "config.json")
+
+	cfg := Default()
+	if err := cfg.Set("format", "md"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := cfg.Set("date_format", "Jan 2"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := cfg.Set("alias.today", "list --where due<=today"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if loaded.Format != "md" || loaded.DateFormat != "Jan 2" || loaded.Color != ColorAuto {
+		t.Errorf("Expected saved settings, got %+v", loaded)
+	}
+	if value, _ := loaded.Get("alias.today"); value != "list --where due<=today" {
+		t.Errorf("Expected alias to be saved, got %q", value)
+	}
+}
+
+func TestLoadRejectsInvalidSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"color": "purple"}`), 0644)
+
+	if _, err := Load(path); err == nil {
+		t.Error("Expected error for invalid color")
+	}
+}
+
+func TestSet(t *testing.T) {
+	cfg := Default()
+
+	invalid := map[string]string{
+		"color":       "sometimes",
+		"format":      "xml",
+		"date_format": "no layout",
+		"editor":      "vim",
+		"alias.a b":   "list",
+	}
+	for key, value := range invalid {
+		if err := cfg.Set(key, value); err == nil {
+			t.Errorf("Expected error setting %s to %q", key, value)
+		}
+	}
+
+	cfg.Set("color", ColorNever)
+	if err := cfg.Set("color", ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Color != ColorAuto {
+		t.Errorf("Expected empty value to restore the default, got %q", cfg.Color)
+	}
+
+	cfg.Set("alias.p", "list --where pending")
+	cfg.Set("alias.p", "")
+	if _, err := cfg.Get("alias.p"); err == nil {
+		t.Error("Expected empty value to remove the alias")
+	}
+}
+
+func TestResolveFile(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	t.Setenv(FileEnv, "")
+
+	project := filepath.Join(root, "project")
+	dir := filepath.Join(project, "src", "pkg")
+	os.MkdirAll(dir, 0755)
+
+	cfg := Default()
+	check := func(flagValue, expected string, source Source) {
+		t.Helper()
+		got, gotSource, err := cfg.ResolveFile(flagValue, dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if got != expected || gotSource != source {
+			t.Errorf("Expected %s from %s, got %s from %s", expected, source, got, gotSource)
+		}
+	}
+
+	check("", filepath.Join(root, "data", "todo", DefaultFilename), SourceDefault)
+
+	legacy := filepath.Join(dir, DefaultFilename)
+	os.WriteFile(legacy, []byte("{}"), 0644)
+	check("", legacy, SourceLegacy)
+
+	cfg.File = "/tmp/configured.json"
+	check("", "/tmp/configured.json", SourceConfig)
+
+	local := filepath.Join(project, LocalFilename)
+	os.WriteFile(local, []byte("{}"), 0644)
+	check("", local, SourceLocal)
+
+	t.Setenv(FileEnv, "/tmp/env.json")
+	check("", "/tmp/env.json", SourceEnv)
+
+	check("flag.json", "flag.json", SourceFlag)
+}
+
+func TestFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	cfg := Default()
+	if err := cfg.Set("field.ticket", "string"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := cfg.Set("field.sprint", "enum s1,s2,s3"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := cfg.Set("field.points", "fraction"); err == nil {
+		t.Error("Expected error for an unknown field type")
+	}
+	if err := cfg.Save(path); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(loaded.Fields) != 2 || loaded.Fields[1].Name != "sprint" || len(loaded.Fields[1].Values) != 3 {
+		t.Fatalf("Expected the declared fields to be saved, got %+v", loaded.Fields)
+	}
+	if value, _ := loaded.Get("field.sprint"); value != "enum s1,s2,s3" {
+		t.Errorf("Expected 'enum s1,s2,s3', got %q", value)
+	}
+	pairs := loaded.List()
+	if last := pairs[len(pairs)-1]; last != [2]string{"field.sprint", "enum s1,s2,s3"} {
+		t.Errorf("Expected fields to be listed last, got %v", last)
+	}
+
+	if err := loaded.Set("field.ticket", ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := loaded.Get("field.ticket"); err == nil || len(loaded.Fields) != 1 {
+		t.Errorf("Expected the field to be removed, got %+v", loaded.Fields)
+	}
+
+	os.WriteFile(path, []byte(`{"fields": [{"name": "text", "type": "string"}]}`), 0644)
+	if _, err := Load(path); err == nil {
+		t.Error("Expected error for a field named after a built-in property")
+	}
+}
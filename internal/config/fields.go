@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// fieldPrefix starts the keys of custom field definitions, as in
+// field.ticket.
+const fieldPrefix = "field."
+
+// getField returns the definition of a declared field as "<type> [values]".
+func (c Config) getField(name string) (string, error) {
+	for _, def := range c.Fields {
+		if def.Name == name {
+			return fieldValue(def), nil
+		}
+	}
+	return "", fmt.Errorf("field %q is not declared", name)
+}
+
+// setField declares a field from "<type> [values]", replacing any previous
+// definition. An empty value removes the declaration.
+func (c *Config) setField(name, value string) error {
+	name = strings.ToLower(name)
+	for i, def := range c.Fields {
+		if def.Name == name {
+			c.Fields = append(c.Fields[:i:i], c.Fields[i+1:]...)
+			break
+		}
+	}
+	if value == "" {
+		return nil
+	}
+
+	typ, values, _ := strings.Cut(value, " ")
+	def, err := todo.ParseFieldDef(name, typ, strings.TrimSpace(values))
+	if err != nil {
+		return err
+	}
+	c.Fields = append(c.Fields, def)
+	return nil
+}
+
+// validateFields checks the field definitions read from the config file.
+func (c Config) validateFields() error {
+	seen := make(map[string]bool)
+	for _, def := range c.Fields {
+		if err := def.Validate(); err != nil {
+			return err
+		}
+		if seen[def.Name] {
+			return fmt.Errorf("field %s is declared twice", def.Name)
+		}
+		seen[def.Name] = true
+	}
+	return nil
+}
+
+// fieldValue formats a definition as the value of its field.<name> key.
+func fieldValue(def todo.FieldDef) string {
+	if def.Type == todo.FieldEnum {
+		return fmt.Sprintf("%s %s", def.Type, strings.Join(def.Values, ","))
+	}
+	return string(def.Type)
+}
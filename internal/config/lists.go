@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultList is the name of the list stored in the data directory's
+// todos.json, used when no other list or file is selected.
+const DefaultList = "default"
+
+var listNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidateListName checks that a list name is usable as a file name.
+func ValidateListName(name string) error {
+	if !listNamePattern.MatchString(name) || strings.HasSuffix(name, ".json") {
+		return fmt.Errorf("invalid list name %q (use letters, digits, '.', '_' and '-')", name)
+	}
+	return nil
+}
+
+// ListsDir returns the directory holding the named lists.
+func ListsDir() (string, error) {
+	data, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(data, "lists"), nil
+}
+
+// ListFile returns the todo file of a named list. The default list is
+// todos.json in the data directory; other lists are <name>.json in the
+// lists directory.
+func ListFile(name string) (string, error) {
+	if err := ValidateListName(name); err != nil {
+		return "", err
+	}
+
+	if name == DefaultList {
+		data, err := DataDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(data, DefaultFilename), nil
+	}
+
+	dir, err := ListsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// ListNames returns the names of the existing lists, sorted, with the
+// default list first if it exists.
+func ListNames() ([]string, error) {
+	var names []string
+
+	if path, err := ListFile(DefaultList); err != nil {
+		return nil, err
+	} else if _, err := os.Stat(path); err == nil {
+		names = append(names, DefaultList)
+	}
+
+	dir, err := ListsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read lists directory %s: %w", dir, err)
+	}
+
+	var named []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() || name == DefaultList || ValidateListName(name) != nil {
+			continue
+		}
+		named = append(named, name)
+	}
+	sort.Strings(named)
+
+	return append(names, named...), nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListFile(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+
+	path, err := ListFile("work")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expected := filepath.Join(data, "todo", "lists", "work.json"); path != expected {
+		t.Errorf("Expected %s, got %s", expected, path)
+	}
+
+	path, _ = ListFile(DefaultList)
+	if expected := filepath.Join(data, "todo", DefaultFilename); path != expected {
+		t.Errorf("Expected default list at %s, got %s", expected, path)
+	}
+
+	for _, name := range []string{"", "../work", "a/b", ".hidden", "work.json"} {
+		if _, err := ListFile(name); err == nil {
+			t.Errorf("Expected error for list name %q", name)
+		}
+	}
+}
+
+func TestListNames(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+
+	names, err := ListNames()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("Expected no lists, got %v", names)
+	}
+
+	lists := filepath.Join(data, "todo", "lists")
+	os.MkdirAll(lists, 0755)
+	for _, file := range []string{"work.json", "personal.json", "notes.txt", "work.json.v0.bak"} {
+		os.WriteFile(filepath.Join(lists, file), []byte("{}"), 0644)
+	}
+	os.WriteFile(filepath.Join(data, "todo", DefaultFilename), []byte("{}"), 0644)
+
+	names, err = ListNames()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{DefaultList, "personal", "work"}
+	if len(names) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, names)
+			break
+		}
+	}
+}
+
+func TestResolveFileUsesSelectedList(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	t.Setenv(FileEnv, "")
+
+	cfg := Default()
+	cfg.CurrentList = "work"
+	cfg.File = "/tmp/configured.json"
+
+	path, source, err := cfg.ResolveFile("", t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if expected := filepath.Join(data, "todo", "lists", "work.json"); path != expected || source != SourceList {
+		t.Errorf("Expected %s from %s, got %s from %s", expected, SourceList, path, source)
+	}
+}
@@ -0,0 +1,292 @@
+// Package getopt parses command line options in the GNU style: long options
+// written --name, --name=value or --name value, short options that can be
+// bundled as in -ab or -wvalue, options mixed with arguments, and -- to end
+// the options.
+package getopt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrHelp is returned by Parse when -h or --help is given and not defined
+// as an option.
+var ErrHelp = errors.New("help requested")
+
+// Option describes a command line option.
+type Option struct {
+	// Name is the long name, without the leading dashes.
+	Name string
+	// Short is the single-letter name, or 0 if there is none.
+	Short rune
+	// Value is the placeholder for the option's value shown in help, such
+	// as "<file>". Options without one are booleans.
+	Value string
+	// Help describes the option.
+	Help string
+}
+
+// TakesValue reports whether the option requires a value.
+func (o Option) TakesValue() bool {
+	return o.Value != ""
+}
+
+// String returns the option as shown in help, e.g. "-w, --where <filter>".
+func (o Option) String() string {
+	s := "--" + o.Name
+	if o.Short != 0 {
+		s = "-" + string(o.Short) + ", " + s
+	}
+	if o.Value != "" {
+		s += " " + o.Value
+	}
+	return s
+}
+
+// Set is a collection of options.
+type Set struct {
+	Options []Option
+	// StopAtArg ends option parsing at the first argument, as needed for
+	// options that come before a subcommand.
+	StopAtArg bool
+}
+
+// Result holds the options and arguments found by Parse.
+type Result struct {
+	// Args are the arguments that are not options, in order.
+	Args   []string
+	values map[string][]string
+}
+
+// Has reports whether the option was given.
+func (r *Result) Has(name string) bool {
+	_, ok := r.values[name]
+	return ok
+}
+
+// Get returns the last value given for the option, or "" if it was not
+// given.
+func (r *Result) Get(name string) string {
+	values := r.values[name]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// All returns every value given for the option, in order.
+func (r *Result) All(name string) []string {
+	return r.values[name]
+}
+
+// Bool reports whether a boolean option was given and not set to false.
+func (r *Result) Bool(name string) bool {
+	return r.Get(name) == "true"
+}
+
+// Parse separates options from arguments. Options may appear anywhere
+// unless StopAtArg is set; everything after -- is an argument, as are "-"
+// and negative numbers.
+func (s *Set) Parse(args []string) (*Result, error) {
+	r := &Result{values: make(map[string][]string)}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			r.Args = append(r.Args, args[i+1:]...)
+			return r, nil
+
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			opt, ok := s.long(name)
+			if !ok {
+				if name == "help" {
+					return nil, ErrHelp
+				}
+				return nil, s.unknown("--" + name)
+			}
+			if opt.TakesValue() && !hasValue {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("option --%s needs a value", opt.Name)
+				}
+				i++
+				value = args[i]
+			}
+			if err := r.add(opt, value, hasValue || opt.TakesValue()); err != nil {
+				return nil, err
+			}
+
+		case strings.HasPrefix(arg, "-") && arg != "-" && !isNumber(arg):
+			consumed, err := s.parseShort(r, arg[1:], args[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			i += consumed
+
+		default:
+			if s.StopAtArg {
+				r.Args = append(r.Args, args[i:]...)
+				return r, nil
+			}
+			r.Args = append(r.Args, arg)
+		}
+	}
+	return r, nil
+}
+
+// parseShort handles a group of bundled short options. The first option
+// taking a value uses the rest of the group, or the next argument. It
+// returns how many of the following arguments were consumed.
+func (s *Set) parseShort(r *Result, group string, rest []string) (int, error) {
+	runes := []rune(group)
+	for j, c := range runes {
+		opt, ok := s.short(c)
+		if !ok {
+			if c == 'h' {
+				return 0, ErrHelp
+			}
+			return 0, s.unknown("-" + string(c))
+		}
+		if !opt.TakesValue() {
+			if err := r.add(opt, "", false); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if value := string(runes[j+1:]); value != "" {
+			return 0, r.add(opt, strings.TrimPrefix(value, "="), true)
+		}
+		if len(rest) == 0 {
+			return 0, fmt.Errorf("option -%c needs a value", c)
+		}
+		return 1, r.add(opt, rest[0], true)
+	}
+	return 0, nil
+}
+
+// add records an option. Boolean options accept an explicit true or false.
+func (r *Result) add(opt Option, value string, hasValue bool) error {
+	if !opt.TakesValue() {
+		if !hasValue {
+			value = "true"
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("option --%s does not take a value", opt.Name)
+		}
+		value = strconv.FormatBool(b)
+	}
+	r.values[opt.Name] = append(r.values[opt.Name], value)
+	return nil
+}
+
+// long returns the option with the given long name.
+func (s *Set) long(name string) (Option, bool) {
+	for _, opt := range s.Options {
+		if opt.Name == name {
+			return opt, true
+		}
+	}
+	return Option{}, false
+}
+
+// short returns the option with the given short name.
+func (s *Set) short(c rune) (Option, bool) {
+	for _, opt := range s.Options {
+		if opt.Short != 0 && opt.Short == c {
+			return opt, true
+		}
+	}
+	return Option{}, false
+}
+
+// unknown reports an undefined option, suggesting a close match.
+func (s *Set) unknown(flag string) error {
+	var names []string
+	for _, opt := range s.Options {
+		names = append(names, "--"+opt.Name)
+	}
+	if suggestion := Suggest(flag, names); suggestion != "" {
+		return fmt.Errorf("unknown option %s (did you mean %s?)", flag, suggestion)
+	}
+	return fmt.Errorf("unknown option %s", flag)
+}
+
+// isNumber reports whether arg is a negative number rather than an option.
+func isNumber(arg string) bool {
+	if len(arg) < 2 || !unicode.IsDigit(rune(arg[1])) && arg[1] != '.' {
+		return false
+	}
+	_, err := strconv.ParseFloat(arg, 64)
+	return err == nil
+}
+
+// Suggest returns the candidate closest to name, or "" if none is close
+// enough to be a likely typo. A candidate that name is the only prefix of
+// also matches.
+func Suggest(name string, candidates []string) string {
+	name = strings.ToLower(name)
+	letters := len(strings.TrimLeft(name, "-"))
+
+	var prefixed []string
+	for _, c := range candidates {
+		if letters >= 2 && strings.HasPrefix(strings.ToLower(c), name) {
+			prefixed = append(prefixed, c)
+		}
+	}
+	if len(prefixed) == 1 {
+		return prefixed[0]
+	}
+
+	best, bestDistance := "", 0
+	for _, c := range candidates {
+		d := distance(name, strings.ToLower(c))
+		if best == "" || d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+
+	// Allow one edit for short names and roughly one per three letters
+	// otherwise.
+	limit := letters / 3
+	if limit < 1 {
+		limit = 1
+	}
+	if best == "" || bestDistance > limit {
+		return ""
+	}
+	return best
+}
+
+// distance returns the Damerau-Levenshtein distance between a and b,
+// counting a swap of adjacent letters as one edit.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
@@ -0,0 +1,119 @@
+package getopt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testOptions = []Option{
+	{Name: "where", Short: 'w', Value: "<filter>"},
+	{Name: "sort", Short: 's', Value: "<keys>"},
+	{Name: "all-lists", Short: 'a'},
+	{Name: "tag", Short: 't', Value: "<tag>"},
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string // where|sort|all-lists|tags|args
+	}{
+		{nil, "||false||"},
+		{[]string{"--where", "pending", "a", "b"}, "pending||false||a b"},
+		{[]string{"a", "--where=due<=today", "b"}, "due<=today||false||a b"},
+		{[]string{"-a", "-w", "done"}, "done||true||"},
+		{[]string{"-aw", "done"}, "done||true||"},
+		{[]string{"-awdone", "-s=due"}, "done|due|true||"},
+		{[]string{"-t", "x", "--tag", "y", "-ty"}, "||false|x,y,y|"},
+		{[]string{"--all-lists=false"}, "||false||"},
+		{[]string{"-a", "--", "-w", "--sort"}, "||true||-w --sort"},
+		{[]string{"-", "-5", "-1.5"}, "||false||- -5 -1.5"},
+	}
+	set := &Set{Options: testOptions}
+	for _, tc := range tests {
+		r, err := set.Parse(tc.args)
+		if err != nil {
+			t.Errorf("%q: expected no error, got %v", tc.args, err)
+			continue
+		}
+		got := strings.Join([]string{
+			r.Get("where"), r.Get("sort"), boolString(r.Bool("all-lists")),
+			strings.Join(r.All("tag"), ","), strings.Join(r.Args, " "),
+		}, "|")
+		if got != tc.expected {
+			t.Errorf("%q: expected %q, got %q", tc.args, tc.expected, got)
+		}
+	}
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+func TestParseStopAtArg(t *testing.T) {
+	set := &Set{Options: testOptions, StopAtArg: true}
+	r, err := set.Parse([]string{"-a", "list", "--where", "done"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !r.Bool("all-lists") || r.Has("where") {
+		t.Errorf("Expected only --all-lists before the command, got where=%q", r.Get("where"))
+	}
+	if strings.Join(r.Args, " ") != "list --where done" {
+		t.Errorf("Expected the command and its arguments, got %q", r.Args)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--wher", "x"}, "unknown option --wher (did you mean --where?)"},
+		{[]string{"--srot"}, "unknown option --srot (did you mean --sort?)"},
+		{[]string{"--all"}, "unknown option --all (did you mean --all-lists?)"},
+		{[]string{"--bogus"}, "unknown option --bogus"},
+		{[]string{"-x"}, "unknown option -x"},
+		{[]string{"--where"}, "option --where needs a value"},
+		{[]string{"-aw"}, "option -w needs a value"},
+		{[]string{"--all-lists=maybe"}, "option --all-lists does not take a value"},
+	}
+	set := &Set{Options: testOptions}
+	for _, tc := range tests {
+		_, err := set.Parse(tc.args)
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("%q: expected error %q, got %v", tc.args, tc.expected, err)
+		}
+	}
+
+	for _, args := range [][]string{{"--help"}, {"-h"}, {"-ah"}} {
+		if _, err := set.Parse(args); !errors.Is(err, ErrHelp) {
+			t.Errorf("%q: expected ErrHelp, got %v", args, err)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"add", "list", "lists", "complete", "delete", "export", "import"}
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"lst", "list"},
+		{"ad", "add"},
+		{"compelte", "complete"},
+		{"exprot", "export"},
+		{"delet", "delete"},
+		{"imp", "import"},
+		{"xyz", ""},
+		{"remove", ""},
+	}
+	for _, tc := range tests {
+		if got := Suggest(tc.name, candidates); got != tc.expected {
+			t.Errorf("Suggest(%q): expected %q, got %q", tc.name, tc.expected, got)
+		}
+	}
+}
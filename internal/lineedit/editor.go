@@ -0,0 +1,508 @@
+// Package lineedit reads lines from a terminal with emacs-style editing,
+// history browsing and search, and tab completion. When input is not a
+// terminal, lines are read as they are.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/term"
+)
+
+// ErrInterrupted is returned by ReadLine when Ctrl-C is pressed.
+var ErrInterrupted = errors.New("interrupted")
+
+// Candidate is a completion for the word before the cursor.
+type Candidate struct {
+	// Text replaces the word being completed.
+	Text string
+	// Help is shown next to the text when candidates are listed.
+	Help string
+}
+
+// Completer returns the candidates for the line up to the cursor, and the
+// byte offset in head where the word they replace starts. A single
+// candidate is followed by a space unless it ends with '=' or '/'.
+type Completer func(head string) (start int, candidates []Candidate)
+
+// Editor reads lines with editing. Keys follow the emacs bindings of
+// readline:
+//
+//	Ctrl-A, Home     start of line       Ctrl-E, End      end of line
+//	Ctrl-B, Left     back one character  Ctrl-F, Right    forward one character
+//	Alt-B            back one word       Alt-F            forward one word
+//	Ctrl-D, Delete   delete character    Backspace        delete previous character
+//	Ctrl-K           kill to end         Ctrl-U           kill to start
+//	Ctrl-W           kill previous word  Alt-D            kill next word
+//	Ctrl-Y           yank killed text    Ctrl-T           transpose characters
+//	Ctrl-P, Up       previous entry      Ctrl-N, Down     next entry
+//	Ctrl-R           search history      Ctrl-L           clear screen
+//	Tab              complete            Ctrl-C           abandon line
+//
+// Ctrl-D on an empty line ends input.
+type Editor struct {
+	// History holds the entries browsed with Up and Down. ReadLine does
+	// not add to it; callers add the lines they accept.
+	History *History
+	// Complete, if set, provides tab completion.
+	Complete Completer
+
+	in  *bufio.Reader
+	out io.Writer
+	// fd is the terminal file descriptor, or -1 when there is none
+	fd int
+	// editing is false when input is read without line editing
+	editing bool
+
+	line    []rune
+	pos     int
+	killed  []rune
+	row     int // row of the cursor, relative to the prompt
+	end     int // last row of the line, relative to the prompt
+	lastTab bool
+}
+
+// New creates an editor reading from in and writing to out. Line editing
+// is used only if in is a terminal and TERM is not "dumb".
+func New(in *os.File, out io.Writer) *Editor {
+	e := &Editor{
+		History: NewHistory(DefaultHistorySize),
+		in:      bufio.NewReader(in),
+		out:     out,
+		fd:      -1,
+	}
+	if fd := int(in.Fd()); term.IsTerminal(fd) && os.Getenv("TERM") != "dumb" {
+		e.fd = fd
+		e.editing = true
+	}
+	return e
+}
+
+// Editing reports whether lines are read with editing, that is from a
+// terminal.
+func (e *Editor) Editing() bool {
+	return e.editing
+}
+
+// ReadLine shows the prompt and returns the line entered, without the line
+// ending. It returns io.EOF at the end of input and ErrInterrupted when
+// Ctrl-C abandons the line.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if !e.editing {
+		return e.readPlain(prompt)
+	}
+	if e.fd >= 0 {
+		restore, err := term.MakeRaw(e.fd)
+		if err != nil {
+			return e.readPlain(prompt)
+		}
+		defer restore()
+	}
+	return e.edit(prompt)
+}
+
+// readPlain reads a line without editing.
+func (e *Editor) readPlain(prompt string) (string, error) {
+	io.WriteString(e.out, prompt)
+	line, err := e.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// edit runs the editing loop for one line.
+func (e *Editor) edit(prompt string) (string, error) {
+	e.line, e.pos, e.row, e.end = nil, 0, 0, 0
+	e.lastTab = false
+	history := e.History.Entries()
+	histIndex := len(history)
+	var saved []rune
+
+	e.render(prompt, e.line, e.pos)
+	for {
+		r, err := term.ReadKey(e.in)
+		if err != nil {
+			return "", err
+		}
+		if r == term.Ctrl('R') {
+			if r, err = e.search(history); err != nil {
+				return "", err
+			}
+		}
+		if r != '\t' {
+			e.lastTab = false
+		}
+
+		switch r {
+		case 0:
+			// Search was cancelled
+
+		case '\r', '\n':
+			e.render(prompt, e.line, len(e.line))
+			io.WriteString(e.out, "\r\n")
+			return string(e.line), nil
+
+		case term.Ctrl('C'):
+			e.render(prompt, e.line, len(e.line))
+			io.WriteString(e.out, "^C\r\n")
+			return "", ErrInterrupted
+
+		case term.Ctrl('D'):
+			if len(e.line) == 0 {
+				io.WriteString(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.deleteRange(e.pos, e.pos+1)
+
+		case term.KeyDelete:
+			e.deleteRange(e.pos, e.pos+1)
+
+		case term.KeyBackspace, term.Ctrl('H'):
+			if e.pos > 0 {
+				e.deleteRange(e.pos-1, e.pos)
+				e.pos--
+			}
+
+		case term.Ctrl('A'), term.KeyHome:
+			e.pos = 0
+		case term.Ctrl('E'), term.KeyEnd:
+			e.pos = len(e.line)
+		case term.Ctrl('B'), term.KeyLeft:
+			if e.pos > 0 {
+				e.pos--
+			}
+		case term.Ctrl('F'), term.KeyRight:
+			if e.pos < len(e.line) {
+				e.pos++
+			}
+		case term.KeyWordLeft:
+			e.pos = e.wordLeft()
+		case term.KeyWordRight:
+			e.pos = e.wordRight()
+
+		case term.Ctrl('K'):
+			e.kill(e.pos, len(e.line))
+		case term.Ctrl('U'):
+			e.kill(0, e.pos)
+			e.pos = 0
+		case term.Ctrl('W'), term.KeyKillWordBack:
+			start := e.wordLeft()
+			e.kill(start, e.pos)
+			e.pos = start
+		case term.KeyKillWord:
+			e.kill(e.pos, e.wordRight())
+		case term.Ctrl('Y'):
+			e.insert(e.killed...)
+		case term.Ctrl('T'):
+			e.transpose()
+
+		case term.Ctrl('P'), term.KeyUp:
+			if histIndex > 0 {
+				if histIndex == len(history) {
+					saved = append([]rune(nil), e.line...)
+				}
+				histIndex--
+				e.line = []rune(history[histIndex])
+				e.pos = len(e.line)
+			}
+		case term.Ctrl('N'), term.KeyDown:
+			if histIndex < len(history) {
+				histIndex++
+				if histIndex == len(history) {
+					e.line = saved
+				} else {
+					e.line = []rune(history[histIndex])
+				}
+				e.pos = len(e.line)
+			}
+
+		case term.Ctrl('L'):
+			io.WriteString(e.out, "\x1b[H\x1b[2J")
+			e.row, e.end = 0, 0
+
+		case '\t':
+			e.complete(prompt)
+
+		default:
+			if r >= ' ' && unicode.IsPrint(r) {
+				e.insert(r)
+			}
+		}
+		e.render(prompt, e.line, e.pos)
+	}
+}
+
+// insert adds runes at the cursor.
+func (e *Editor) insert(runes ...rune) {
+	line := make([]rune, 0, len(e.line)+len(runes))
+	line = append(line, e.line[:e.pos]...)
+	line = append(line, runes...)
+	e.line = append(line, e.line[e.pos:]...)
+	e.pos += len(runes)
+}
+
+// deleteRange removes the runes from start up to end.
+func (e *Editor) deleteRange(start, end int) {
+	if end > len(e.line) {
+		end = len(e.line)
+	}
+	if start >= end {
+		return
+	}
+	e.line = append(e.line[:start:start], e.line[end:]...)
+}
+
+// kill removes the runes from start up to end, keeping them for yanking.
+func (e *Editor) kill(start, end int) {
+	if start >= end {
+		return
+	}
+	e.killed = append([]rune(nil), e.line[start:end]...)
+	e.deleteRange(start, end)
+}
+
+// transpose swaps the characters before and at the cursor, or the last
+// two characters at the end of the line, and moves forward.
+func (e *Editor) transpose() {
+	if len(e.line) < 2 || e.pos == 0 {
+		return
+	}
+	if e.pos == len(e.line) {
+		e.pos--
+	}
+	e.line[e.pos-1], e.line[e.pos] = e.line[e.pos], e.line[e.pos-1]
+	e.pos++
+}
+
+// wordLeft returns the start of the word before the cursor.
+func (e *Editor) wordLeft() int {
+	i := e.pos
+	for i > 0 && !isWordRune(e.line[i-1]) {
+		i--
+	}
+	for i > 0 && isWordRune(e.line[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordRight returns the end of the word after the cursor.
+func (e *Editor) wordRight() int {
+	i := e.pos
+	for i < len(e.line) && !isWordRune(e.line[i]) {
+		i++
+	}
+	for i < len(e.line) && isWordRune(e.line[i]) {
+		i++
+	}
+	return i
+}
+
+// isWordRune reports whether r is part of a word for word movement.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// search runs an incremental reverse search of the history. It returns the
+// key that ended the search, to be handled as usual, or 0 if the search
+// was cancelled.
+func (e *Editor) search(history []string) (rune, error) {
+	original, originalPos := e.line, e.pos
+	var query []rune
+	match := len(history)
+	failed := false
+
+	// find looks for the newest entry containing the query, at or before
+	// index from
+	find := func(from int) {
+		for i := from; i >= 0; i-- {
+			if i >= len(history) {
+				continue
+			}
+			if at := strings.Index(history[i], string(query)); at >= 0 {
+				match = i
+				e.line = []rune(history[i])
+				e.pos = len([]rune(history[i][:at]))
+				failed = false
+				return
+			}
+		}
+		failed = true
+	}
+
+	for {
+		label := "reverse-i-search"
+		if failed {
+			label = "failed " + label
+		}
+		e.render(fmt.Sprintf("(%s)`%s': ", label, string(query)), e.line, e.pos)
+
+		r, err := term.ReadKey(e.in)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case r == term.Ctrl('R'):
+			if len(query) > 0 {
+				find(match - 1)
+			}
+		case r == term.KeyBackspace || r == term.Ctrl('H'):
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				find(len(history) - 1)
+			}
+		case r == term.Ctrl('G') || r == term.Ctrl('C'):
+			e.line, e.pos = original, originalPos
+			return 0, nil
+		case r >= ' ' && unicode.IsPrint(r):
+			query = append(query, r)
+			find(match)
+		default:
+			return r, nil
+		}
+	}
+}
+
+// complete replaces the word before the cursor with its completion, or
+// the longest prefix the candidates share. Pressing Tab again when no
+// progress can be made lists the candidates.
+func (e *Editor) complete(prompt string) {
+	if e.Complete == nil {
+		return
+	}
+	head := string(e.line[:e.pos])
+	start, candidates := e.Complete(head)
+	if start < 0 || start > len(head) || len(candidates) == 0 {
+		io.WriteString(e.out, "\a")
+		return
+	}
+	startPos := len([]rune(head[:start]))
+	word := string(e.line[startPos:e.pos])
+
+	replacement := candidates[0].Text
+	if len(candidates) == 1 {
+		if !strings.HasSuffix(replacement, "=") && !strings.HasSuffix(replacement, "/") {
+			replacement += " "
+		}
+	} else {
+		for _, c := range candidates[1:] {
+			replacement = commonPrefix(replacement, c.Text)
+		}
+	}
+
+	if replacement != word && strings.HasPrefix(replacement, word) || len(candidates) == 1 {
+		e.deleteRange(startPos, e.pos)
+		e.pos = startPos
+		e.insert([]rune(replacement)...)
+		e.lastTab = false
+		return
+	}
+
+	if !e.lastTab {
+		e.lastTab = true
+		io.WriteString(e.out, "\a")
+		return
+	}
+	e.listCandidates(prompt, candidates)
+}
+
+// listCandidates prints the candidates below the line and redraws it.
+func (e *Editor) listCandidates(prompt string, candidates []Candidate) {
+	var b strings.Builder
+	if down := e.end - e.row; down > 0 {
+		fmt.Fprintf(&b, "\x1b[%dB", down)
+	}
+	b.WriteString("\r\n")
+
+	widest := 0
+	described := false
+	for _, c := range candidates {
+		widest = max(widest, term.Width(c.Text))
+		described = described || c.Help != ""
+	}
+
+	if described {
+		for _, c := range candidates {
+			fmt.Fprintf(&b, "%s%s  %s\r\n", c.Text, strings.Repeat(" ", widest-term.Width(c.Text)), c.Help)
+		}
+	} else {
+		perRow := max(1, e.width()/(widest+2))
+		for i, c := range candidates {
+			b.WriteString(c.Text)
+			if (i+1)%perRow == 0 || i == len(candidates)-1 {
+				b.WriteString("\r\n")
+			} else {
+				b.WriteString(strings.Repeat(" ", widest+2-term.Width(c.Text)))
+			}
+		}
+	}
+
+	io.WriteString(e.out, b.String())
+	e.row, e.end = 0, 0
+	e.render(prompt, e.line, e.pos)
+}
+
+// commonPrefix returns the longest common prefix of a and b.
+func commonPrefix(a, b string) string {
+	ra, rb := []rune(a), []rune(b)
+	n := 0
+	for n < len(ra) && n < len(rb) && ra[n] == rb[n] {
+		n++
+	}
+	return string(ra[:n])
+}
+
+// width returns the terminal width, assuming 80 columns if unknown.
+func (e *Editor) width() int {
+	if e.fd >= 0 {
+		if w, _, err := term.Size(e.fd); err == nil && w > 0 {
+			return w
+		}
+	}
+	return 80
+}
+
+// render redraws the prompt and line, which may wrap over several rows,
+// and places the cursor at pos.
+func (e *Editor) render(prompt string, line []rune, pos int) {
+	cols := e.width()
+	var b strings.Builder
+
+	if e.row > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", e.row)
+	}
+	b.WriteString("\r\x1b[J")
+	b.WriteString(prompt)
+	b.WriteString(string(line))
+
+	promptWidth := term.Width(prompt)
+	total := promptWidth + term.Width(string(line))
+	end := total / cols
+	if total > 0 && total%cols == 0 {
+		// Move off the last column so the cursor position is unambiguous
+		b.WriteString("\r\n")
+	}
+
+	cursor := promptWidth + term.Width(string(line[:pos]))
+	row, col := cursor/cols, cursor%cols
+	if up := end - row; up > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", up)
+	}
+	b.WriteString("\r")
+	if col > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", col)
+	}
+
+	e.row, e.end = row, end
+	io.WriteString(e.out, b.String())
+}
@@ -0,0 +1,230 @@
+package lineedit
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// newTestEditor returns an editor reading keys from input as if from a
+// terminal, with the given history.
+func newTestEditor(input string, history ...string) (*Editor, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	e := &Editor{
+		History: NewHistory(0),
+		in:      bufio.NewReader(strings.NewReader(input)),
+		out:     out,
+		fd:      -1,
+		editing: true,
+	}
+	for _, line := range history {
+		e.History.Add(line)
+	}
+	return e, out
+}
+
+func TestEditing(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", "add milk\r", "add milk"},
+		{"newline", "add milk\n", "add milk"},
+		{"backspace", "add milkk\x7f\r", "add milk"},
+		{"ctrl-h", "add milkk\x08\r", "add milk"},
+		{"left and insert", "ac\x02b\r", "abc"},
+		{"arrow keys", "ac\x1b[Db\x1b[Cd\r", "abcd"},
+		{"home and end", "bc\x01a\x05d\r", "abcd"},
+		{"home and end keys", "bc\x1b[Ha\x1b[Fd\x1b[1~_\x1b[4~!\r", "_abcd!"},
+		{"delete", "abc\x01\x04\x1b[3~\r", "c"},
+		{"kill to end and yank", "hello world\x01\x06\x06\x06\x06\x06\x0b\x19\x19\r", "hello world world"},
+		{"kill to start", "junk add\x02\x02\x02\x15\r", "add"},
+		{"kill word", "add buy milk\x17bread\r", "add buy bread"},
+		{"alt-backspace", "add buy milk\x1b\x7fbread\r", "add buy bread"},
+		{"kill next word", "add old milk\x1bb\x1bb\x1bd\x04new\r", "add newmilk"},
+		{"word movement", "one two\x1bbX\x1bfY\r", "one XtwoY"},
+		{"ctrl-arrows", "one two\x1b[1;5DX\x1b[1;5CY\r", "one XtwoY"},
+		{"transpose", "ab\x14\r", "ba"},
+		{"transpose middle", "acb\x02\x14\r", "abc"},
+		{"utf-8", "café\x02\x7f\r", "caé"},
+		{"ignored control keys", "a\x00\x1b[Zb\r", "ab"},
+	}
+	for _, tc := range tests {
+		e, _ := newTestEditor(tc.input)
+		got, err := e.ReadLine("> ")
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tc.name, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestReadLineEndings(t *testing.T) {
+	e, _ := newTestEditor("\x04")
+	if _, err := e.ReadLine("> "); err != io.EOF {
+		t.Errorf("Expected io.EOF for Ctrl-D on an empty line, got %v", err)
+	}
+
+	e, out := newTestEditor("some text\x03next\r")
+	if _, err := e.ReadLine("> "); !errors.Is(err, ErrInterrupted) {
+		t.Errorf("Expected ErrInterrupted for Ctrl-C, got %v", err)
+	}
+	if !strings.Contains(out.String(), "^C") {
+		t.Errorf("Expected ^C to be echoed, got %q", out.String())
+	}
+	if got, _ := e.ReadLine("> "); got != "next" {
+		t.Errorf("Expected the next line to start empty, got %q", got)
+	}
+
+	e, _ = newTestEditor("unfinished")
+	if _, err := e.ReadLine("> "); err != io.EOF {
+		t.Errorf("Expected io.EOF when input ends, got %v", err)
+	}
+}
+
+func TestReadLinePlain(t *testing.T) {
+	out := &bytes.Buffer{}
+	e := &Editor{History: NewHistory(0), in: bufio.NewReader(strings.NewReader("add milk\r\nlist\x01\nlast")), out: out, fd: -1}
+
+	for _, expected := range []string{"add milk", "list\x01", "last"} {
+		got, err := e.ReadLine("> ")
+		if err != nil || got != expected {
+			t.Errorf("Expected %q, got %q (error %v)", expected, got, err)
+		}
+	}
+	if _, err := e.ReadLine("> "); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+	if out.String() != "> > > > " {
+		t.Errorf("Expected only prompts to be written, got %q", out.String())
+	}
+}
+
+func TestHistoryNavigation(t *testing.T) {
+	history := []string{"first", "second"}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"up", "\x1b[A\r", "second"},
+		{"up twice", "\x1b[A\x1b[A\r", "first"},
+		{"past the oldest", "\x10\x10\x10\r", "first"},
+		{"edit an entry", "\x10\x10!\r", "first!"},
+		{"back to the edited line", "draft\x10\x10\x0e\x0e\r", "draft"},
+		{"down arrow", "\x1b[A\x1b[A\x1b[B\r", "second"},
+		{"down at the newest", "x\x0e\r", "x"},
+	}
+	for _, tc := range tests {
+		e, _ := newTestEditor(tc.input, history...)
+		got, err := e.ReadLine("> ")
+		if err != nil || got != tc.expected {
+			t.Errorf("%s: expected %q, got %q (error %v)", tc.name, tc.expected, got, err)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	history := []string{"add milk", "list --where pending", "add bread", "done 2"}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"newest match", "\x12add\r", "add bread"},
+		{"older match", "\x12add\x12\r", "add milk"},
+		{"no older match", "\x12add\x12\x12\x12\r", "add milk"},
+		{"refine query", "\x12a\x12d\r", "add milk"},
+		{"backspace", "\x12addx\x7f\r", "add bread"},
+		{"accept and edit", "\x12pend\x05 --sort due\r", "list --where pending --sort due"},
+		{"accept with arrow", "\x12milk\x1b[D\x1b[D!\r", "ad!d milk"},
+		{"cancel", "draft\x12list\x07\r", "draft"},
+		{"cancel with ctrl-c", "draft\x12list\x03!\r", "draft!"},
+		{"no match", "\x12zzz\r", ""},
+	}
+	for _, tc := range tests {
+		e, _ := newTestEditor(tc.input, history...)
+		got, err := e.ReadLine("> ")
+		if err != nil || got != tc.expected {
+			t.Errorf("%s: expected %q, got %q (error %v)", tc.name, tc.expected, got, err)
+		}
+	}
+
+	e, out := newTestEditor("\x12zzz\r", history...)
+	e.ReadLine("> ")
+	if !strings.Contains(out.String(), "(failed reverse-i-search)`zzz': ") {
+		t.Errorf("Expected the failed search prompt, got %q", out.String())
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	words := []Candidate{{"complete", "Mark items as completed"}, {"clear", ""}, {"config", ""}, {"priority=", ""}}
+	completer := func(head string) (int, []Candidate) {
+		start := strings.LastIndex(head, " ") + 1
+		var result []Candidate
+		for _, c := range words {
+			if strings.HasPrefix(c.Text, head[start:]) {
+				result = append(result, c)
+			}
+		}
+		return start, result
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"single candidate", "comp\t1\r", "complete 1"},
+		{"no space after =", "set 1 pri\thigh\r", "set 1 priority=high"},
+		{"common prefix", "co\tn\t\r", "config "},
+		{"no candidates", "xyz\t\r", "xyz"},
+		{"middle of line", "comp 1\x01\x06\x06\x06\x06\t\r", "complete  1"},
+	}
+	for _, tc := range tests {
+		e, _ := newTestEditor(tc.input)
+		e.Complete = completer
+		got, err := e.ReadLine("> ")
+		if err != nil || got != tc.expected {
+			t.Errorf("%s: expected %q, got %q (error %v)", tc.name, tc.expected, got, err)
+		}
+	}
+
+	e, out := newTestEditor("c\t\t\r")
+	e.Complete = completer
+	e.ReadLine("> ")
+	for _, s := range []string{"complete  Mark items as completed", "clear", "config"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("Expected the second Tab to list %q, got %q", s, out.String())
+		}
+	}
+}
+
+func TestRenderWrapping(t *testing.T) {
+	e, out := newTestEditor("")
+	line := []rune(strings.Repeat("x", 100))
+
+	e.render("> ", line, len(line))
+	if e.row != 1 || e.end != 1 {
+		t.Errorf("Expected the cursor on the second row, got row %d of %d", e.row, e.end)
+	}
+
+	out.Reset()
+	e.render("> ", line, 0)
+	if !strings.HasPrefix(out.String(), "\x1b[1A\r") {
+		t.Errorf("Expected the redraw to start on the first row, got %q", out.String())
+	}
+	if !strings.HasSuffix(out.String(), "\x1b[1A\r\x1b[2C") {
+		t.Errorf("Expected the cursor to move back after the prompt, got %q", out.String())
+	}
+	if e.row != 0 {
+		t.Errorf("Expected the cursor on the first row, got %d", e.row)
+	}
+}
@@ -0,0 +1,119 @@
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultHistorySize is the number of entries a history keeps by default.
+const DefaultHistorySize = 1000
+
+// History holds previously entered lines, oldest first. When loaded from a
+// file, new entries are appended to it as they are added.
+type History struct {
+	entries []string
+	max     int
+	path    string
+}
+
+// NewHistory creates an empty history keeping at most max entries.
+func NewHistory(max int) *History {
+	if max <= 0 {
+		max = DefaultHistorySize
+	}
+	return &History{max: max}
+}
+
+// Entries returns the entries, oldest first.
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Len returns the number of entries.
+func (h *History) Len() int {
+	return len(h.entries)
+}
+
+// Load reads the history file at path, which need not exist yet, and
+// appends later entries to it. A file holding more entries than the
+// history keeps is rewritten with only the newest ones.
+func (h *History) Load(path string) error {
+	h.path = path
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read history %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read history %s: %w", path, err)
+	}
+
+	h.entries = nil
+	for _, entry := range entries {
+		h.append(entry)
+	}
+	if len(entries) > h.max {
+		return h.save()
+	}
+	return nil
+}
+
+// Add records a line, unless it is blank or repeats the latest entry.
+func (h *History) Add(line string) error {
+	if strings.TrimSpace(line) == "" || strings.ContainsAny(line, "\r\n") {
+		return nil
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return nil
+	}
+	h.append(line)
+
+	if h.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write history %s: %w", h.path, err)
+	}
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write history %s: %w", h.path, err)
+	}
+	return f.Close()
+}
+
+// append adds an entry in memory, dropping the oldest beyond the limit.
+func (h *History) append(line string) {
+	h.entries = append(h.entries, line)
+	if len(h.entries) > h.max {
+		h.entries = h.entries[len(h.entries)-h.max:]
+	}
+}
+
+// save rewrites the history file with the current entries.
+func (h *History) save() error {
+	data := strings.Join(h.entries, "\n") + "\n"
+	if err := os.WriteFile(h.path, []byte(data), 0600); err != nil {
+		return fmt.Errorf("failed to write history %s: %w", h.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package lineedit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHistoryAdd(t *testing.T) {
+	h := NewHistory(3)
+	for _, line := range []string{"one", "", "  ", "two", "two", "multi\nline", "three", "four"} {
+		if err := h.Add(line); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	expected := "two|three|four"
+	if got := strings.Join(h.Entries(), "|"); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo", "history")
+
+	h := NewHistory(0)
+	if err := h.Load(path); err != nil {
+		t.Fatalf("Expected a missing file to be ignored, got %v", err)
+	}
+	for _, line := range []string{"add milk", "list"} {
+		if err := h.Add(line); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	reloaded := NewHistory(0)
+	if err := reloaded.Load(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := strings.Join(reloaded.Entries(), "|"); got != "add milk|list" {
+		t.Errorf("Expected the saved entries, got %q", got)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected the history file to exist, got %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the history file to be private, got %v", info.Mode().Perm())
+	}
+}
+
+func TestHistoryTrimsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(path, []byte("a\nb\n\nc\nd\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHistory(2)
+	if err := h.Load(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := strings.Join(h.Entries(), "|"); got != "c|d" {
+		t.Errorf("Expected the newest entries, got %q", got)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "c\nd\n" {
+		t.Errorf("Expected the file to be trimmed, got %q", data)
+	}
+}
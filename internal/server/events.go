@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+const (
+	// feedSize is the number of past events kept for clients that
+	// reconnect.
+	feedSize = 1000
+	// heartbeatInterval is how often an idle stream sends a comment, so
+	// that proxies and clients do not take it for dead.
+	heartbeatInterval = 15 * time.Second
+)
+
+// feedEvent is a change with the id clients resume from.
+type feedEvent struct {
+	id    uint64
+	event todo.Event
+}
+
+// feed keeps the recent changes to the list for the clients of /events and
+// wakes them when one arrives.
+type feed struct {
+	mu     sync.Mutex
+	events []feedEvent
+	// last is the id of the newest event. Ids start from the time the
+	// server started, so that those of a previous run are recognized as
+	// too old rather than mistaken for new ones.
+	last uint64
+	// wake is closed and replaced when an event arrives
+	wake chan struct{}
+}
+
+func newFeed() *feed {
+	return &feed{
+		last: uint64(time.Now().UnixMicro()),
+		wake: make(chan struct{}),
+	}
+}
+
+// add records an event and wakes the waiting clients.
+func (f *feed) add(e todo.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.last++
+	f.events = append(f.events, feedEvent{id: f.last, event: e})
+	if len(f.events) > feedSize {
+		f.events = append(f.events[:0:0], f.events[len(f.events)-feedSize:]...)
+	}
+	close(f.wake)
+	f.wake = make(chan struct{})
+}
+
+// since returns the events after the one with the given id, and a channel
+// closed when more arrive. ok is false if the events after id are no longer
+// all kept, or id is unknown; the position to go on from is then returned
+// as the new id.
+func (f *feed) since(id uint64) (events []feedEvent, next uint64, ok bool, wake <-chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	oldest := f.last + 1
+	if len(f.events) > 0 {
+		oldest = f.events[0].id
+	}
+	if id > f.last || id+1 < oldest {
+		return nil, f.last, false, f.wake
+	}
+	start := len(f.events) - int(f.last-id)
+	events = append(events, f.events[start:]...)
+	return events, f.last, true, f.wake
+}
+
+// latest returns the id of the newest event.
+func (f *feed) latest() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.last
+}
+
+// streamEvents sends the changes to the list as server-sent events until
+// the client goes away or the server is closed. Each event is named after
+// its type, such as item-added, and its data is a JSON object with the
+// item number and the item before and after the change. A client resuming
+// with Last-Event-ID, or the last_event_id query parameter, first gets the
+// events it missed; if they are no longer kept it gets a reset event,
+// meaning it should fetch /items again.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, errorf(http.StatusInternalServerError, "streaming is not supported"))
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	next := s.feed.latest()
+	if lastID != "" {
+		id, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			writeError(w, errorf(http.StatusBadRequest, "invalid event id %q", lastID))
+			return
+		}
+		next = id
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		events, last, ok, wake := s.feed.since(next)
+		if !ok {
+			fmt.Fprintf(w, "id: %d\nevent: reset\ndata: {}\n\n", last)
+		}
+		for _, fe := range events {
+			if err := writeEvent(w, fe); err != nil {
+				return
+			}
+		}
+		next = last
+		flusher.Flush()
+
+		select {
+		case <-wake:
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// eventJSON is the data of a server-sent event.
+type eventJSON struct {
+	Type string `json:"type"`
+	// Item is the item number, before the change for deletions; 0 for
+	// list-cleared.
+	Item    int         `json:"item,omitempty"`
+	Before  *todo.Item  `json:"before,omitempty"`
+	After   *todo.Item  `json:"after,omitempty"`
+	Cleared []todo.Item `json:"cleared,omitempty"`
+}
+
+// writeEvent writes a change in the server-sent events format.
+func writeEvent(w http.ResponseWriter, fe feedEvent) error {
+	e := fe.event
+	data, err := json.Marshal(eventJSON{
+		Type:    e.Type.String(),
+		Item:    e.Index + 1,
+		Before:  e.Before,
+		After:   e.After,
+		Cleared: e.Cleared,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", fe.id, e.Type, data)
+	return err
+}
+
+// Source is where Watch reloads the list from, such as a todo.FileStore.
+type Source interface {
+	// Changed reports whether the list was changed since it was last
+	// loaded or saved.
+	Changed() bool
+	// Load returns the current list.
+	Load() (*todo.List, error)
+}
+
+// Watch makes the server load changes made to the list by other programs
+// from src, which must be what the server saves to so that its own changes
+// are not taken for changes by others. Every request checks src first, so
+// that no change is made to an outdated list, and a goroutine checks it
+// every interval until ctx is done, so that clients of /events receive the
+// differences as events while the server is idle.
+//
+// Loading is tried again at the next check if it fails, since the file may
+// have been read while being written. A request fails if it cannot load the
+// list; the goroutine passes an error that persists once to onError, if not
+// nil.
+func (s *Server) Watch(ctx context.Context, src Source, interval time.Duration, onError func(error)) {
+	s.list.Update(func(*todo.Tx) error {
+		s.src = src
+		return nil
+	})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		// failures counts the checks in a row that failed to load the list
+		failures := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := s.reload(); err != nil {
+				failures++
+				if failures == 2 && onError != nil {
+					onError(err)
+				}
+				continue
+			}
+			failures = 0
+		}
+	}()
+}
+
+// reload loads the list from the source given to Watch, if it was changed.
+// It is a transaction of its own, so that the list stays loaded if the
+// change that follows fails.
+func (s *Server) reload() error {
+	return s.list.Update(func(tx *todo.Tx) error {
+		if s.src == nil || !s.src.Changed() {
+			return nil
+		}
+		list, err := s.src.Load()
+		if err != nil {
+			return errorf(http.StatusInternalServerError, "%v", err)
+		}
+		tx.Reset(list)
+		return nil
+	})
+}
+
+// Close ends the event streams, so that shutting down the HTTP server does
+// not wait for them. It can be registered with http.Server.RegisterOnShutdown.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
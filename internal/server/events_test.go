@@ -0,0 +1,353 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// sseEvent is an event read from /events.
+type sseEvent struct {
+	id   string
+	name string
+	data map[string]any
+}
+
+// stream is a connection to /events.
+type stream struct {
+	events chan sseEvent
+	cancel func()
+}
+
+// openStream connects to /events with headers given as name, value pairs,
+// and reads the events sent until the test ends.
+func (ts *testServer) openStream(t *testing.T, query string, headers ...string) *stream {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/events"+query, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		cancel()
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected Content-Type text/event-stream, got %q", ct)
+	}
+
+	s := &stream{events: make(chan sseEvent, 100)}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer resp.Body.Close()
+		var e sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if e.name != "" {
+					s.events <- e
+				}
+				e = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				e.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				e.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e.data)
+			}
+		}
+	}()
+	s.cancel = func() {
+		cancel()
+		wg.Wait()
+	}
+	t.Cleanup(s.cancel)
+	return s
+}
+
+// next returns the next event, failing the test if none arrives soon.
+func (s *stream) next(t *testing.T) sseEvent {
+	t.Helper()
+	select {
+	case e := <-s.events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+		return sseEvent{}
+	}
+}
+
+// text returns the text of the item after the change.
+func (e sseEvent) text() string {
+	after, _ := e.data["after"].(map[string]any)
+	text, _ := after["text"].(string)
+	return text
+}
+
+// id returns the id of an event as a number.
+func id(t *testing.T, e sseEvent) uint64 {
+	t.Helper()
+	n, err := strconv.ParseUint(e.id, 10, 64)
+	if err != nil {
+		t.Fatalf("Invalid event id %q", e.id)
+	}
+	return n
+}
+
+func TestStreamEvents(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.openStream(t, "")
+
+	ts.do(t, http.MethodPost, "/items", `{"text":"Book flights"}`)
+	ts.do(t, http.MethodPost, "/items/1/complete", "")
+	ts.do(t, http.MethodDelete, "/items/2", "")
+
+	e := s.next(t)
+	if e.name != "item-added" || e.text() != "Book flights" || e.data["item"] != 4.0 {
+		t.Errorf("Expected item-added of item 4 Book flights, got %s %v", e.name, e.data)
+	}
+	e = s.next(t)
+	if e.name != "item-completed" || e.text() != "Buy milk" || e.data["item"] != 1.0 {
+		t.Errorf("Expected item-completed of item 1 Buy milk, got %s %v", e.name, e.data)
+	}
+	e = s.next(t)
+	before, _ := e.data["before"].(map[string]any)
+	if e.name != "item-deleted" || before["text"] != "Write report" || e.data["item"] != 2.0 {
+		t.Errorf("Expected item-deleted of item 2 Write report, got %s %v", e.name, e.data)
+	}
+}
+
+func TestStreamEventsSkipsFailedChanges(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.openStream(t, "")
+
+	ts.fail = true
+	ts.do(t, http.MethodPost, "/items", `{"text":"Lost"}`)
+	ts.fail = false
+	ts.do(t, http.MethodPost, "/items", `{"text":"Kept"}`)
+
+	if e := s.next(t); e.text() != "Kept" {
+		t.Errorf("Expected only the saved item, got %s %v", e.name, e.data)
+	}
+}
+
+func TestStreamEventsResume(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.openStream(t, "")
+
+	ts.do(t, http.MethodPost, "/items", `{"text":"First"}`)
+	first := s.next(t)
+	s.cancel()
+
+	ts.do(t, http.MethodPost, "/items", `{"text":"Second"}`)
+	ts.do(t, http.MethodPost, "/items", `{"text":"Third"}`)
+
+	for _, resume := range []*stream{
+		ts.openStream(t, "", "Last-Event-ID", first.id),
+		ts.openStream(t, "?last_event_id="+first.id),
+	} {
+		second, third := resume.next(t), resume.next(t)
+		if second.text() != "Second" || third.text() != "Third" {
+			t.Errorf("Expected the missed events Second and Third, got %q and %q", second.text(), third.text())
+		}
+		if id(t, second) <= id(t, first) || id(t, third) <= id(t, second) {
+			t.Errorf("Expected increasing ids after %s, got %s and %s", first.id, second.id, third.id)
+		}
+	}
+}
+
+func TestStreamEventsReset(t *testing.T) {
+	ts := newTestServer(t)
+
+	s := ts.openStream(t, "", "Last-Event-ID", "1")
+	e := s.next(t)
+	if e.name != "reset" {
+		t.Fatalf("Expected reset for an unknown id, got %s", e.name)
+	}
+
+	// The stream goes on from the reset
+	ts.do(t, http.MethodPost, "/items", `{"text":"After reset"}`)
+	if e := s.next(t); e.text() != "After reset" {
+		t.Errorf("Expected the event after the reset, got %s %v", e.name, e.data)
+	}
+
+	resp, result := ts.do(t, http.MethodGet, "/events?last_event_id=abc", "")
+	if resp.StatusCode != http.StatusBadRequest || result["error"] == nil {
+		t.Errorf("Expected 400 with an error for an invalid id, got %d %v", resp.StatusCode, result)
+	}
+}
+
+func TestCloseEndsStreams(t *testing.T) {
+	ts := newTestServer(t)
+	s := ts.openStream(t, "")
+
+	ts.handler.Close()
+	select {
+	case _, ok := <-s.events:
+		if ok {
+			t.Error("Expected no events after Close")
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.cancel()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the stream to end after Close")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	store := todo.NewFileStore(path)
+	list, err := store.Load()
+	if err != nil {
+		t.Fatalf("Failed to load list: %v", err)
+	}
+	list.Add("Buy milk")
+	if err := store.Save(list); err != nil {
+		t.Fatalf("Failed to save list: %v", err)
+	}
+
+	ts := &testServer{list: todo.NewSyncList(list)}
+	ts.handler = New(ts.list, store.Save)
+	ts.Server = httptest.NewServer(ts.handler)
+	t.Cleanup(ts.Close)
+	s := ts.openStream(t, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 10)
+	ts.handler.Watch(ctx, store, 10*time.Millisecond, func(err error) { errs <- err })
+
+	// A change through the server is not reported again by Watch
+	ts.do(t, http.MethodPost, "/items", `{"text":"From the API"}`)
+	if e := s.next(t); e.text() != "From the API" {
+		t.Errorf("Expected the item added through the API, got %s %v", e.name, e.data)
+	}
+
+	// A change by another program is
+	other := todo.NewList()
+	if err := other.Load(path); err != nil {
+		t.Fatalf("Failed to load list: %v", err)
+	}
+	other.Complete(0)
+	other.Add("From the CLI")
+	time.Sleep(10 * time.Millisecond) // let the modification time move on
+	if err := other.Save(path); err != nil {
+		t.Fatalf("Failed to save list: %v", err)
+	}
+
+	e := s.next(t)
+	if e.name != "item-completed" || e.text() != "Buy milk" {
+		t.Errorf("Expected item-completed of Buy milk, got %s %v", e.name, e.data)
+	}
+	e = s.next(t)
+	if e.name != "item-added" || e.text() != "From the CLI" || e.data["item"] != 3.0 {
+		t.Errorf("Expected item-added of item 3 From the CLI, got %s %v", e.name, e.data)
+	}
+	select {
+	case e := <-s.events:
+		t.Errorf("Expected no more events, got %s %v", e.name, e.data)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if n := ts.list.Count(); n != 3 {
+		t.Errorf("Expected the server's list to have 3 items, got %d", n)
+	}
+	select {
+	case err := <-errs:
+		t.Errorf("Expected no errors, got %v", err)
+	default:
+	}
+
+	// A file that cannot be read is reported and tried again
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	select {
+	case err := <-errs:
+		if err == nil || errors.Is(err, context.Canceled) {
+			t.Errorf("Expected a load error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a load error")
+	}
+	if n := ts.list.Count(); n != 3 {
+		t.Errorf("Expected the list to be kept on a load error, got %d items", n)
+	}
+}
+
+func TestWatchReloadsBeforeRequests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	store := todo.NewFileStore(path)
+	list, err := store.Load()
+	if err != nil {
+		t.Fatalf("Failed to load list: %v", err)
+	}
+
+	ts := &testServer{list: todo.NewSyncList(list)}
+	ts.handler = New(ts.list, store.Save)
+	ts.Server = httptest.NewServer(ts.handler)
+	t.Cleanup(ts.Close)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The periodic check never runs during the test
+	ts.handler.Watch(ctx, store, time.Hour, nil)
+	s := ts.openStream(t, "")
+
+	other := todo.NewList()
+	other.Add("From the CLI")
+	if err := other.Save(path); err != nil {
+		t.Fatalf("Failed to save list: %v", err)
+	}
+
+	resp, result := ts.do(t, http.MethodPost, "/items", `{"text":"From the API"}`)
+	if resp.StatusCode != http.StatusCreated || result["id"] != 2.0 {
+		t.Errorf("Expected the item to be added as item 2, got %d %v", resp.StatusCode, result)
+	}
+	if e := s.next(t); e.text() != "From the CLI" {
+		t.Errorf("Expected the change by the CLI first, got %s %v", e.name, e.data)
+	}
+	if e := s.next(t); e.text() != "From the API" {
+		t.Errorf("Expected the change through the API next, got %s %v", e.name, e.data)
+	}
+
+	saved := todo.NewList()
+	if err := saved.Load(path); err != nil {
+		t.Fatalf("Failed to load list: %v", err)
+	}
+	if saved.Count() != 2 {
+		t.Errorf("Expected the file to keep both items, got %d", saved.Count())
+	}
+}
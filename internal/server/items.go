@@ -0,0 +1,342 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// itemJSON encodes an item with its number as "id".
+func itemJSON(index int, item todo.Item) (json.RawMessage, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	// Items always encode as a non-empty object
+	return append([]byte(`{"id":`+strconv.Itoa(index+1)+`,`), data[1:]...), nil
+}
+
+// writeItem sends an item with its entity tag.
+func writeItem(w http.ResponseWriter, status int, index int, item todo.Item) {
+	data, err := itemJSON(index, item)
+	if err != nil {
+		writeError(w, errorf(http.StatusInternalServerError, "failed to encode item: %v", err))
+		return
+	}
+	w.Header().Set("ETag", etag(item))
+	writeJSON(w, status, data)
+}
+
+// listItems sends the items matching the filter given in the query: the
+// where and sort parameters take filter expressions and sort keys as
+// 'todo list' does, and other parameters are field=value terms, as in
+// /items?project=home&done=false.
+func (s *Server) listItems(w http.ResponseWriter, r *http.Request) {
+	list := s.list.Snapshot()
+	tag := etag(list)
+	w.Header().Set("ETag", tag)
+	if notModified(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	where, err := queryFilter(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	indices, err := list.Query(where, r.URL.Query().Get("sort"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	items := make([]json.RawMessage, len(indices))
+	for i, index := range indices {
+		if items[i], err = itemJSON(index, list.Items[index]); err != nil {
+			writeError(w, errorf(http.StatusInternalServerError, "failed to encode item: %v", err))
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// queryFilter builds a filter expression from the query parameters.
+func queryFilter(r *http.Request) (string, error) {
+	query := r.URL.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var terms []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			switch {
+			case name == "sort":
+			case name == "where":
+				terms = append(terms, value)
+			case strings.ContainsAny(value, " \t\n"):
+				return "", errorf(http.StatusBadRequest, "%s: filter values cannot contain spaces (use ~ in where for part of a value)", name)
+			default:
+				terms = append(terms, name+"="+value)
+			}
+		}
+	}
+	return strings.Join(terms, " "), nil
+}
+
+// getItem sends one item.
+func (s *Server) getItem(w http.ResponseWriter, r *http.Request, index int) {
+	item, err := s.list.Item(index)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if tag := etag(item); notModified(r, tag) {
+		w.Header().Set("ETag", tag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeItem(w, http.StatusOK, index, item)
+}
+
+// addItem adds an item from a JSON object holding its text and any other
+// properties, and sends it back. If-Match applies to the whole list.
+func (s *Server) addItem(w http.ResponseWriter, r *http.Request) {
+	var c changes
+	if err := decodeBody(w, r, &c); err != nil {
+		writeError(w, err)
+		return
+	}
+	if strings.TrimSpace(c.text) == "" {
+		writeError(w, errors.New("missing text"))
+		return
+	}
+
+	var index int
+	var item todo.Item
+	err := s.list.Update(func(tx *todo.Tx) error {
+		if err := checkMatch(r, etag(tx.List)); err != nil {
+			return err
+		}
+		index = tx.Add(c.text)
+		if err := c.apply(tx.List, index); err != nil {
+			return err
+		}
+		if err := s.runHook(tx.List, todo.ItemAdded, index, nil); err != nil {
+			return err
+		}
+		item = tx.Items[index].Clone()
+		return s.commit(tx.List)
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/items/%d", index+1))
+	writeItem(w, http.StatusCreated, index, item)
+}
+
+// updateItem changes the properties given in a JSON object and sends the
+// item back.
+func (s *Server) updateItem(w http.ResponseWriter, r *http.Request, index int) {
+	var c changes
+	if err := decodeBody(w, r, &c); err != nil {
+		writeError(w, err)
+		return
+	}
+	// Only marking an item as done counts as completing it
+	change := todo.ItemEdited
+	if c.done != nil && *c.done && len(c.set) == 0 {
+		change = todo.ItemCompleted
+	}
+	s.change(w, r, index, change, c.apply)
+}
+
+// completeItem marks an item as done and sends it back.
+func (s *Server) completeItem(w http.ResponseWriter, r *http.Request, index int) {
+	s.change(w, r, index, todo.ItemCompleted, func(list *todo.List, index int) error {
+		return list.Complete(index)
+	})
+}
+
+// change runs fn on the item at index if it matches the request's
+// If-Match header, runs the hook for the change, saves the list and sends
+// the item back. The hook is skipped if fn leaves the item as it was.
+func (s *Server) change(w http.ResponseWriter, r *http.Request, index int, change todo.EventType, fn func(list *todo.List, index int) error) {
+	var item todo.Item
+	err := s.list.Update(func(tx *todo.Tx) error {
+		if err := matchItem(r, tx.List, index); err != nil {
+			return err
+		}
+		before := tx.Items[index].Clone()
+		if err := fn(tx.List, index); err != nil {
+			return err
+		}
+		if etag(tx.Items[index]) != etag(before) {
+			if err := s.runHook(tx.List, change, index, &before); err != nil {
+				return err
+			}
+		}
+		item = tx.Items[index].Clone()
+		return s.commit(tx.List)
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeItem(w, http.StatusOK, index, item)
+}
+
+// deleteItem deletes an item.
+func (s *Server) deleteItem(w http.ResponseWriter, r *http.Request, index int) {
+	err := s.list.Update(func(tx *todo.Tx) error {
+		if err := matchItem(r, tx.List, index); err != nil {
+			return err
+		}
+		if err := s.runHook(tx.List, todo.ItemDeleted, index, nil); err != nil {
+			return err
+		}
+		if err := tx.Delete(index); err != nil {
+			return err
+		}
+		return s.commit(tx.List)
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// matchItem checks that the item at index exists and matches the request's
+// If-Match header.
+func matchItem(r *http.Request, list *todo.List, index int) error {
+	if index >= list.Count() {
+		return &todo.IndexError{Index: index, Len: list.Count()}
+	}
+	return checkMatch(r, etag(list.Items[index]))
+}
+
+// runHook calls the hook, if one is set, for a change to the item at index.
+func (s *Server) runHook(list *todo.List, change todo.EventType, index int, before *todo.Item) error {
+	if s.hook == nil {
+		return nil
+	}
+	if err := s.hook(list, change, index, before); err != nil {
+		return errorf(http.StatusConflict, "%v", err)
+	}
+	return nil
+}
+
+// commit saves the list after a change.
+func (s *Server) commit(list *todo.List) error {
+	if err := s.save(list); err != nil {
+		return errorf(http.StatusInternalServerError, "%v", err)
+	}
+	return nil
+}
+
+// changes are the properties to set on an item, decoded from a JSON
+// object. Values are strings, numbers, arrays of strings for tags and null
+// to clear a property; done takes a boolean.
+type changes struct {
+	text string
+	done *bool
+	// set holds the other properties in name order, as given to List.Set
+	set [][2]string
+}
+
+// UnmarshalJSON decodes the properties of a JSON object.
+func (c *changes) UnmarshalJSON(data []byte) error {
+	var props map[string]json.RawMessage
+	if err := json.Unmarshal(data, &props); err != nil {
+		return err
+	}
+	if props == nil {
+		return errors.New("expected an object")
+	}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		raw := props[name]
+		if name == "done" {
+			var done bool
+			if err := json.Unmarshal(raw, &done); err != nil {
+				return fmt.Errorf("done must be true or false")
+			}
+			c.done = &done
+			continue
+		}
+
+		value, err := propertyValue(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if name == "text" {
+			c.text = value
+		}
+		c.set = append(c.set, [2]string{name, value})
+	}
+	return nil
+}
+
+// propertyValue converts a JSON value to the string List.Set takes.
+func propertyValue(raw json.RawMessage) (string, error) {
+	var v any
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return "", err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case []any:
+		values := make([]string, len(v))
+		for i, elem := range v {
+			s, ok := elem.(string)
+			if !ok || strings.Contains(s, ",") {
+				return "", errors.New("expected an array of strings without commas")
+			}
+			values[i] = s
+		}
+		return strings.Join(values, ","), nil
+	}
+	return "", errors.New("expected a string, number, array of strings or null")
+}
+
+// apply makes the changes to the item at index.
+func (c changes) apply(list *todo.List, index int) error {
+	for _, prop := range c.set {
+		if err := list.Set(index, prop[0], prop[1]); err != nil {
+			return err
+		}
+	}
+	if c.done != nil {
+		if *c.done {
+			return list.Complete(index)
+		}
+		return list.Uncomplete(index)
+	}
+	return nil
+}
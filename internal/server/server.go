@@ -0,0 +1,280 @@
+// Package server provides a REST API for a todo list over HTTP, for tools
+// such as editor plugins and dashboards.
+//
+// Items are identified by their number, as on the command line:
+//
+//	GET    /items                 list items, filtered by query parameters
+//	POST   /items                 add an item
+//	GET    /items/{id}            get an item
+//	PATCH  /items/{id}            change properties of an item
+//	DELETE /items/{id}            delete an item
+//	POST   /items/{id}/complete   mark an item as done
+//	GET    /events                stream changes as server-sent events
+//
+// Changes run the hook set with SetHook, which may veto them.
+//
+// Since numbers change when items are deleted, responses carry an ETag and
+// changes may be made conditional with If-Match: a change to an item that
+// no longer matches the tag fails with 412 Precondition Failed.
+//
+// Clients of /events are sent each change as it is made, whether through
+// the API or, with Watch, by other programs writing the list's file.
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// maxBodySize limits the size of request bodies.
+const maxBodySize = 1 << 20
+
+// Server serves the REST API for a list. It implements http.Handler.
+type Server struct {
+	list *todo.SyncList
+	save func(list *todo.List) error
+	hook todo.HookFunc
+	feed *feed
+	// src is the source given to Watch; it is used with the list locked
+	src Source
+	// done is closed by Close to end the event streams
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New returns a server for list. save is called with the list after each
+// change, while no other request can change it, and the change is undone
+// if it fails. A nil save keeps changes in memory only.
+func New(list *todo.SyncList, save func(list *todo.List) error) *Server {
+	if save == nil {
+		save = func(*todo.List) error { return nil }
+	}
+	s := &Server{list: list, save: save, feed: newFeed(), done: make(chan struct{})}
+	list.Subscribe(s.feed.add)
+	return s
+}
+
+// SetHook sets the function called for each change made through the API,
+// before the list is saved. An error it returns vetoes the change and is
+// reported with 409 Conflict.
+func (s *Server) SetHook(hook todo.HookFunc) {
+	s.hook = hook
+}
+
+// statusError is an error to report with a particular HTTP status.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// errorf returns an error reported with status.
+func errorf(status int, format string, args ...any) error {
+	return &statusError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// ServeHTTP routes a request to the handler of its path and method.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := checkOrigin(r); err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := s.reload(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 1 && parts[0] == "events" {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, "GET")
+			return
+		}
+		s.streamEvents(w, r)
+		return
+	}
+	if parts[0] != "items" || len(parts) > 3 {
+		writeError(w, errorf(http.StatusNotFound, "no such endpoint: %s", r.URL.Path))
+		return
+	}
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			s.listItems(w, r)
+		case http.MethodPost:
+			s.addItem(w, r)
+		default:
+			methodNotAllowed(w, "GET, HEAD, POST")
+		}
+		return
+	}
+
+	index, err := parseID(parts[1])
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if len(parts) == 3 {
+		if parts[2] != "complete" {
+			writeError(w, errorf(http.StatusNotFound, "no such endpoint: %s", r.URL.Path))
+			return
+		}
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, "POST")
+			return
+		}
+		s.completeItem(w, r, index)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		s.getItem(w, r, index)
+	case http.MethodPatch:
+		s.updateItem(w, r, index)
+	case http.MethodDelete:
+		s.deleteItem(w, r, index)
+	default:
+		methodNotAllowed(w, "GET, HEAD, PATCH, DELETE")
+	}
+}
+
+// checkOrigin rejects requests made by web pages from other sites, which
+// browsers send without asking when they look like form submissions.
+func checkOrigin(r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host != r.Host {
+		return errorf(http.StatusForbidden, "cross-origin requests are not allowed")
+	}
+	return nil
+}
+
+// parseID converts an item number from a path to an index.
+func parseID(id string) (int, error) {
+	n, err := strconv.Atoi(id)
+	if err != nil || n < 1 {
+		return 0, errorf(http.StatusNotFound, "item %s not found", id)
+	}
+	return n - 1, nil
+}
+
+// methodNotAllowed reports a method the endpoint does not support.
+func methodNotAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	writeError(w, errorf(http.StatusMethodNotAllowed, "method not allowed (use %s)", allow))
+}
+
+// errorStatus returns the HTTP status and message reporting err. Index
+// errors from the todo package mean the item does not exist; its other
+// errors reject invalid values.
+func errorStatus(err error) (int, string) {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status, err.Error()
+	}
+	var indexErr *todo.IndexError
+	if errors.As(err, &indexErr) {
+		return http.StatusNotFound, fmt.Sprintf("item %d not found", indexErr.Index+1)
+	}
+	return http.StatusBadRequest, err.Error()
+}
+
+// writeError sends an error as {"error": "message"}.
+func writeError(w http.ResponseWriter, err error) {
+	status, msg := errorStatus(err)
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// writeJSON sends v as the JSON body of a response with status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
+
+// decodeBody decodes the JSON body of a request into v.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return errorf(http.StatusUnsupportedMediaType, "request body must be application/json")
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return errorf(http.StatusRequestEntityTooLarge, "request body is larger than %d bytes", maxBodySize)
+		}
+		return errorf(http.StatusBadRequest, "invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+// etag returns a strong entity tag for the JSON encoding of v.
+func etag(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// checkMatch fails with 412 Precondition Failed if the request has an
+// If-Match header that names neither the current tag nor *.
+func checkMatch(r *http.Request, current string) error {
+	header := r.Header.Get("If-Match")
+	if header == "" || matchesTag(header, current) {
+		return nil
+	}
+	return errorf(http.StatusPreconditionFailed, "the resource was changed since it was read (ETag %s)", current)
+}
+
+// notModified reports whether the request has an If-None-Match header
+// naming the current tag.
+func notModified(r *http.Request, current string) bool {
+	header := r.Header.Get("If-None-Match")
+	return header != "" && matchesTag(header, current)
+}
+
+// matchesTag reports whether a comma-separated list of entity tags names
+// current or is *.
+func matchesTag(header, current string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
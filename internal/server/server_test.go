@@ -0,0 +1,361 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// testServer is a server on a list of three items that records saves.
+type testServer struct {
+	*httptest.Server
+	handler *Server
+	list    *todo.SyncList
+	saves   int
+	// fail makes saving fail
+	fail bool
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	list := todo.NewList()
+	list.Add("Buy milk")
+	list.Add("Write report")
+	list.Add("Call mum")
+	list.Set(0, "project", "home")
+	list.Set(1, "project", "work")
+	list.Set(1, "priority", "high")
+	list.Complete(2)
+
+	ts := &testServer{list: todo.NewSyncList(list)}
+	ts.handler = New(ts.list, func(*todo.List) error {
+		if ts.fail {
+			return errors.New("disk full")
+		}
+		ts.saves++
+		return nil
+	})
+	ts.Server = httptest.NewServer(ts.handler)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// do sends a request with an optional JSON body and headers given as
+// name, value pairs, and decodes the JSON response into a map.
+func (ts *testServer) do(t *testing.T, method, path, body string, headers ...string) (*http.Response, map[string]any) {
+	t.Helper()
+
+	var req *http.Request
+	var err error
+	if body != "" {
+		req, err = http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req, err = http.NewRequest(method, ts.URL+path, nil)
+	}
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]any
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified {
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response to %s %s: %v", method, path, err)
+		}
+	}
+	return resp, result
+}
+
+// texts returns the texts of the items in a list response.
+func texts(result map[string]any) []string {
+	var texts []string
+	items, _ := result["items"].([]any)
+	for _, item := range items {
+		texts = append(texts, item.(map[string]any)["text"].(string))
+	}
+	return texts
+}
+
+func TestListItems(t *testing.T) {
+	ts := newTestServer(t)
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "Buy milk,Write report,Call mum"},
+		{"?done=false", "Buy milk,Write report"},
+		{"?project=work", "Write report"},
+		{"?where=pending+text~milk", "Buy milk"},
+		{"?sort=-text", "Write report,Call mum,Buy milk"},
+		{"?done=true&sort=text", "Call mum"},
+	}
+	for _, tt := range tests {
+		resp, result := ts.do(t, "GET", "/items"+tt.query, "")
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", tt.query, resp.StatusCode)
+			continue
+		}
+		if got := strings.Join(texts(result), ","); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.query, tt.want, got)
+		}
+	}
+
+	_, result := ts.do(t, "GET", "/items?project=work", "")
+	item := result["items"].([]any)[0].(map[string]any)
+	if item["id"] != float64(2) || item["priority"] != "high" {
+		t.Errorf("Expected item 2 with its properties, got %v", item)
+	}
+
+	for _, query := range []string{"?where=colour=red", "?sort=tags", "?project=my+home"} {
+		if resp, result := ts.do(t, "GET", "/items"+query, ""); resp.StatusCode != http.StatusBadRequest || result["error"] == "" {
+			t.Errorf("%s: expected 400 with an error, got %d %v", query, resp.StatusCode, result)
+		}
+	}
+}
+
+func TestGetItem(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, item := ts.do(t, "GET", "/items/3", "")
+	if resp.StatusCode != http.StatusOK || item["text"] != "Call mum" || item["done"] != true || item["id"] != float64(3) {
+		t.Errorf("Expected item 3, got %d %v", resp.StatusCode, item)
+	}
+	if resp.Header.Get("ETag") == "" {
+		t.Error("Expected an ETag")
+	}
+
+	resp, _ = ts.do(t, "GET", "/items/3", "", "If-None-Match", resp.Header.Get("ETag"))
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("Expected 304 for a matching If-None-Match, got %d", resp.StatusCode)
+	}
+
+	for _, path := range []string{"/items/4", "/items/0", "/items/x", "/items/1/x", "/other"} {
+		if resp, result := ts.do(t, "GET", path, ""); resp.StatusCode != http.StatusNotFound || result["error"] == "" {
+			t.Errorf("%s: expected 404 with an error, got %d %v", path, resp.StatusCode, result)
+		}
+	}
+	if _, result := ts.do(t, "GET", "/items/4", ""); result["error"] != "item 4 not found" {
+		t.Errorf("Expected the item number in the error, got %v", result["error"])
+	}
+}
+
+func TestAddItem(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, item := ts.do(t, "POST", "/items", `{"text": "Water plants", "tags": ["home", "garden"], "priority": "low", "due": null}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d %v", resp.StatusCode, item)
+	}
+	if resp.Header.Get("Location") != "/items/4" || item["id"] != float64(4) {
+		t.Errorf("Expected item 4, got %s and %v", resp.Header.Get("Location"), item["id"])
+	}
+	tags, _ := item["tags"].([]any)
+	if item["text"] != "Water plants" || item["priority"] != "low" || len(tags) != 2 {
+		t.Errorf("Expected the given properties, got %v", item)
+	}
+	if ts.list.Count() != 4 || ts.saves != 1 {
+		t.Errorf("Expected the item to be added and saved, got %d items and %d saves", ts.list.Count(), ts.saves)
+	}
+
+	for _, body := range []string{
+		`{"priority": "high"}`,
+		`{"text": "Task", "priority": "urgent"}`,
+		`{"text": "Task", "colour": "red"}`,
+		`{"text": "Task", "tags": [1]}`,
+		`{"text": "Task", "done": "yes"}`,
+		`{"text": `,
+		`[]`,
+	} {
+		if resp, result := ts.do(t, "POST", "/items", body); resp.StatusCode != http.StatusBadRequest || result["error"] == "" {
+			t.Errorf("%s: expected 400 with an error, got %d %v", body, resp.StatusCode, result)
+		}
+	}
+	if ts.list.Count() != 4 {
+		t.Errorf("Expected invalid requests to add nothing, got %d items", ts.list.Count())
+	}
+
+	req, _ := http.NewRequest("POST", ts.URL+"/items", strings.NewReader(`{"text": "Task"}`))
+	req.Header.Set("Content-Type", "text/plain")
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected 415 for a body that is not JSON, got %v %v", resp.StatusCode, err)
+	}
+}
+
+func TestUpdateItem(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, item := ts.do(t, "PATCH", "/items/1", `{"text": "Buy oat milk", "project": null, "done": true}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d %v", resp.StatusCode, item)
+	}
+	if item["text"] != "Buy oat milk" || item["project"] != nil || item["done"] != true {
+		t.Errorf("Expected the changes, got %v", item)
+	}
+
+	resp, item = ts.do(t, "PATCH", "/items/1", `{"done": false, "priority": "nope"}`)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid value, got %d %v", resp.StatusCode, item)
+	}
+	if got, _ := ts.list.Item(0); !got.Done {
+		t.Error("Expected a failed change to leave the item alone")
+	}
+
+	if resp, _ := ts.do(t, "PATCH", "/items/9", `{"text": "Task"}`); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestCompleteAndDeleteItem(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, item := ts.do(t, "POST", "/items/2/complete", "")
+	if resp.StatusCode != http.StatusOK || item["done"] != true {
+		t.Errorf("Expected the item to be completed, got %d %v", resp.StatusCode, item)
+	}
+
+	resp, _ = ts.do(t, "DELETE", "/items/1", "")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", resp.StatusCode)
+	}
+	if ts.list.Count() != 2 || ts.saves != 2 {
+		t.Errorf("Expected the item to be deleted and saved, got %d items and %d saves", ts.list.Count(), ts.saves)
+	}
+	if resp, _ := ts.do(t, "DELETE", "/items/3", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", resp.StatusCode)
+	}
+
+	resp, _ = ts.do(t, "PUT", "/items/1", `{}`)
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") == "" {
+		t.Errorf("Expected 405 with Allow, got %d", resp.StatusCode)
+	}
+	if resp, _ := ts.do(t, "GET", "/items/1/complete", ""); resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", resp.StatusCode)
+	}
+}
+
+func TestOptimisticConcurrency(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, _ := ts.do(t, "GET", "/items/2", "")
+	tag := resp.Header.Get("ETag")
+
+	// Another client deletes item 1, so item 2 is now another item
+	ts.do(t, "DELETE", "/items/1", "")
+	resp, result := ts.do(t, "PATCH", "/items/2", `{"text": "Changed"}`, "If-Match", tag)
+	if resp.StatusCode != http.StatusPreconditionFailed || result["error"] == "" {
+		t.Errorf("Expected 412 after the item changed, got %d %v", resp.StatusCode, result)
+	}
+	if resp, _ := ts.do(t, "DELETE", "/items/2", "", "If-Match", tag); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 for a delete, got %d", resp.StatusCode)
+	}
+
+	resp, _ = ts.do(t, "GET", "/items/1", "")
+	tag = resp.Header.Get("ETag")
+	resp, _ = ts.do(t, "PATCH", "/items/1", `{"text": "Report written"}`, "If-Match", tag)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 for a matching tag, got %d", resp.StatusCode)
+	}
+	if resp.Header.Get("ETag") == tag {
+		t.Error("Expected the ETag to change with the item")
+	}
+
+	resp, _ = ts.do(t, "GET", "/items", "")
+	listTag := resp.Header.Get("ETag")
+	if resp, _ := ts.do(t, "GET", "/items", "", "If-None-Match", listTag); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("Expected 304 for an unchanged list, got %d", resp.StatusCode)
+	}
+	if resp, _ := ts.do(t, "POST", "/items", `{"text": "Task"}`, "If-Match", listTag); resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected 201 for a matching list tag, got %d", resp.StatusCode)
+	}
+	if resp, _ := ts.do(t, "POST", "/items", `{"text": "Task"}`, "If-Match", listTag); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Errorf("Expected 412 once the list changed, got %d", resp.StatusCode)
+	}
+}
+
+func TestSaveFailureRollsBack(t *testing.T) {
+	ts := newTestServer(t)
+	ts.fail = true
+
+	resp, result := ts.do(t, "POST", "/items", `{"text": "Task"}`)
+	if resp.StatusCode != http.StatusInternalServerError || !strings.Contains(result["error"].(string), "disk full") {
+		t.Errorf("Expected 500 with the save error, got %d %v", resp.StatusCode, result)
+	}
+	if ts.list.Count() != 3 {
+		t.Errorf("Expected the change to be undone, got %d items", ts.list.Count())
+	}
+}
+
+func TestHook(t *testing.T) {
+	ts := newTestServer(t)
+	var changes []todo.EventType
+	ts.handler.SetHook(func(list *todo.List, change todo.EventType, index int, before *todo.Item) error {
+		changes = append(changes, change)
+		item := list.Items[index]
+		if item.Text == "Forbidden" || change == todo.ItemDeleted {
+			return errors.New("not allowed")
+		}
+		if change == todo.ItemAdded {
+			item.Text += " (checked)"
+			return list.Replace(index, item)
+		}
+		return nil
+	})
+
+	resp, item := ts.do(t, "POST", "/items", `{"text": "Task"}`)
+	if resp.StatusCode != http.StatusCreated || item["text"] != "Task (checked)" {
+		t.Errorf("Expected the item as changed by the hook, got %d %v", resp.StatusCode, item)
+	}
+	resp, result := ts.do(t, "POST", "/items", `{"text": "Forbidden"}`)
+	if resp.StatusCode != http.StatusConflict || !strings.Contains(result["error"].(string), "not allowed") {
+		t.Errorf("Expected 409 with the hook's error, got %d %v", resp.StatusCode, result)
+	}
+	if resp, _ := ts.do(t, "DELETE", "/items/1", ""); resp.StatusCode != http.StatusConflict {
+		t.Errorf("Expected 409 for a vetoed delete, got %d", resp.StatusCode)
+	}
+	if ts.list.Count() != 4 || ts.saves != 1 {
+		t.Errorf("Expected vetoed changes to be undone, got %d items and %d saves", ts.list.Count(), ts.saves)
+	}
+
+	changes = nil
+	ts.do(t, "PATCH", "/items/1", `{"done": true}`)
+	ts.do(t, "PATCH", "/items/1", `{"done": true}`)
+	ts.do(t, "PATCH", "/items/2", `{"text": "Report written"}`)
+	expected := []todo.EventType{todo.ItemCompleted, todo.ItemEdited}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected hooks for %v, got %v", expected, changes)
+	}
+}
+
+func TestRejectsCrossOriginRequests(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, _ := ts.do(t, "POST", "/items/1/complete", "", "Origin", "https://example.com")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for another origin, got %d", resp.StatusCode)
+	}
+	if item, _ := ts.list.Item(0); item.Done {
+		t.Error("Expected the cross-origin request to change nothing")
+	}
+
+	resp, _ = ts.do(t, "GET", "/items/1", "", "Origin", ts.URL)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 for the same origin, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,158 @@
+// Package shellwords splits command lines into words the way a POSIX shell
+// does, without expanding variables or globs.
+package shellwords
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnterminatedQuote is returned when a quoted word is not closed.
+	ErrUnterminatedQuote = errors.New("unterminated quote")
+	// ErrTrailingBackslash is returned when the input ends with a backslash
+	// that has nothing to escape. Callers reading lines may treat it as a
+	// request to continue on the next line.
+	ErrTrailingBackslash = errors.New("backslash at end of input")
+)
+
+// Split breaks a line into words separated by unquoted whitespace. Single
+// quotes preserve everything up to the closing quote. Double quotes preserve
+// everything except backslash escapes of ", \, $ and `. Outside quotes a
+// backslash escapes the next character. A backslash followed by a newline,
+// outside single quotes, joins the lines. Quoted empty strings produce empty
+// words.
+func Split(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, ErrTrailingBackslash
+			}
+			i++
+			if runes[i] == '\n' {
+				continue
+			}
+			inWord = true
+			word.WriteRune(runes[i])
+
+		case r == '\'':
+			inWord = true
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, unterminated(r, i)
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			i = end
+
+		case r == '"':
+			inWord = true
+			start := i
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '"' {
+					closed = true
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) {
+					if runes[i+1] == '\n' {
+						i++
+						continue
+					}
+					if strings.ContainsRune("\"\\$`", runes[i+1]) {
+						i++
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, unterminated(r, start)
+			}
+
+		default:
+			inWord = true
+			word.WriteRune(r)
+		}
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// WordStart returns the byte offset at which the last word of line starts,
+// or len(line) if line ends with unquoted whitespace. A word whose quote is
+// still open starts at or before the quote, so a partly typed line can be
+// completed.
+func WordStart(line string) int {
+	start := 0
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			start = i + 1
+		}
+	}
+	return start
+}
+
+// unterminated reports a quote opened at index i that is never closed.
+func unterminated(quote rune, i int) error {
+	return fmt.Errorf("%w: missing closing %c for the quote at column %d", ErrUnterminatedQuote, quote, i+1)
+}
+
+// indexRune returns the index of the first r at or after start, or -1.
+func indexRune(runes []rune, start int, r rune) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// Quote returns word quoted so that Split reads it back unchanged.
+func Quote(word string) string {
+	if word == "" {
+		return "''"
+	}
+	if !strings.ContainsAny(word, " \t\n\r'\"\\$`") {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// Join quotes words as needed and joins them with spaces.
+func Join(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = Quote(w)
+	}
+	return strings.Join(quoted, " ")
+}
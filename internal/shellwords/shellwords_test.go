@@ -0,0 +1,118 @@
+package shellwords
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", nil},
+		{"  add   buy milk ", []string{"add", "buy", "milk"}},
+		{`add "buy milk"`, []string{"add", "buy milk"}},
+		{`list --where 'due<=today'`, []string{"list", "--where", "due<=today"}},
+		{`edit 2 "spaced  text"`, []string{"edit", "2", "spaced  text"}},
+		{`say "a \"quoted\" word"`, []string{"say", `a "quoted" word`}},
+		{`path C:\\dir`, []string{"path", `C:\dir`}},
+		{`one\ word`, []string{"one word"}},
+		{`it's"" ok`, nil},
+		{`''`, []string{""}},
+		{`pre"fix"'suffix'`, []string{"prefixsuffix"}},
+		{"\tadd\t\"tabbed\ttext\"\n", []string{"add", "tabbed\ttext"}},
+		{`""`, []string{""}},
+		{`add "" ''`, []string{"add", "", ""}},
+		{`'back\slash'`, []string{`back\slash`}},
+		{`"keep \n and \t"`, []string{`keep \n and \t`}},
+		{`"cost \$5"`, []string{"cost $5"}},
+		{`'say "hi"'`, []string{`say "hi"`}},
+		{`"it's"`, []string{"it's"}},
+		{`add café ☕`, []string{"add", "café", "☕"}},
+		{`\'quoted\'`, []string{"'quoted'"}},
+		{"one \\\ntwo", []string{"one", "two"}},
+		{"con\\\ntinued", []string{"continued"}},
+		{"\"multi\\\nline\"", []string{"multiline"}},
+		{"'keep\\\nnewline'", []string{"keep\\\nnewline"}},
+	}
+	for _, tc := range tests {
+		got, err := Split(tc.input)
+		if tc.expected == nil && tc.input != "" {
+			if err == nil {
+				t.Errorf("%q: expected error, got %q", tc.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: expected no error, got %v", tc.input, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(tc.expected, "|") || len(got) != len(tc.expected) {
+			t.Errorf("%q: expected %q, got %q", tc.input, tc.expected, got)
+		}
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected error
+		message  string
+	}{
+		{`add "buy milk`, ErrUnterminatedQuote, `unterminated quote: missing closing " for the quote at column 5`},
+		{`add 'buy milk`, ErrUnterminatedQuote, `unterminated quote: missing closing ' for the quote at column 5`},
+		{`'it\'s'`, ErrUnterminatedQuote, `unterminated quote: missing closing ' for the quote at column 7`},
+		{`"escaped \"`, ErrUnterminatedQuote, `unterminated quote: missing closing " for the quote at column 1`},
+		{`add milk \`, ErrTrailingBackslash, "backslash at end of input"},
+	}
+	for _, tc := range tests {
+		_, err := Split(tc.input)
+		if !errors.Is(err, tc.expected) {
+			t.Errorf("%q: expected %v, got %v", tc.input, tc.expected, err)
+			continue
+		}
+		if err.Error() != tc.message {
+			t.Errorf("%q: expected message %q, got %q", tc.input, tc.message, err.Error())
+		}
+	}
+}
+
+func TestWordStart(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"", ""},
+		{"comp", "comp"},
+		{"complete ", ""},
+		{"set 1 tags=ba", "tags=ba"},
+		{`add "buy mi`, `"buy mi`},
+		{`add 'it''s a`, `'it''s a`},
+		{`add one\ tw`, `one\ tw`},
+		{`add "done" ne`, "ne"},
+		{"list\t--wh", "--wh"},
+	}
+	for _, tc := range tests {
+		if got := tc.line[WordStart(tc.line):]; got != tc.expected {
+			t.Errorf("%q: expected %q, got %q", tc.line, tc.expected, got)
+		}
+	}
+}
+
+func TestJoinRoundTrip(t *testing.T) {
+	words := []string{"add", "buy milk", "it's", "", `back\slash`, `"quoted"`, "multi\nline", "tab\there", "$HOME"}
+	got, err := Split(Join(words))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(got) != len(words) {
+		t.Fatalf("Expected %q, got %q", words, got)
+	}
+	for i := range words {
+		if got[i] != words[i] {
+			t.Errorf("Expected %q, got %q", words[i], got[i])
+		}
+	}
+}
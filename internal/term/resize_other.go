@@ -0,0 +1,9 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package term
+
+import "os"
+
+// NotifyResize does nothing, as resizes are not signalled on this
+// platform.
+func NotifyResize(c chan<- os.Signal) {}
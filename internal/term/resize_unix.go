@@ -0,0 +1,15 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package term
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// NotifyResize relays a signal to c whenever the terminal is resized.
+// Use signal.Stop to stop the notifications.
+func NotifyResize(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGWINCH)
+}
@@ -0,0 +1,190 @@
+// Package term provides the terminal handling shared by the line editor and
+// the full-screen interface: raw mode, the window size, decoding of keys
+// from escape sequences, and the display width of text.
+package term
+
+import (
+	"bufio"
+	"strings"
+	"unicode"
+)
+
+// Special keys, decoded from escape sequences by ReadKey. They are negative
+// so that they never clash with characters.
+const (
+	KeyUnknown rune = -(iota + 1)
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyDelete
+	KeyPageUp
+	KeyPageDown
+	KeyWordLeft
+	KeyWordRight
+	KeyKillWord
+	KeyKillWordBack
+)
+
+// Keys read as single characters.
+const (
+	KeyBackspace rune = 127
+	KeyEscape    rune = 27
+)
+
+// Ctrl returns the key code of Ctrl and the letter c.
+func Ctrl(c rune) rune {
+	return c & 0x1f
+}
+
+// ReadKey reads a key, decoding escape sequences into special keys. An
+// escape that is not followed by more input already waiting is returned
+// as KeyEscape, as terminals send a whole sequence at once.
+func ReadKey(in *bufio.Reader) (rune, error) {
+	r, _, err := in.ReadRune()
+	if err != nil || r != KeyEscape {
+		return r, err
+	}
+	if in.Buffered() == 0 {
+		return KeyEscape, nil
+	}
+
+	r, _, err = in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch r {
+	case '[':
+		return readCSI(in)
+	case 'O':
+		r, _, err = in.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		return finalKey(r, ""), nil
+	case 'b', 'B':
+		return KeyWordLeft, nil
+	case 'f', 'F':
+		return KeyWordRight, nil
+	case 'd', 'D':
+		return KeyKillWord, nil
+	case KeyBackspace, Ctrl('H'):
+		return KeyKillWordBack, nil
+	}
+	return KeyUnknown, nil
+}
+
+// readCSI decodes a control sequence such as ESC [ A or ESC [ 1 ; 5 C.
+func readCSI(in *bufio.Reader) (rune, error) {
+	var params strings.Builder
+	for {
+		r, _, err := in.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r >= 0x40 && r <= 0x7e {
+			return finalKey(r, params.String()), nil
+		}
+		params.WriteRune(r)
+	}
+}
+
+// finalKey maps the final character and parameters of a control sequence
+// onto a key. Arrows with a modifier, such as Ctrl-Left, move by words.
+func finalKey(final rune, params string) rune {
+	modified := strings.Contains(params, ";")
+	switch final {
+	case 'A':
+		return KeyUp
+	case 'B':
+		return KeyDown
+	case 'C':
+		if modified {
+			return KeyWordRight
+		}
+		return KeyRight
+	case 'D':
+		if modified {
+			return KeyWordLeft
+		}
+		return KeyLeft
+	case 'H':
+		return KeyHome
+	case 'F':
+		return KeyEnd
+	case '~':
+		switch params {
+		case "1", "7":
+			return KeyHome
+		case "4", "8":
+			return KeyEnd
+		case "3":
+			return KeyDelete
+		case "5":
+			return KeyPageUp
+		case "6":
+			return KeyPageDown
+		}
+	}
+	return KeyUnknown
+}
+
+// Width returns the number of terminal columns s occupies. Wide East
+// Asian characters take two columns and combining marks none.
+func Width(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// Truncate shortens s to at most width columns, ending it with "…" when
+// anything was cut.
+func Truncate(s string, width int) string {
+	if Width(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	used := 0
+	for _, r := range s {
+		w := runeWidth(r)
+		if used+w > width-1 {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	b.WriteString("…")
+	return b.String()
+}
+
+// runeWidth returns the number of columns r occupies.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || r == '\u200b':
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// isWide reports whether r is displayed in two columns.
+func isWide(r rune) bool {
+	return r >= 0x1100 && (r <= 0x115f || // Hangul Jamo
+		r >= 0x2e80 && r <= 0xa4cf && r != 0x303f || // CJK to Yi
+		r >= 0xac00 && r <= 0xd7a3 || // Hangul syllables
+		r >= 0xf900 && r <= 0xfaff || // CJK compatibility ideographs
+		r >= 0xfe30 && r <= 0xfe4f || // CJK compatibility forms
+		r >= 0xff00 && r <= 0xff60 || // Fullwidth forms
+		r >= 0xffe0 && r <= 0xffe6 ||
+		r >= 0x1f300 && r <= 0x1f64f || // Pictographs and emoticons
+		r >= 0x1f900 && r <= 0x1f9ff ||
+		r >= 0x20000 && r <= 0x3fffd)
+}
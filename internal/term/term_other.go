@@ -0,0 +1,23 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package term
+
+import "errors"
+
+var errUnsupported = errors.New("raw terminal mode is not supported on this platform")
+
+// IsTerminal reports false, as raw mode is not supported on this platform
+// and input is read line by line.
+func IsTerminal(fd int) bool {
+	return false
+}
+
+// MakeRaw is not supported on this platform.
+func MakeRaw(fd int) (func() error, error) {
+	return nil, errUnsupported
+}
+
+// Size is not known on this platform.
+func Size(fd int) (cols, rows int, err error) {
+	return 0, 0, errUnsupported
+}
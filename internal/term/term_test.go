@@ -0,0 +1,74 @@
+package term
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestReadKey(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected rune
+	}{
+		{"a", 'a'},
+		{"é", 'é'},
+		{"\x1b[A", KeyUp},
+		{"\x1bOB", KeyDown},
+		{"\x1b[1;5C", KeyWordRight},
+		{"\x1b[3~", KeyDelete},
+		{"\x1b[5~", KeyPageUp},
+		{"\x1b[6~", KeyPageDown},
+		{"\x1bb", KeyWordLeft},
+		{"\x1b\x7f", KeyKillWordBack},
+		{"\x1b", KeyEscape},
+		{"\x1b[99X", KeyUnknown},
+	}
+	for _, tc := range tests {
+		got, err := ReadKey(bufio.NewReader(strings.NewReader(tc.input)))
+		if err != nil {
+			t.Errorf("ReadKey(%q): unexpected error: %v", tc.input, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("ReadKey(%q): expected %d, got %d", tc.input, tc.expected, got)
+		}
+	}
+}
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected int
+	}{
+		{"abc", 3},
+		{"café", 4},
+		{"café", 4},
+		{"日本", 4},
+		{"ok 👍", 5},
+	}
+	for _, tc := range tests {
+		if got := Width(tc.s); got != tc.expected {
+			t.Errorf("Width(%q): expected %d, got %d", tc.s, tc.expected, got)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s        string
+		width    int
+		expected string
+	}{
+		{"buy milk", 10, "buy milk"},
+		{"buy milk", 8, "buy milk"},
+		{"buy milk", 5, "buy …"},
+		{"日本語", 4, "日…"},
+		{"abc", 0, ""},
+	}
+	for _, tc := range tests {
+		if got := Truncate(tc.s, tc.width); got != tc.expected {
+			t.Errorf("Truncate(%q, %d): expected %q, got %q", tc.s, tc.width, tc.expected, got)
+		}
+	}
+}
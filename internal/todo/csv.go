@@ -0,0 +1,334 @@
+package todo
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Item fields that can be mapped to CSV columns.
+const (
+	FieldText        = "text"
+	FieldDone        = "done"
+	FieldCreatedAt   = "created_at"
+	FieldCompletedAt = "completed_at"
+	FieldDue         = "due"
+	FieldPriority    = "priority"
+	FieldProject     = "project"
+	FieldTags        = "tags"
+)
+
+// csvFields lists the mappable item fields in their default column order.
+// Fields added later come last, so files without a header written before
+// them still read in order.
+var csvFields = []string{
+	FieldText, FieldDone, FieldCreatedAt, FieldCompletedAt,
+	FieldDue, FieldPriority, FieldProject, FieldTags,
+}
+
+// CSVMapping maps item field names to CSV column headers.
+type CSVMapping map[string]string
+
+// DefaultCSVMapping returns a mapping that uses the field names as headers.
+func DefaultCSVMapping() CSVMapping {
+	m := make(CSVMapping, len(csvFields))
+	for _, field := range csvFields {
+		m[field] = field
+	}
+	return m
+}
+
+// ParseCSVMapping parses a mapping of the form "text=Title,done=Status".
+// Fields that are not mentioned keep their default column names. Besides
+// the built-in fields, custom field names may be mapped.
+func ParseCSVMapping(s string) (CSVMapping, error) {
+	m := DefaultCSVMapping()
+	if strings.TrimSpace(s) == "" {
+		return m, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		column = strings.TrimSpace(column)
+		if !ok || field == "" || column == "" {
+			return nil, fmt.Errorf("invalid column mapping %q (expected field=Column)", pair)
+		}
+		if _, known := m[field]; !known && (builtinFields[field] || !fieldNamePattern.MatchString(field)) {
+			return nil, fmt.Errorf("unknown field %q in column mapping (valid fields: %s, or a custom field)",
+				field, strings.Join(csvFields, ", "))
+		}
+		m[field] = column
+	}
+
+	return m, nil
+}
+
+// column returns the header of a field, defaulting to the field name.
+func (m CSVMapping) column(field string) string {
+	if column, ok := m[field]; ok {
+		return column
+	}
+	return field
+}
+
+// WriteCSV writes the list as CSV with a header row using the given mapping.
+// Custom fields of the list follow the built-in columns. Timestamps and due
+// dates are written in RFC 3339 format, and tags joined with commas.
+func (l *List) WriteCSV(w io.Writer, m CSVMapping) error {
+	if m == nil {
+		m = DefaultCSVMapping()
+	}
+
+	cw := csv.NewWriter(w)
+	defs := l.FieldDefs()
+
+	header := make([]string, 0, len(csvFields)+len(defs))
+	for _, field := range csvFields {
+		header = append(header, m.column(field))
+	}
+	for _, def := range defs {
+		header = append(header, m.column(def.Name))
+	}
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, item := range l.Items {
+		completedAt := ""
+		if item.CompletedAt != nil {
+			completedAt = item.CompletedAt.Format(time.RFC3339)
+		}
+		createdAt := ""
+		if !item.CreatedAt.IsZero() {
+			createdAt = item.CreatedAt.Format(time.RFC3339)
+		}
+		due := ""
+		if item.Due != nil {
+			due = item.Due.Format(time.RFC3339)
+		}
+
+		record := []string{
+			item.Text, fmt.Sprintf("%t", item.Done), createdAt, completedAt,
+			due, string(item.Priority), item.Project, strings.Join(item.Tags, ","),
+		}
+		for _, def := range defs {
+			record = append(record, item.Fields[def.Name])
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV reads items from CSV using the given mapping.
+// A header row is detected when the first record contains any of the mapped
+// column names; otherwise columns are assumed to be in the default order.
+// Values of the given custom fields are read from their columns when the
+// input has a header. Malformed rows are collected in the result rather
+// than aborting the read.
+func ReadCSV(r io.Reader, m CSVMapping, fields ...FieldDef) (*ImportResult, error) {
+	if m == nil {
+		m = DefaultCSVMapping()
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	result := &ImportResult{}
+
+	first, err := cr.Read()
+	if err == io.EOF {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	first[0] = strings.TrimPrefix(first[0], "\ufeff")
+
+	columns, hasHeader := csvColumns(first, m, fields)
+	if _, ok := columns[FieldText]; !ok {
+		return nil, fmt.Errorf("CSV header has no %q column for the task text", m[FieldText])
+	}
+
+	record := first
+	if hasHeader {
+		record = nil
+	}
+
+	for {
+		line := 1
+		if record == nil {
+			record, err = cr.Read()
+			if err == io.EOF {
+				break
+			}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				result.Errors = append(result.Errors, RowError{Line: parseErr.StartLine, Err: parseErr.Err})
+				record = nil
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CSV: %w", err)
+			}
+			line, _ = cr.FieldPos(0)
+		}
+
+		if !isBlankRecord(record) {
+			item, err := csvItem(record, columns, fields)
+			if err != nil {
+				result.Errors = append(result.Errors, RowError{Line: line, Err: err})
+			} else {
+				result.Items = append(result.Items, item)
+			}
+		}
+		record = nil
+	}
+
+	return result, nil
+}
+
+// csvColumns resolves the column index of each mapped field.
+// It reports whether the first record was recognised as a header.
+func csvColumns(first []string, m CSVMapping, fields []FieldDef) (map[string]int, bool) {
+	byHeader := make(map[string]string, len(csvFields)+len(fields))
+	for _, field := range csvFields {
+		byHeader[strings.ToLower(strings.TrimSpace(m.column(field)))] = field
+	}
+	for _, def := range fields {
+		byHeader[strings.ToLower(strings.TrimSpace(m.column(def.Name)))] = def.Name
+	}
+
+	columns := make(map[string]int)
+	for i, cell := range first {
+		if field, ok := byHeader[strings.ToLower(strings.TrimSpace(cell))]; ok {
+			columns[field] = i
+		}
+	}
+	if len(columns) > 0 {
+		return columns, true
+	}
+
+	for i, field := range csvFields {
+		columns[field] = i
+	}
+	return columns, false
+}
+
+// csvItem builds an item from a single CSV record.
+func csvItem(record []string, columns map[string]int, fields []FieldDef) (Item, error) {
+	cell := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	text := cell(FieldText)
+	if text == "" {
+		return Item{}, errors.New("missing task text")
+	}
+
+	done, err := parseDone(cell(FieldDone))
+	if err != nil {
+		return Item{}, err
+	}
+
+	item := NewItem(text)
+	item.Done = done
+
+	if s := cell(FieldCreatedAt); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return Item{}, fmt.Errorf("invalid %s %q: expected RFC 3339 timestamp", FieldCreatedAt, s)
+		}
+		item.CreatedAt = t
+	}
+
+	if s := cell(FieldCompletedAt); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return Item{}, fmt.Errorf("invalid %s %q: expected RFC 3339 timestamp", FieldCompletedAt, s)
+		}
+		item.CompletedAt = &t
+		item.Done = true
+	}
+
+	if s := cell(FieldDue); s != "" {
+		t, err := ParseDate(s)
+		if err != nil {
+			return Item{}, fmt.Errorf("invalid %s %q: expected RFC 3339 timestamp or YYYY-MM-DD", FieldDue, s)
+		}
+		item.Due = &t
+	}
+
+	priority, err := ParsePriority(cell(FieldPriority))
+	if err != nil {
+		return Item{}, err
+	}
+	item.Priority = priority
+	item.Project = cell(FieldProject)
+	for _, tag := range strings.Split(cell(FieldTags), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			item.Tags = append(item.Tags, tag)
+		}
+	}
+
+	for _, def := range fields {
+		if s := cell(def.Name); s != "" {
+			if item.Fields == nil {
+				item.Fields = make(map[string]string)
+			}
+			item.Fields[def.Name] = s
+		}
+	}
+
+	return validateFields(item, fields)
+}
+
+// doneValues lists the accepted spellings of a completion status.
+var doneValues = map[string]bool{
+	"":          false,
+	"false":     false,
+	"no":        false,
+	"n":         false,
+	"0":         false,
+	"pending":   false,
+	"todo":      false,
+	"open":      false,
+	"true":      true,
+	"yes":       true,
+	"y":         true,
+	"1":         true,
+	"x":         true,
+	"done":      true,
+	"completed": true,
+	"closed":    true,
+}
+
+// parseDone interprets a completion status cell.
+func parseDone(s string) (bool, error) {
+	done, ok := doneValues[strings.ToLower(s)]
+	if !ok {
+		return false, fmt.Errorf("invalid %s value %q (expected true or false)", FieldDone, s)
+	}
+	return done, nil
+}
+
+// isBlankRecord reports whether every cell of the record is empty.
+func isBlankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
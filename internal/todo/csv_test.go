@@ -0,0 +1,168 @@
+package todo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSVMapping(t *testing.T) {
+	m, err := ParseCSVMapping("text=Title, done=Status")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if m[FieldText] != "Title" {
+		t.Errorf("Expected text column 'Title', got '%s'", m[FieldText])
+	}
+	if m[FieldDone] != "Status" {
+		t.Errorf("Expected done column 'Status', got '%s'", m[FieldDone])
+	}
+	if m[FieldCreatedAt] != FieldCreatedAt {
+		t.Errorf("Expected unmapped field to keep default column, got '%s'", m[FieldCreatedAt])
+	}
+
+	if _, err := ParseCSVMapping("notes=N"); err == nil {
+		t.Error("Expected error for unknown field")
+	}
+	if _, err := ParseCSVMapping("text"); err == nil {
+		t.Error("Expected error for mapping without column")
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	list := NewList()
+	list.Add("Plain task")
+	list.Add("Task, with \"quotes\"\nand a second line")
+	list.Complete(1)
+	list.Set(0, "due", "2024-03-01")
+	list.Set(0, "priority", "high")
+	list.Set(0, "project", "home")
+	list.Set(0, "tags", "errand,weekend")
+
+	m, _ := ParseCSVMapping("text=Title,done=Status")
+
+	var buf bytes.Buffer
+	if err := list.WriteCSV(&buf, m); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+
+	if !strings.HasPrefix(buf.String(), "Title,Status,created_at,completed_at,due,priority,project,tags\n") {
+		t.Errorf("Expected mapped header row, got '%s'", strings.SplitN(buf.String(), "\n", 2)[0])
+	}
+
+	result, err := ReadCSV(&buf, m)
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+
+	if len(result.Errors) != 0 {
+		t.Fatalf("Expected no row errors, got %v", result.Errors)
+	}
+	if len(result.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(result.Items))
+	}
+
+	if result.Items[1].Text != list.Items[1].Text {
+		t.Errorf("Expected multi-line text to survive, got '%s'", result.Items[1].Text)
+	}
+	if !result.Items[1].Done || result.Items[1].CompletedAt == nil {
+		t.Error("Expected second item to be completed with a timestamp")
+	}
+
+	want := list.Items[0].CreatedAt.Truncate(time.Second)
+	if !result.Items[0].CreatedAt.Equal(want) {
+		t.Errorf("Expected created_at %v, got %v", want, result.Items[0].CreatedAt)
+	}
+
+	got := result.Items[0]
+	if got.Due == nil || !got.Due.Equal(*list.Items[0].Due) {
+		t.Errorf("Expected due %v, got %v", list.Items[0].Due, got.Due)
+	}
+	if got.Priority != PriorityHigh || got.Project != "home" {
+		t.Errorf("Expected priority high and project home, got %q and %q", got.Priority, got.Project)
+	}
+	if strings.Join(got.Tags, ",") != "errand,weekend" {
+		t.Errorf("Expected tags errand,weekend, got %v", got.Tags)
+	}
+	if result.Items[1].Due != nil || result.Items[1].Priority != PriorityNone || result.Items[1].Tags != nil {
+		t.Errorf("Expected empty columns to leave properties unset, got %+v", result.Items[1])
+	}
+}
+
+func TestReadCSVWithoutHeader(t *testing.T) {
+	input := "Buy milk,false\nWrite report,x,2024-01-15T10:30:00Z\n"
+
+	result, err := ReadCSV(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+
+	if len(result.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(result.Items))
+	}
+	if result.Items[0].Text != "Buy milk" || result.Items[0].Done {
+		t.Errorf("Unexpected first item: %+v", result.Items[0])
+	}
+	if !result.Items[1].Done {
+		t.Error("Expected 'x' to mark the second item as done")
+	}
+
+	want := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	if !result.Items[1].CreatedAt.Equal(want) {
+		t.Errorf("Expected created_at %v, got %v", want, result.Items[1].CreatedAt)
+	}
+}
+
+func TestReadCSVReportsRowErrors(t *testing.T) {
+	input := "Status,Title,Owner\n" +
+		"done,Good row,alice\n" +
+		"maybe,Bad status,bob\n" +
+		"yes,,carol\n" +
+		"no,\"Multi\nline\",dave\n"
+
+	m, _ := ParseCSVMapping("text=Title,done=Status")
+	result, err := ReadCSV(strings.NewReader(input), m)
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+
+	if len(result.Items) != 2 {
+		t.Errorf("Expected 2 valid items, got %d", len(result.Items))
+	}
+	if len(result.Errors) != 2 {
+		t.Fatalf("Expected 2 row errors, got %d", len(result.Errors))
+	}
+
+	if result.Errors[0].Line != 3 {
+		t.Errorf("Expected first error on line 3, got %d", result.Errors[0].Line)
+	}
+	if result.Errors[1].Line != 4 {
+		t.Errorf("Expected second error on line 4, got %d", result.Errors[1].Line)
+	}
+}
+
+func TestReadCSVInvalidTimestamp(t *testing.T) {
+	input := "text,done,created_at\nTask,false,15/01/2024\n"
+
+	result, err := ReadCSV(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected 1 row error, got %d", len(result.Errors))
+	}
+	if !strings.Contains(result.Errors[0].Error(), "RFC 3339") {
+		t.Errorf("Expected RFC 3339 hint in error, got '%v'", result.Errors[0])
+	}
+}
+
+func TestReadCSVHeaderWithoutTextColumn(t *testing.T) {
+	m, _ := ParseCSVMapping("text=Title")
+	_, err := ReadCSV(strings.NewReader("done,Owner\ntrue,alice\n"), m)
+	if err == nil {
+		t.Error("Expected error when header lacks the text column")
+	}
+}
@@ -0,0 +1,360 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// EventType identifies the kind of change an Event describes.
+type EventType int
+
+// Kinds of change to a list.
+const (
+	ItemAdded EventType = iota + 1
+	ItemCompleted
+	ItemUncompleted
+	ItemEdited
+	ItemDeleted
+	ListCleared
+)
+
+// String returns the name of the event type, such as "item-added".
+func (t EventType) String() string {
+	switch t {
+	case ItemAdded:
+		return "item-added"
+	case ItemCompleted:
+		return "item-completed"
+	case ItemUncompleted:
+		return "item-uncompleted"
+	case ItemEdited:
+		return "item-edited"
+	case ItemDeleted:
+		return "item-deleted"
+	case ListCleared:
+		return "list-cleared"
+	}
+	return "unknown"
+}
+
+// Event describes a change to a list. The items it holds are copies, so
+// they stay as they were when the event was emitted.
+type Event struct {
+	Type EventType
+	// Index is the position of the item in the list, after the change for
+	// ItemAdded and before it for ItemDeleted. It is -1 for ListCleared.
+	Index int
+	// Before is the item before the change; nil for ItemAdded.
+	Before *Item
+	// After is the item after the change; nil for ItemDeleted.
+	After *Item
+	// Cleared holds the items removed by ListCleared.
+	Cleared []Item
+}
+
+// HookFunc is called for a change to the item at index, within the
+// transaction making it, by code that changes a list for a user and runs
+// their hooks. change is ItemAdded, ItemCompleted, ItemUncompleted,
+// ItemEdited or ItemDeleted. For ItemDeleted it is called before the item
+// is removed, and for the others after the change, with the item as it
+// was before in before; before is nil for ItemAdded and ItemDeleted. The
+// function may replace the item, and an error vetoes the change.
+type HookFunc func(list *List, change EventType, index int, before *Item) error
+
+// eventHub keeps the subscriptions of a list. The list creates it on first
+// use without locking, so subscribing is subject to the same rule as any
+// other use of a List: it must not race with changes to the list, and
+// SyncList takes its lock to subscribe. The hub's own lock lets the
+// functions that end subscriptions be called from any goroutine, and
+// subscribers subscribe and cancel while an event is delivered.
+type eventHub struct {
+	mu     sync.Mutex
+	subs   []*subscription
+	nextID int
+	// depth is the number of open transactions; their events are queued
+	// until the outermost one commits
+	depth  int
+	queued []Event
+}
+
+// subscription receives events through a function or a channel.
+type subscription struct {
+	id int
+	fn func(Event)
+	ch chan Event
+}
+
+// Subscribe calls fn with each change made to the list through its
+// methods, synchronously, after the change is made. Changes made in a
+// transaction are reported when it commits and not at all if it rolls
+// back. Reset reports the differences it makes; Load and direct changes to
+// Items are not reported. The returned function ends the subscription and
+// may be called from any goroutine; Subscribe itself, like the other
+// methods, must not run concurrently with other uses of the list (see
+// SyncList).
+func (l *List) Subscribe(fn func(Event)) (cancel func()) {
+	return l.hub().add(&subscription{fn: fn})
+}
+
+// Events returns a channel receiving the changes reported to Subscribe
+// callers, with room for size events. Like signal.Notify, reporting never
+// blocks a change: events that do not fit in the channel are dropped. The
+// returned function ends the subscription and closes the channel. As with
+// Subscribe, it may be called from any goroutine, but Events may not.
+func (l *List) Events(size int) (events <-chan Event, cancel func()) {
+	ch := make(chan Event, size)
+	return ch, l.hub().add(&subscription{ch: ch})
+}
+
+// hub returns the list's subscriptions, creating them if needed. Like the
+// rest of the list, l.events is not synchronised.
+func (l *List) hub() *eventHub {
+	if l.events == nil {
+		l.events = &eventHub{}
+	}
+	return l.events
+}
+
+// add registers a subscription and returns the function removing it.
+func (h *eventHub) add(s *subscription) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nextID++
+	s.id = h.nextID
+	h.subs = append(h.subs, s)
+
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for i, other := range h.subs {
+			if other.id == s.id {
+				h.subs = append(h.subs[:i:i], h.subs[i+1:]...)
+				if s.ch != nil {
+					close(s.ch)
+				}
+				return
+			}
+		}
+	}
+}
+
+// observed reports whether anyone is subscribed to the list, so that
+// events need to be built. It is called by methods changing the list,
+// which must not run concurrently with Subscribe, so l.events is read
+// without locking.
+func (l *List) observed() bool {
+	if l.events == nil {
+		return false
+	}
+	l.events.mu.Lock()
+	defer l.events.mu.Unlock()
+	return len(l.events.subs) > 0
+}
+
+// emit reports an event, or queues it while a transaction is open.
+func (l *List) emit(e Event) {
+	h := l.events
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	if h.depth > 0 {
+		h.queued = append(h.queued, e)
+		h.mu.Unlock()
+		return
+	}
+	h.mu.Unlock()
+	h.deliver([]Event{e})
+}
+
+// deliver sends events to the subscribers. Channels are filled while the
+// lock is held, so they cannot be closed meanwhile; functions are called
+// after it is released, so they may subscribe and cancel.
+func (h *eventHub) deliver(events []Event) {
+	h.mu.Lock()
+	subs := append([]*subscription(nil), h.subs...)
+	for _, e := range events {
+		for _, s := range subs {
+			if s.ch != nil {
+				select {
+				case s.ch <- e:
+				default:
+				}
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	for _, e := range events {
+		for _, s := range subs {
+			if s.fn != nil {
+				s.fn(e)
+			}
+		}
+	}
+}
+
+// begin starts queueing events for a transaction and returns the position
+// in the queue to go back to on rollback.
+func (h *eventHub) begin() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.depth++
+	return len(h.queued)
+}
+
+// end closes a transaction, dropping its events if it was rolled back,
+// and reports the queued events once the outermost one is closed.
+func (h *eventHub) end(mark int, rollback bool) {
+	h.mu.Lock()
+	if rollback && mark <= len(h.queued) {
+		h.queued = h.queued[:mark]
+	}
+	h.depth--
+	var events []Event
+	if h.depth == 0 {
+		events, h.queued = h.queued, nil
+	}
+	h.mu.Unlock()
+
+	if len(events) > 0 {
+		h.deliver(events)
+	}
+}
+
+// change runs fn, which changes the item at a valid index, and emits an
+// event of the given type if the item is different afterwards.
+func (l *List) change(t EventType, index int, fn func() error) error {
+	if !l.observed() {
+		return fn()
+	}
+	before := l.Items[index].Clone()
+	if err := fn(); err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(before, l.Items[index]) {
+		after := l.Items[index].Clone()
+		l.emit(Event{Type: t, Index: index, Before: &before, After: &after})
+	}
+	return nil
+}
+
+// emitAdded reports the item at index as added.
+func (l *List) emitAdded(index int) {
+	if l.observed() {
+		after := l.Items[index].Clone()
+		l.emit(Event{Type: ItemAdded, Index: index, After: &after})
+	}
+}
+
+// Diff returns the events that turn the items of old into those of new, for
+// a list replaced as a whole, such as one reloaded from a file. Items are
+// matched by UID, or by creation time if they have none; items sharing a
+// creation time, as those of a migrated file may, are told apart by their
+// text, and then by their order. Deletions come first, from the last item,
+// with their index in old; then additions and changes in the order of new,
+// with their index there. If new has no items the result is a single
+// ListCleared event.
+func Diff(old, new *List) []Event {
+	if len(new.Items) == 0 {
+		if len(old.Items) == 0 {
+			return nil
+		}
+		return []Event{{Type: ListCleared, Index: -1, Cleared: cloneItems(old.Items)}}
+	}
+
+	match := make([]int, len(new.Items))
+	for j := range match {
+		match[j] = -1
+	}
+	matched := make([]bool, len(old.Items))
+	// Match unchanged texts first, so that an item deleted among others
+	// created at the same time is not taken for an edit of the next one
+	for _, key := range []func(Item) string{
+		func(item Item) string { return identity(item) + "\x00" + item.Text },
+		identity,
+	} {
+		unmatched := make(map[string][]int)
+		for i, item := range old.Items {
+			if !matched[i] {
+				k := key(item)
+				unmatched[k] = append(unmatched[k], i)
+			}
+		}
+		for j, item := range new.Items {
+			if match[j] >= 0 {
+				continue
+			}
+			k := key(item)
+			if indices := unmatched[k]; len(indices) > 0 {
+				match[j] = indices[0]
+				matched[indices[0]] = true
+				unmatched[k] = indices[1:]
+			}
+		}
+	}
+
+	var events []Event
+	for i := len(old.Items) - 1; i >= 0; i-- {
+		if !matched[i] {
+			before := old.Items[i].Clone()
+			events = append(events, Event{Type: ItemDeleted, Index: i, Before: &before})
+		}
+	}
+	for j, i := range match {
+		after := new.Items[j].Clone()
+		if i < 0 {
+			events = append(events, Event{Type: ItemAdded, Index: j, After: &after})
+			continue
+		}
+		if sameItem(old.Items[i], new.Items[j]) {
+			continue
+		}
+		before := old.Items[i].Clone()
+		t := ItemEdited
+		switch {
+		case !before.Done && after.Done:
+			t = ItemCompleted
+		case before.Done && !after.Done:
+			t = ItemUncompleted
+		}
+		events = append(events, Event{Type: t, Index: j, Before: &before, After: &after})
+	}
+	return events
+}
+
+// sameItem reports whether two items are saved alike. Items are compared
+// by their encoding, since times read from a file lack the monotonic clock
+// reading of those made by the program and may be in another location.
+func sameItem(a, b Item) bool {
+	da, errA := json.Marshal(a)
+	db, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(da, db)
+}
+
+// identity returns the key Diff matches items by.
+func identity(item Item) string {
+	if item.UID != "" {
+		return "uid:" + item.UID
+	}
+	return "created:" + item.CreatedAt.Format(time.RFC3339Nano)
+}
+
+// Reset replaces the contents of the list with those of other, which must
+// not be used afterwards, keeping the list's subscriptions and declared
+// fields. The changes are reported as the events returned by Diff.
+func (l *List) Reset(other *List) {
+	var events []Event
+	if l.observed() {
+		events = Diff(l, other)
+	}
+	hub, declared := l.events, l.declared
+	*l = *other
+	l.events, l.declared = hub, declared
+	for _, e := range events {
+		l.emit(e)
+	}
+}
@@ -0,0 +1,346 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordEvents subscribes to the list and returns the events received so
+// far each time it is called.
+func recordEvents(list *List) func() []Event {
+	var events []Event
+	list.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+	return func() []Event {
+		got := events
+		events = nil
+		return got
+	}
+}
+
+func TestEventsFromMutations(t *testing.T) {
+	list := NewList()
+	if err := list.DefineField(FieldDef{Name: "size", Type: FieldEnum, Values: []string{"s", "m", "l"}}); err != nil {
+		t.Fatalf("Failed to define field: %v", err)
+	}
+	events := recordEvents(list)
+
+	list.Add("Buy milk")
+	list.Add("Write report")
+	got := events()
+	if len(got) != 2 || got[1].Type != ItemAdded || got[1].Index != 1 || got[1].Before != nil || got[1].After.Text != "Write report" {
+		t.Fatalf("Expected two ItemAdded events, got %+v", got)
+	}
+
+	list.Complete(0)
+	list.Complete(0)
+	got = events()
+	if len(got) != 1 || got[0].Type != ItemCompleted || got[0].Before.Done || !got[0].After.Done {
+		t.Errorf("Expected one ItemCompleted event, got %+v", got)
+	}
+
+	list.Uncomplete(0)
+	got = events()
+	if len(got) != 1 || got[0].Type != ItemUncompleted || !got[0].Before.Done || got[0].After.Done {
+		t.Errorf("Expected an ItemUncompleted event, got %+v", got)
+	}
+
+	list.Edit(0, "Buy oat milk")
+	list.Set(0, "text", "Buy soy milk")
+	list.Set(1, "size", "m")
+	list.Set(1, "size", "m")
+	got = events()
+	if len(got) != 3 || got[0].Before.Text != "Buy milk" || got[0].After.Text != "Buy oat milk" ||
+		got[1].After.Text != "Buy soy milk" || got[2].After.Fields["size"] != "m" {
+		t.Errorf("Expected three ItemEdited events, got %+v", got)
+	}
+	for _, e := range got {
+		if e.Type != ItemEdited {
+			t.Errorf("Expected ItemEdited, got %v", e.Type)
+		}
+	}
+
+	list.RemoveField("size")
+	got = events()
+	if len(got) != 1 || got[0].Type != ItemEdited || got[0].Index != 1 || got[0].After.Fields != nil {
+		t.Errorf("Expected an ItemEdited event for the item with the field, got %+v", got)
+	}
+
+	list.Edit(0, "")
+	list.Complete(5)
+	if got = events(); len(got) != 0 {
+		t.Errorf("Expected no events for failed changes, got %+v", got)
+	}
+
+	list.Delete(0)
+	got = events()
+	if len(got) != 1 || got[0].Type != ItemDeleted || got[0].Index != 0 || got[0].Before.Text != "Buy soy milk" || got[0].After != nil {
+		t.Errorf("Expected an ItemDeleted event, got %+v", got)
+	}
+
+	list.Clear()
+	list.Clear()
+	got = events()
+	if len(got) != 1 || got[0].Type != ListCleared || got[0].Index != -1 ||
+		len(got[0].Cleared) != 1 || got[0].Cleared[0].Text != "Write report" {
+		t.Errorf("Expected one ListCleared event with the cleared items, got %+v", got)
+	}
+}
+
+func TestEventsHoldCopies(t *testing.T) {
+	list := NewList()
+	list.Add("Buy milk")
+	list.Set(0, "tags", "shop")
+	events := recordEvents(list)
+
+	list.Set(0, "tags", "home")
+	got := events()
+	list.Items[0].Tags[0] = "changed"
+	if got[0].Before.Tags[0] != "shop" || got[0].After.Tags[0] != "home" {
+		t.Errorf("Expected the event to keep its own copies, got %v and %v", got[0].Before.Tags, got[0].After.Tags)
+	}
+}
+
+func TestEventsFromImportAndSync(t *testing.T) {
+	list := NewList()
+	list.Add("Buy milk")
+	events := recordEvents(list)
+
+	if _, err := decodeJSON(strings.NewReader(`{"version":1,"items":[{"text":"Call mum"}]}`), list); err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	doc := "<!-- todo:begin -->\n- [x] Buy milk\n- [ ] Water plants\n<!-- todo:end -->\n"
+	if _, _, err := list.SyncMarkdown(doc, MarkdownOptions{}); err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+
+	var types []string
+	for _, e := range events() {
+		types = append(types, e.Type.String())
+	}
+	want := "item-added item-completed item-added"
+	if strings.Join(types, " ") != want {
+		t.Errorf("Expected %q, got %q", want, strings.Join(types, " "))
+	}
+}
+
+func TestEventsInTransactions(t *testing.T) {
+	list := NewList()
+	events := recordEvents(list)
+
+	list.Transaction(func(tx *Tx) error {
+		tx.Add("Buy milk")
+		if got := events(); len(got) != 0 {
+			t.Errorf("Expected events to be held back during the transaction, got %+v", got)
+		}
+		return nil
+	})
+	if got := events(); len(got) != 1 || got[0].Type != ItemAdded {
+		t.Errorf("Expected the event on commit, got %+v", got)
+	}
+
+	list.Transaction(func(tx *Tx) error {
+		tx.Complete(0)
+		return errors.New("failure")
+	})
+	if got := events(); len(got) != 0 {
+		t.Errorf("Expected no events after a rollback, got %+v", got)
+	}
+
+	outer := list.Begin()
+	outer.Add("Outer")
+	inner := list.Begin()
+	inner.Add("Inner")
+	inner.Rollback()
+	if got := events(); len(got) != 0 {
+		t.Errorf("Expected events to wait for the outer transaction, got %+v", got)
+	}
+	outer.Commit()
+	if got := events(); len(got) != 1 || got[0].After.Text != "Outer" {
+		t.Errorf("Expected only the outer transaction's event, got %+v", got)
+	}
+
+	list.Transaction(func(tx *Tx) error {
+		tx.Clear()
+		return errors.New("failure")
+	})
+	list.Add("After rollback")
+	if got := events(); len(got) != 1 || got[0].Type != ItemAdded {
+		t.Errorf("Expected the subscription to survive a rollback, got %+v", got)
+	}
+}
+
+func TestEventsChannel(t *testing.T) {
+	list := NewList()
+	ch, cancel := list.Events(2)
+
+	list.Add("Buy milk")
+	list.Add("Write report")
+	list.Add("Dropped")
+
+	for _, want := range []string{"Buy milk", "Write report"} {
+		if e := <-ch; e.After.Text != want {
+			t.Errorf("Expected %q, got %q", want, e.After.Text)
+		}
+	}
+	select {
+	case e := <-ch:
+		t.Errorf("Expected the event that did not fit to be dropped, got %+v", e)
+	default:
+	}
+
+	cancel()
+	cancel()
+	list.Add("After cancel")
+	if _, ok := <-ch; ok {
+		t.Errorf("Expected the channel to be closed")
+	}
+}
+
+func TestSubscribeCancel(t *testing.T) {
+	list := NewList()
+	var first, second int
+	cancel := list.Subscribe(func(Event) { first++ })
+	list.Subscribe(func(Event) { second++ })
+
+	list.Add("Buy milk")
+	cancel()
+	list.Add("Write report")
+
+	if first != 1 || second != 2 {
+		t.Errorf("Expected 1 and 2 calls, got %d and %d", first, second)
+	}
+}
+
+func TestEventsSurviveLoad(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "todos.json")
+	saved := NewList()
+	saved.Add("Buy milk")
+	if err := saved.Save(filename); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	list := NewList()
+	events := recordEvents(list)
+	if err := list.Load(filename); err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	list.Complete(0)
+	if got := events(); len(got) != 1 || got[0].Type != ItemCompleted {
+		t.Errorf("Expected only the change after loading, got %+v", got)
+	}
+
+	s := NewSyncList(nil)
+	ch, cancel := s.Events(4)
+	defer cancel()
+	if err := s.Load(filename); err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if e := <-ch; e.Type != ItemAdded || e.After.Text != "Buy milk" {
+		t.Errorf("Expected loading to report the new item, got %+v", e)
+	}
+	s.Delete(0)
+	if e := <-ch; e.Type != ItemDeleted || e.Before.Text != "Buy milk" {
+		t.Errorf("Expected an ItemDeleted event, got %+v", e)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := NewList()
+	old.Add("Buy milk")
+	old.Add("Write report")
+	old.Add("Call mum")
+	old.Add("Water plants")
+
+	// Reload through JSON, as from a file changed by another program
+	data, err := json.Marshal(old)
+	if err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	updated := NewList()
+	if err := json.Unmarshal(data, updated); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if events := Diff(old, updated); len(events) != 0 {
+		t.Fatalf("Expected no events for an unchanged list, got %+v", events)
+	}
+
+	updated.Delete(3)
+	updated.Delete(0)
+	updated.Complete(0)
+	updated.Edit(1, "Call dad")
+	updated.Add("Book flights")
+
+	var got []string
+	for _, e := range Diff(old, updated) {
+		got = append(got, fmt.Sprintf("%v %d", e.Type, e.Index))
+	}
+	want := "item-deleted 3,item-deleted 0,item-completed 0,item-edited 1,item-added 2"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, ","))
+	}
+
+	events := Diff(old, NewList())
+	if len(events) != 1 || events[0].Type != ListCleared || len(events[0].Cleared) != 4 {
+		t.Errorf("Expected one ListCleared event, got %+v", events)
+	}
+}
+
+func TestDiffSameCreationTime(t *testing.T) {
+	// Migrated files give all items without a creation time the same one
+	created := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	old := NewList()
+	for _, text := range []string{"Buy milk", "Write report", "Call mum"} {
+		old.Items = append(old.Items, Item{Text: text, CreatedAt: created})
+	}
+
+	updated := old.Clone()
+	updated.Delete(1)
+	var got []string
+	for _, e := range Diff(old, updated) {
+		got = append(got, fmt.Sprintf("%v %d", e.Type, e.Index))
+	}
+	if want := "item-deleted 1"; strings.Join(got, ",") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, ","))
+	}
+
+	updated = old.Clone()
+	updated.Edit(2, "Call dad")
+	got = nil
+	for _, e := range Diff(old, updated) {
+		got = append(got, fmt.Sprintf("%v %d %s", e.Type, e.Index, e.After.Text))
+	}
+	if want := "item-edited 2 Call dad"; strings.Join(got, ",") != want {
+		t.Errorf("Expected %s, got %s", want, strings.Join(got, ","))
+	}
+}
+
+func TestReset(t *testing.T) {
+	list := NewList()
+	list.Add("Buy milk")
+	events := recordEvents(list)
+
+	other := NewList()
+	other.Add("Write report")
+	other.Fields = []FieldDef{{Name: "ticket", Type: FieldString}}
+	list.Reset(other)
+
+	if list.Count() != 1 || list.Items[0].Text != "Write report" || len(list.Fields) != 1 {
+		t.Errorf("Expected the other list's contents, got %+v", list)
+	}
+	got := events()
+	if len(got) != 2 || got[0].Type != ItemDeleted || got[1].Type != ItemAdded {
+		t.Errorf("Expected a deletion and an addition, got %+v", got)
+	}
+
+	list.Add("Call mum")
+	if got := events(); len(got) != 1 {
+		t.Errorf("Expected the subscription to survive Reset, got %+v", got)
+	}
+}
@@ -0,0 +1,159 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Fields this package does not know about, such as those written by a newer
+// release or added by external scripts, are kept in the Extra maps of Item
+// and List and written back unchanged by Save, so they survive a round trip
+// through an older binary.
+
+// jsonItem and jsonList have the same fields as Item and List but none of
+// their methods, so they can be marshaled without recursing into the
+// custom MarshalJSON and UnmarshalJSON implementations.
+type (
+	jsonItem Item
+	jsonList List
+)
+
+var (
+	itemKeys = jsonKeys(reflect.TypeOf(Item{}))
+	listKeys = jsonKeys(reflect.TypeOf(List{}))
+)
+
+// UnmarshalJSON decodes an item, keeping unknown fields in Extra.
+func (i *Item) UnmarshalJSON(data []byte) error {
+	var item jsonItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return err
+	}
+
+	extra, err := unknownFields(data, itemKeys)
+	if err != nil {
+		return err
+	}
+	item.Extra = extra
+
+	*i = Item(item)
+	return nil
+}
+
+// MarshalJSON encodes an item, including any unknown fields in Extra.
+func (i Item) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(jsonItem(i))
+	if err != nil {
+		return nil, err
+	}
+	return appendFields(data, i.Extra, itemKeys)
+}
+
+// UnmarshalJSON decodes a list, keeping unknown fields in Extra.
+func (l *List) UnmarshalJSON(data []byte) error {
+	var list jsonList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	extra, err := unknownFields(data, listKeys)
+	if err != nil {
+		return err
+	}
+	list.Extra = extra
+	// Keep the subscriptions, as loading is not reported as changes, and
+	// the declared fields, which are not saved
+	list.events = l.events
+	list.declared = l.declared
+
+	*l = List(list)
+	return nil
+}
+
+// MarshalJSON encodes a list, including any unknown fields in Extra.
+func (l *List) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal((*jsonList)(l))
+	if err != nil {
+		return nil, err
+	}
+	return appendFields(data, l.Extra, listKeys)
+}
+
+// jsonKeys returns the JSON field names of a struct type.
+func jsonKeys(t reflect.Type) map[string]bool {
+	keys := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch {
+		case name == "-" || !field.IsExported():
+			continue
+		case name == "":
+			name = field.Name
+		}
+		keys[name] = true
+	}
+	return keys
+}
+
+// unknownFields returns the fields of a JSON object that are not in known,
+// or nil if there are none.
+func unknownFields(data []byte, known map[string]bool) (map[string]json.RawMessage, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	var extra map[string]json.RawMessage
+	for key, value := range raw {
+		if known[key] {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+		extra[key] = value
+	}
+	return extra, nil
+}
+
+// appendFields adds extra fields, sorted by name, to the end of an encoded
+// JSON object. Fields that clash with a known field are not written.
+func appendFields(object []byte, extra map[string]json.RawMessage, known map[string]bool) ([]byte, error) {
+	if len(extra) == 0 {
+		return object, nil
+	}
+
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.Write(object[:len(object)-1])
+	empty := bytes.Equal(bytes.TrimSpace(object), []byte("{}"))
+	for _, key := range keys {
+		if known[key] {
+			continue
+		}
+		if !empty {
+			buf.WriteByte(',')
+		}
+		empty = false
+
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(extra[key])
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
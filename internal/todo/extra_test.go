@@ -0,0 +1,116 @@
+package todo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const listWithUnknownFields = `{
+  "version": 1,
+  "owner": {"team": "ops"},
+  "items": [
+    {
+      "text": "Task",
+      "done": false,
+      "created_at": "2024-01-15T10:30:00Z",
+      "ticket": "OPS-123",
+      "estimate": 3.5,
+      "subtasks": [
+        {"text": "Subtask", "done": false, "created_at": "2024-01-15T10:30:00Z", "assignee": "sam"}
+      ]
+    }
+  ]
+}`
+
+func TestUnknownFieldsSurviveRoundTrip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "todos.json")
+	if err := os.WriteFile(filename, []byte(listWithUnknownFields), 0644); err != nil {
+		t.Fatalf("Could not write test file: %v", err)
+	}
+
+	list := NewList()
+	if err := list.Load(filename); err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	if string(list.Extra["owner"]) != `{"team": "ops"}` {
+		t.Errorf("Expected list-level field to be captured, got %s", list.Extra["owner"])
+	}
+	if string(list.Items[0].Extra["ticket"]) != `"OPS-123"` {
+		t.Errorf("Expected item field to be captured, got %s", list.Items[0].Extra["ticket"])
+	}
+	if _, ok := list.Items[0].Extra["text"]; ok {
+		t.Error("Known fields should not be captured in Extra")
+	}
+
+	// Modify the list and save it, as an older binary would.
+	list.Complete(0)
+	list.Add("New task")
+	if err := list.Save(filename); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	var doc map[string]interface{}
+	data, _ := os.ReadFile(filename)
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Saved file is not valid JSON: %v", err)
+	}
+
+	if owner, ok := doc["owner"].(map[string]interface{}); !ok || owner["team"] != "ops" {
+		t.Errorf("Expected list-level field to be written back, got %v", doc["owner"])
+	}
+
+	item := doc["items"].([]interface{})[0].(map[string]interface{})
+	if item["ticket"] != "OPS-123" || item["estimate"] != 3.5 || item["done"] != true {
+		t.Errorf("Expected item fields to be written back, got %v", item)
+	}
+	sub := item["subtasks"].([]interface{})[0].(map[string]interface{})
+	if sub["assignee"] != "sam" {
+		t.Errorf("Expected subtask field to be written back, got %v", sub)
+	}
+
+	added := doc["items"].([]interface{})[1].(map[string]interface{})
+	if len(added) != 3 {
+		t.Errorf("Expected new item to have only text, done and created_at, got %v", added)
+	}
+}
+
+func TestUnknownFieldsSurviveMigration(t *testing.T) {
+	legacy := `{"items": [{"text": "Old", "done": false, "custom": [1, 2]}], "source": "script"}`
+	filename := filepath.Join(t.TempDir(), "todos.json")
+	if err := os.WriteFile(filename, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Could not write test file: %v", err)
+	}
+
+	list := NewList()
+	if err := list.Load(filename); err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+
+	if string(list.Extra["source"]) != `"script"` {
+		t.Errorf("Expected list-level field after migration, got %s", list.Extra["source"])
+	}
+	if string(list.Items[0].Extra["custom"]) != `[1,2]` {
+		t.Errorf("Expected item field after migration, got %s", list.Items[0].Extra["custom"])
+	}
+}
+
+func TestExtraDoesNotOverrideKnownFields(t *testing.T) {
+	item := NewItem("Real text")
+	item.Extra = map[string]json.RawMessage{"text": json.RawMessage(`"shadow"`), "zeta": json.RawMessage(`1`)}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	if strings.Contains(string(data), "shadow") {
+		t.Errorf("Extra field should not override a known field: %s", data)
+	}
+	if !strings.HasSuffix(string(data), `,"zeta":1}`) {
+		t.Errorf("Expected extra field at the end of the object: %s", data)
+	}
+}
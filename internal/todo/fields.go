@@ -0,0 +1,385 @@
+package todo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType is the type of a user-defined item field.
+type FieldType string
+
+// Supported custom field types.
+const (
+	FieldString FieldType = "string"
+	FieldNumber FieldType = "number"
+	FieldDate   FieldType = "date"
+	FieldEnum   FieldType = "enum"
+)
+
+// DateLayout is the format of date values, such as custom date fields.
+const DateLayout = "2006-01-02"
+
+var fieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// builtinFields are names that refer to Item properties in filters, sorting
+// and `todo set`; custom fields cannot reuse them.
+var builtinFields = map[string]bool{
+	"text": true, "done": true, "created": true, "created_at": true,
+	"completed": true, "completed_at": true, "due": true, "priority": true,
+	"project": true, "tag": true, "tags": true, "uid": true, "notes": true,
+	"recurrence": true, "subtasks": true, "fields": true,
+}
+
+// FieldDef declares a custom field that items of a list may carry.
+type FieldDef struct {
+	Name string    `json:"name"`
+	Type FieldType `json:"type"`
+	// Values lists the allowed values of an enum field, in sort order.
+	Values []string `json:"values,omitempty"`
+}
+
+// Validate checks that the definition is well formed.
+func (d FieldDef) Validate() error {
+	if !fieldNamePattern.MatchString(d.Name) {
+		return fmt.Errorf("invalid field name %q (use lowercase letters, digits and '-', starting with a letter)", d.Name)
+	}
+	if builtinFields[d.Name] {
+		return fmt.Errorf("field name %q is reserved", d.Name)
+	}
+
+	switch d.Type {
+	case FieldString, FieldNumber, FieldDate:
+		if len(d.Values) > 0 {
+			return fmt.Errorf("field %s: only enum fields take a list of values", d.Name)
+		}
+	case FieldEnum:
+		if len(d.Values) == 0 {
+			return fmt.Errorf("field %s: enum fields need at least one value", d.Name)
+		}
+		seen := make(map[string]bool)
+		for _, v := range d.Values {
+			if strings.TrimSpace(v) == "" || seen[strings.ToLower(v)] {
+				return fmt.Errorf("field %s: enum values must be non-empty and unique", d.Name)
+			}
+			seen[strings.ToLower(v)] = true
+		}
+	default:
+		return fmt.Errorf("field %s: unknown type %q (expected string, number, date or enum)", d.Name, d.Type)
+	}
+	return nil
+}
+
+// Parse validates a value for the field and returns its canonical form:
+// numbers are normalised, dates are written as YYYY-MM-DD and enum values
+// use the spelling from the definition.
+func (d FieldDef) Parse(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("field %s: value cannot be empty", d.Name)
+	}
+
+	switch d.Type {
+	case FieldNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %q is not a number", d.Name, value)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case FieldDate:
+		t, err := ParseDate(value)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", d.Name, err)
+		}
+		return t.Format(DateLayout), nil
+	case FieldEnum:
+		for _, v := range d.Values {
+			if strings.EqualFold(v, value) {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("field %s: %q is not one of %s", d.Name, value, strings.Join(d.Values, ", "))
+	}
+	return value, nil
+}
+
+// String returns the definition in the form accepted by ParseFieldDef.
+func (d FieldDef) String() string {
+	if d.Type == FieldEnum {
+		return fmt.Sprintf("%s %s %s", d.Name, d.Type, strings.Join(d.Values, ","))
+	}
+	return fmt.Sprintf("%s %s", d.Name, d.Type)
+}
+
+// ParseFieldDef builds a definition from a name, a type and, for enums, a
+// comma-separated list of values.
+func ParseFieldDef(name, typ, values string) (FieldDef, error) {
+	d := FieldDef{Name: strings.ToLower(strings.TrimSpace(name)), Type: FieldType(strings.ToLower(typ))}
+	if values != "" {
+		for _, v := range strings.Split(values, ",") {
+			d.Values = append(d.Values, strings.TrimSpace(v))
+		}
+	}
+	return d, d.Validate()
+}
+
+// ParseDate parses a date given as YYYY-MM-DD, an RFC 3339 timestamp, or one
+// of the keywords today, tomorrow and yesterday. Dates without a time are
+// interpreted as midnight local time.
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+
+	switch strings.ToLower(s) {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+
+	if t, err := time.ParseInLocation(DateLayout, s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD, RFC 3339, today, tomorrow or yesterday)", s)
+}
+
+// FieldDef returns the definition of the named custom field.
+func (l *List) FieldDef(name string) (FieldDef, bool) {
+	return findFieldDef(l.FieldDefs(), name)
+}
+
+// Declare adds custom field definitions that apply to the list without
+// being saved in it, such as those of the user's configuration. They are
+// kept when the list is loaded or reset. A definition in Fields takes
+// precedence over a declared one with the same name.
+func (l *List) Declare(defs ...FieldDef) error {
+	declared := append([]FieldDef(nil), l.declared...)
+	for _, def := range defs {
+		if err := def.Validate(); err != nil {
+			return err
+		}
+		if _, found := findFieldDef(declared, def.Name); found {
+			return fmt.Errorf("field %s is declared twice", def.Name)
+		}
+		declared = append(declared, def)
+	}
+	l.declared = declared
+	return nil
+}
+
+// FieldDefs returns the custom fields items of the list may carry: those
+// defined in Fields, followed by the declared ones they do not redefine.
+func (l *List) FieldDefs() []FieldDef {
+	if len(l.declared) == 0 {
+		return l.Fields
+	}
+	defs := append([]FieldDef(nil), l.Fields...)
+	for _, def := range l.declared {
+		if _, found := findFieldDef(l.Fields, def.Name); !found {
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
+// Declared reports whether the named field is declared and not defined in
+// Fields.
+func (l *List) Declared(name string) bool {
+	_, declared := findFieldDef(l.declared, name)
+	_, defined := findFieldDef(l.Fields, name)
+	return declared && !defined
+}
+
+// findFieldDef returns the definition with the given name.
+func findFieldDef(defs []FieldDef, name string) (FieldDef, bool) {
+	for _, d := range defs {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return FieldDef{}, false
+}
+
+// DefineField adds a custom field definition, or replaces the definition of
+// an existing field with the same name. Replacing a definition fails if an
+// item holds a value the new definition does not accept.
+func (l *List) DefineField(def FieldDef) error {
+	if err := def.Validate(); err != nil {
+		return err
+	}
+
+	for i, item := range l.Items {
+		if value, ok := item.Fields[def.Name]; ok {
+			if _, err := def.Parse(value); err != nil {
+				return fmt.Errorf("item #%d: %w", i+1, err)
+			}
+		}
+	}
+
+	for i, d := range l.Fields {
+		if d.Name == def.Name {
+			l.Fields[i] = def
+			return nil
+		}
+	}
+	l.Fields = append(l.Fields, def)
+	return nil
+}
+
+// RemoveField deletes a custom field definition and the field's value from
+// every item. If the field is also declared, only the definition is
+// removed, so that the declared one applies again; a field that is only
+// declared cannot be removed from the list.
+func (l *List) RemoveField(name string) error {
+	for i, d := range l.Fields {
+		if d.Name != name {
+			continue
+		}
+		l.Fields = append(l.Fields[:i], l.Fields[i+1:]...)
+		if l.Declared(name) {
+			return nil
+		}
+		for j := range l.Items {
+			if _, ok := l.Items[j].Fields[name]; !ok {
+				continue
+			}
+			l.change(ItemEdited, j, func() error {
+				delete(l.Items[j].Fields, name)
+				if len(l.Items[j].Fields) == 0 {
+					l.Items[j].Fields = nil
+				}
+				return nil
+			})
+		}
+		return nil
+	}
+	if l.Declared(name) {
+		return fmt.Errorf("field %q is declared in the configuration, not in the list", name)
+	}
+	return fmt.Errorf("unknown field %q", name)
+}
+
+// Set assigns a property of the item at the specified index. The name may
+// be a custom field or one of text, project, priority, due, tags and
+// recurrence. An empty value clears the property.
+// Returns an error if the index is out of range or the value is invalid.
+func (l *List) Set(index int, name, value string) error {
+	if err := l.validateIndex(index); err != nil {
+		return err
+	}
+
+	return l.change(ItemEdited, index, func() error {
+		return l.set(index, name, value)
+	})
+}
+
+// set assigns a property of the item at a valid index for Set.
+func (l *List) set(index int, name, value string) error {
+	item := &l.Items[index]
+	name = strings.ToLower(strings.TrimSpace(name))
+	value = strings.TrimSpace(value)
+
+	switch name {
+	case "text":
+		return l.setText(index, value)
+	case "project":
+		item.Project = value
+	case "priority":
+		p, err := ParsePriority(value)
+		if err != nil {
+			return err
+		}
+		item.Priority = p
+	case "due":
+		if value == "" {
+			item.Due = nil
+			return nil
+		}
+		t, err := ParseDate(value)
+		if err != nil {
+			return err
+		}
+		item.Due = &t
+	case "tags", "tag":
+		item.Tags = nil
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				item.Tags = append(item.Tags, tag)
+			}
+		}
+	case "recurrence":
+		item.Recurrence = value
+	default:
+		def, ok := l.FieldDef(name)
+		if !ok {
+			return fmt.Errorf("unknown field %q (define it first with 'todo field add')", name)
+		}
+		if value == "" {
+			delete(item.Fields, name)
+			if len(item.Fields) == 0 {
+				item.Fields = nil
+			}
+			return nil
+		}
+		canonical, err := def.Parse(value)
+		if err != nil {
+			return err
+		}
+		if item.Fields == nil {
+			item.Fields = make(map[string]string)
+		}
+		item.Fields[name] = canonical
+	}
+	return nil
+}
+
+// validateFields checks an item's custom field values against the
+// definitions and returns the item with canonical values. Values for
+// fields that are not defined are rejected.
+func validateFields(item Item, defs []FieldDef) (Item, error) {
+	if len(item.Fields) == 0 {
+		return item, nil
+	}
+
+	fields := make(map[string]string, len(item.Fields))
+	for name, value := range item.Fields {
+		def, found := findFieldDef(defs, name)
+		if !found {
+			return item, fmt.Errorf("unknown field %q", name)
+		}
+		if value == "" {
+			continue
+		}
+		canonical, err := def.Parse(value)
+		if err != nil {
+			return item, err
+		}
+		fields[name] = canonical
+	}
+
+	if len(fields) == 0 {
+		fields = nil
+	}
+	item.Fields = fields
+	return item, nil
+}
+
+// FieldsString formats an item's custom fields as "name: value" pairs in
+// the order the fields are defined in the list.
+func (l *List) FieldsString(item Item) string {
+	var parts []string
+	for _, d := range l.FieldDefs() {
+		if value, ok := item.Fields[d.Name]; ok {
+			parts = append(parts, d.Name+": "+value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
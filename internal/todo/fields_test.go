@@ -0,0 +1,316 @@
+package todo
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newFieldList(t *testing.T) *List {
+	t.Helper()
+
+	list := NewList()
+	for _, def := range []FieldDef{
+		{Name: "ticket", Type: FieldString},
+		{Name: "points", Type: FieldNumber},
+		{Name: "deadline", Type: FieldDate},
+		{Name: "sprint", Type: FieldEnum, Values: []string{"s1", "s2", "s3"}},
+	} {
+		if err := list.DefineField(def); err != nil {
+			t.Fatalf("Failed to define field %s: %v", def.Name, err)
+		}
+	}
+	return list
+}
+
+func TestParseFieldDef(t *testing.T) {
+	def, err := ParseFieldDef("Sprint", "enum", "s1, s2")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if def.Name != "sprint" || def.Type != FieldEnum || len(def.Values) != 2 || def.Values[1] != "s2" {
+		t.Errorf("Expected sprint enum s1,s2, got %s", def)
+	}
+
+	invalid := []struct{ name, typ, values string }{
+		{"due", "date", ""},
+		{"2fast", "string", ""},
+		{"ticket", "uuid", ""},
+		{"ticket", "string", "a,b"},
+		{"sprint", "enum", ""},
+		{"sprint", "enum", "s1,S1"},
+	}
+	for _, tc := range invalid {
+		if _, err := ParseFieldDef(tc.name, tc.typ, tc.values); err == nil {
+			t.Errorf("Expected error for %s %s %q", tc.name, tc.typ, tc.values)
+		}
+	}
+}
+
+func TestFieldDefParse(t *testing.T) {
+	tests := []struct {
+		def      FieldDef
+		input    string
+		expected string
+	}{
+		{FieldDef{Name: "points", Type: FieldNumber}, "5.0", "5"},
+		{FieldDef{Name: "deadline", Type: FieldDate}, "2026-03-01", "2026-03-01"},
+		{FieldDef{Name: "sprint", Type: FieldEnum, Values: []string{"S1"}}, "s1", "S1"},
+		{FieldDef{Name: "ticket", Type: FieldString}, " OPS-1 ", "OPS-1"},
+	}
+	for _, tc := range tests {
+		got, err := tc.def.Parse(tc.input)
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", tc.input, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("Expected %q, got %q", tc.expected, got)
+		}
+	}
+
+	if _, err := (FieldDef{Name: "points", Type: FieldNumber}).Parse("many"); err == nil {
+		t.Error("Expected error for non-numeric value")
+	}
+	if _, err := (FieldDef{Name: "deadline", Type: FieldDate}).Parse("03/01/2026"); err == nil {
+		t.Error("Expected error for invalid date")
+	}
+}
+
+func TestListSet(t *testing.T) {
+	list := newFieldList(t)
+	list.Add("Fix login")
+
+	if err := list.Set(0, "ticket", "OPS-123"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := list.Set(0, "sprint", "S2"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := list.Set(0, "priority", "high"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	item := list.Items[0]
+	if item.Fields["ticket"] != "OPS-123" || item.Fields["sprint"] != "s2" {
+		t.Errorf("Expected ticket OPS-123 and sprint s2, got %v", item.Fields)
+	}
+	if item.Priority != PriorityHigh {
+		t.Errorf("Expected high priority, got %q", item.Priority)
+	}
+
+	if err := list.Set(0, "sprint", "s9"); err == nil {
+		t.Error("Expected error for value outside the enum")
+	}
+	if err := list.Set(0, "customer", "ACME"); err == nil {
+		t.Error("Expected error for undefined field")
+	}
+	if err := list.Set(1, "ticket", "OPS-1"); err == nil {
+		t.Error("Expected error for index out of range")
+	}
+
+	if err := list.Set(0, "ticket", ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := list.Items[0].Fields["ticket"]; ok {
+		t.Error("Expected empty value to clear the field")
+	}
+
+	if got := list.FieldsString(list.Items[0]); got != "sprint: s2" {
+		t.Errorf("Expected 'sprint: s2', got %q", got)
+	}
+}
+
+func TestListReplace(t *testing.T) {
+	list := newFieldList(t)
+	list.Add("Fix login")
+
+	item := list.Items[0].Clone()
+	item.Text = "  Fix logout "
+	item.Fields = map[string]string{"sprint": "S3"}
+	if err := list.Replace(0, item); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := list.Items[0]; got.Text != "Fix logout" || got.Fields["sprint"] != "s3" {
+		t.Errorf("Expected trimmed text and canonical field, got %q and %v", got.Text, got.Fields)
+	}
+
+	for _, bad := range []Item{
+		{Text: " "},
+		{Text: "Task", Priority: "urgent"},
+		{Text: "Task", Fields: map[string]string{"sprint": "s9"}},
+		{Text: "Task", Fields: map[string]string{"customer": "ACME"}},
+	} {
+		if err := list.Replace(0, bad); err == nil {
+			t.Errorf("Expected error replacing with %+v", bad)
+		}
+	}
+	if list.Items[0].Text != "Fix logout" {
+		t.Errorf("Expected invalid items to leave the list alone, got %q", list.Items[0].Text)
+	}
+	if err := list.Replace(1, item); err == nil {
+		t.Error("Expected error for index out of range")
+	}
+}
+
+func TestDefineAndRemoveField(t *testing.T) {
+	list := newFieldList(t)
+	list.Add("Task")
+	list.Set(0, "points", "8")
+
+	if err := list.DefineField(FieldDef{Name: "points", Type: FieldEnum, Values: []string{"1", "2"}}); err == nil {
+		t.Error("Expected error when redefining a field that existing values violate")
+	}
+
+	if err := list.RemoveField("points"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if list.Items[0].Fields != nil {
+		t.Errorf("Expected field values to be removed, got %v", list.Items[0].Fields)
+	}
+	if _, ok := list.FieldDef("points"); ok {
+		t.Error("Expected field definition to be removed")
+	}
+	if err := list.RemoveField("points"); err == nil {
+		t.Error("Expected error removing an unknown field")
+	}
+}
+
+func TestDeclaredFields(t *testing.T) {
+	list := NewList()
+	err := list.Declare(
+		FieldDef{Name: "ticket", Type: FieldString},
+		FieldDef{Name: "sprint", Type: FieldEnum, Values: []string{"s1", "s2"}},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := list.Declare(FieldDef{Name: "ticket", Type: FieldNumber}); err == nil {
+		t.Error("Expected error declaring a field twice")
+	}
+
+	list.Add("Task")
+	if err := list.Set(0, "sprint", "S2"); err != nil {
+		t.Fatalf("Expected declared field to be settable, got %v", err)
+	}
+	if err := list.Set(0, "sprint", "s3"); err == nil {
+		t.Error("Expected declared field to be validated")
+	}
+
+	// The list's own definition takes precedence
+	list.DefineField(FieldDef{Name: "sprint", Type: FieldString})
+	if def, _ := list.FieldDef("sprint"); def.Type != FieldString {
+		t.Errorf("Expected the list's definition to win, got %v", def)
+	}
+	if n := len(list.FieldDefs()); n != 2 {
+		t.Errorf("Expected 2 definitions, got %d", n)
+	}
+
+	// Declared fields are not saved, but survive loading
+	filename := filepath.Join(t.TempDir(), "todos.json")
+	if err := list.Save(filename); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	data, _ := os.ReadFile(filename)
+	if strings.Contains(string(data), "ticket") {
+		t.Errorf("Expected declared fields not to be saved, got %s", data)
+	}
+	if err := list.Load(filename); err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if _, ok := list.FieldDef("ticket"); !ok {
+		t.Error("Expected declared fields to survive Load")
+	}
+
+	// Removing the list's definition brings back the declared one and
+	// keeps the values
+	if err := list.RemoveField("sprint"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if def, _ := list.FieldDef("sprint"); def.Type != FieldEnum || list.Items[0].Fields["sprint"] != "s2" {
+		t.Errorf("Expected the declared definition and the value, got %v %v", def, list.Items[0].Fields)
+	}
+	if err := list.RemoveField("ticket"); err == nil {
+		t.Error("Expected error removing a field only declared")
+	}
+}
+
+func TestCustomFieldsRoundTrip(t *testing.T) {
+	list := newFieldList(t)
+	list.Add("Fix login")
+	list.Add("Write docs")
+	list.Set(0, "ticket", "OPS-123")
+	list.Set(0, "points", "3")
+	list.Set(0, "deadline", "2026-03-01")
+	list.Set(1, "sprint", "s2")
+
+	for _, name := range []string{"csv", "markdown", "ics", "taskwarrior"} {
+		format, err := LookupFormat(name)
+		if err != nil {
+			t.Fatalf("Expected format %s, got %v", name, err)
+		}
+		enc, _ := format.Encoder(nil)
+		dec, _ := format.Decoder(nil)
+
+		var buf bytes.Buffer
+		if err := enc.Encode(&buf, list); err != nil {
+			t.Fatalf("%s: failed to encode: %v", name, err)
+		}
+
+		imported := NewList()
+		imported.Fields = list.Fields
+		result, err := dec.Decode(&buf, imported)
+		if err != nil {
+			t.Fatalf("%s: failed to decode: %v", name, err)
+		}
+		if len(result.Errors) > 0 || len(result.Unmapped) > 0 {
+			t.Errorf("%s: unexpected errors %v or unmapped attributes %v", name, result.Errors, result.Unmapped)
+		}
+		if imported.Count() != 2 {
+			t.Fatalf("%s: expected 2 items, got %d", name, imported.Count())
+		}
+
+		for i, item := range imported.Items {
+			if got, want := imported.FieldsString(item), list.FieldsString(list.Items[i]); got != want {
+				t.Errorf("%s: expected fields %q, got %q", name, want, got)
+			}
+			if item.Text != list.Items[i].Text {
+				t.Errorf("%s: expected text %q, got %q", name, list.Items[i].Text, item.Text)
+			}
+		}
+	}
+}
+
+func TestReadCSVRejectsInvalidField(t *testing.T) {
+	list := newFieldList(t)
+	input := "text,points\nValid,2\nInvalid,lots\n"
+
+	result, err := ReadCSV(strings.NewReader(input), nil, list.Fields...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Items) != 1 {
+		t.Errorf("Expected 1 item, got %d", len(result.Items))
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 3 {
+		t.Errorf("Expected one error on line 3, got %v", result.Errors)
+	}
+}
+
+func TestReadMarkdownKeepsUnknownSpans(t *testing.T) {
+	list := newFieldList(t)
+	doc := "- [ ] Deploy `ticket=OPS-9`\n- [ ] Use `go test` `other=x`\n"
+
+	items, err := ReadMarkdown(strings.NewReader(doc), list.Fields...)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if items[0].Text != "Deploy" || items[0].Fields["ticket"] != "OPS-9" {
+		t.Errorf("Expected 'Deploy' with ticket OPS-9, got %q %v", items[0].Text, items[0].Fields)
+	}
+	if items[1].Text != "Use `go test` `other=x`" || items[1].Fields != nil {
+		t.Errorf("Expected unknown span to stay in the text, got %q %v", items[1].Text, items[1].Fields)
+	}
+}
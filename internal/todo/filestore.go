@@ -0,0 +1,85 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileStore keeps a list in a JSON file, noticing changes made to it by
+// other programs from its modification time and size. It is not safe for
+// concurrent use.
+type FileStore struct {
+	// Fields are declared on the lists Load returns; see List.Declare.
+	Fields []FieldDef
+
+	path    string
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// NewFileStore creates a store for the todo file at path, which need not
+// exist yet.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load reads the file, returning an empty list if it does not exist.
+func (s *FileStore) Load() (*List, error) {
+	// Take the file's state before reading it, so that a change made while
+	// it is read shows up as a later change
+	s.remember()
+
+	list := NewList()
+	if err := list.Declare(s.Fields...); err != nil {
+		return nil, err
+	}
+	if !s.exists {
+		return list, nil
+	}
+	if err := list.Load(s.path); err != nil {
+		// The file may have been read while being written; forget its
+		// state so that Changed reports it again
+		s.exists, s.modTime, s.size = false, time.Time{}, 0
+		return nil, err
+	}
+	return list, nil
+}
+
+// Save writes the list to the file, creating its directory if needed.
+func (s *FileStore) Save(list *List) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", s.path, err)
+	}
+	if err := list.Save(s.path); err != nil {
+		return fmt.Errorf("failed to save todos: %w", err)
+	}
+	s.remember()
+	return nil
+}
+
+// Changed reports whether the file was written, created or removed since
+// it was last loaded or saved.
+func (s *FileStore) Changed() bool {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s.exists
+	}
+	if err != nil {
+		return false
+	}
+	return !s.exists || !info.ModTime().Equal(s.modTime) || info.Size() != s.size
+}
+
+// remember records the current state of the file.
+func (s *FileStore) remember() {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		s.exists, s.modTime, s.size = false, time.Time{}, 0
+		return
+	}
+	s.exists, s.modTime, s.size = true, info.ModTime(), info.Size()
+}
@@ -0,0 +1,69 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "todos.json")
+	store := NewFileStore(path)
+
+	list, err := store.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if list.Count() != 0 {
+		t.Errorf("Expected an empty list for a missing file, got %d items", list.Count())
+	}
+	if store.Changed() {
+		t.Errorf("Expected a missing file to be unchanged")
+	}
+
+	list.Add("buy milk")
+	if err := store.Save(list); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if store.Changed() {
+		t.Errorf("Expected the store's own save not to count as a change")
+	}
+
+	other := NewList()
+	other.Add("buy milk")
+	other.Add("call mum")
+	if err := other.Save(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Make sure the change shows even where timestamps are coarse
+	later := time.Now().Add(2 * time.Second)
+	os.Chtimes(path, later, later)
+	if !store.Changed() {
+		t.Errorf("Expected a change by another writer to be noticed")
+	}
+
+	list, err = store.Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if list.Count() != 2 {
+		t.Errorf("Expected 2 items after reloading, got %d", list.Count())
+	}
+	if store.Changed() {
+		t.Errorf("Expected no change right after loading")
+	}
+
+	os.Remove(path)
+	if !store.Changed() {
+		t.Errorf("Expected removing the file to be noticed")
+	}
+
+	os.WriteFile(path, []byte(`{"items": [`), 0644)
+	if _, err := store.Load(); err == nil {
+		t.Fatalf("Expected an error for a partly written file")
+	}
+	if !store.Changed() {
+		t.Errorf("Expected a file that failed to load to count as changed")
+	}
+}
@@ -0,0 +1,307 @@
+package todo
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Encoder writes a list in a particular format.
+type Encoder interface {
+	Encode(w io.Writer, l *List) error
+}
+
+// Decoder reads items in a particular format into a list.
+// The returned result describes the items read and any rows that were
+// rejected or skipped.
+type Decoder interface {
+	Decode(r io.Reader, l *List) (*ImportResult, error)
+}
+
+// EncoderFunc adapts an ordinary function to the Encoder interface.
+type EncoderFunc func(w io.Writer, l *List) error
+
+// Encode calls f(w, l).
+func (f EncoderFunc) Encode(w io.Writer, l *List) error {
+	return f(w, l)
+}
+
+// DecoderFunc adapts an ordinary function to the Decoder interface.
+type DecoderFunc func(r io.Reader, l *List) (*ImportResult, error)
+
+// Decode calls f(r, l).
+func (f DecoderFunc) Decode(r io.Reader, l *List) (*ImportResult, error) {
+	return f(r, l)
+}
+
+// Options holds format-specific settings, such as the CSV column mapping.
+type Options map[string]string
+
+// Format describes a named list format used for export and import.
+type Format struct {
+	// Name identifies the format, e.g. "csv".
+	Name string
+	// Aliases are alternative names accepted by LookupFormat.
+	Aliases []string
+	// Extensions are the file extensions, including the dot, that
+	// FormatForFile associates with the format.
+	Extensions []string
+	// Options lists the option names the format accepts.
+	Options []string
+	// NewEncoder and NewDecoder create a configured encoder or decoder.
+	// Either may be nil if the format only supports one direction.
+	NewEncoder func(opts Options) (Encoder, error)
+	NewDecoder func(opts Options) (Decoder, error)
+}
+
+// Encoder returns an encoder for the format configured with opts.
+func (f Format) Encoder(opts Options) (Encoder, error) {
+	if f.NewEncoder == nil {
+		return nil, fmt.Errorf("format %s does not support export", f.Name)
+	}
+	if err := f.checkOptions(opts); err != nil {
+		return nil, err
+	}
+	return f.NewEncoder(opts)
+}
+
+// Decoder returns a decoder for the format configured with opts.
+func (f Format) Decoder(opts Options) (Decoder, error) {
+	if f.NewDecoder == nil {
+		return nil, fmt.Errorf("format %s does not support import", f.Name)
+	}
+	if err := f.checkOptions(opts); err != nil {
+		return nil, err
+	}
+	return f.NewDecoder(opts)
+}
+
+// checkOptions rejects options the format does not understand.
+func (f Format) checkOptions(opts Options) error {
+	for key := range opts {
+		supported := false
+		for _, name := range f.Options {
+			if name == key {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("format %s does not support the %q option", f.Name, key)
+		}
+	}
+	return nil
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]Format)
+)
+
+// RegisterFormat makes a format available by name, alias and extension.
+// It panics if the name is empty or a format with the same name or alias
+// is already registered.
+func RegisterFormat(f Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	if f.Name == "" {
+		panic("todo: RegisterFormat called with an empty name")
+	}
+	for _, name := range append([]string{f.Name}, f.Aliases...) {
+		name = strings.ToLower(name)
+		for _, existing := range formats {
+			if matchesName(existing, name) {
+				panic(fmt.Sprintf("todo: format %q registered twice", name))
+			}
+		}
+	}
+
+	formats[strings.ToLower(f.Name)] = f
+}
+
+// LookupFormat returns the format registered under the given name or alias.
+// A file extension without the dot, such as "md", is also accepted.
+func LookupFormat(name string) (Format, error) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, f := range formats {
+		if matchesName(f, name) {
+			return f, nil
+		}
+	}
+	for _, f := range formats {
+		if matchesExtension(f, "."+name) {
+			return f, nil
+		}
+	}
+	return Format{}, fmt.Errorf("unknown format %q (available: %s)", name, strings.Join(FormatNames(), ", "))
+}
+
+// FormatForFile returns the format associated with the file's extension.
+func FormatForFile(filename string) (Format, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == "" {
+		return Format{}, fmt.Errorf("cannot determine format of %s: file has no extension", filename)
+	}
+
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	for _, f := range formats {
+		if matchesExtension(f, ext) {
+			return f, nil
+		}
+	}
+	return Format{}, fmt.Errorf("no format registered for %s files", ext)
+}
+
+// FormatNames returns the names of all registered formats, sorted.
+func FormatNames() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+
+	names := make([]string, 0, len(formats))
+	for _, f := range formats {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// matchesName reports whether name is the format's name or one of its aliases.
+func matchesName(f Format, name string) bool {
+	if strings.EqualFold(f.Name, name) {
+		return true
+	}
+	for _, alias := range f.Aliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesExtension reports whether ext is one of the format's extensions.
+func matchesExtension(f Format, ext string) bool {
+	for _, e := range f.Extensions {
+		if strings.EqualFold(e, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// RowError describes an input row that could not be imported.
+type RowError struct {
+	Line int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// ImportResult holds the outcome of reading items from an external format.
+// Rows that failed to parse are reported in Errors and omitted from Items.
+// Rows that were deliberately left out are reported in Skipped, and input
+// attributes without an Item equivalent are counted in Unmapped.
+type ImportResult struct {
+	Items    []Item
+	Errors   []RowError
+	Skipped  []RowError
+	Unmapped map[string]int
+}
+
+// appendResult adds the items of a result to the list and returns the result.
+func appendResult(l *List, result *ImportResult, err error) (*ImportResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	l.Append(result.Items...)
+	return result, nil
+}
+
+func init() {
+	RegisterFormat(Format{
+		Name:       "json",
+		Extensions: []string{".json"},
+		NewEncoder: func(Options) (Encoder, error) { return EncoderFunc(encodeJSON), nil },
+		NewDecoder: func(Options) (Decoder, error) { return DecoderFunc(decodeJSON), nil },
+	})
+
+	RegisterFormat(Format{
+		Name:       "csv",
+		Extensions: []string{".csv"},
+		Options:    []string{"map"},
+		NewEncoder: func(opts Options) (Encoder, error) {
+			m, err := ParseCSVMapping(opts["map"])
+			if err != nil {
+				return nil, err
+			}
+			return EncoderFunc(func(w io.Writer, l *List) error { return l.WriteCSV(w, m) }), nil
+		},
+		NewDecoder: func(opts Options) (Decoder, error) {
+			m, err := ParseCSVMapping(opts["map"])
+			if err != nil {
+				return nil, err
+			}
+			return DecoderFunc(func(r io.Reader, l *List) (*ImportResult, error) {
+				result, err := ReadCSV(r, m, l.FieldDefs()...)
+				return appendResult(l, result, err)
+			}), nil
+		},
+	})
+
+	RegisterFormat(Format{
+		Name:       "markdown",
+		Aliases:    []string{"md"},
+		Extensions: []string{".md", ".markdown"},
+		Options:    []string{"group"},
+		NewEncoder: func(opts Options) (Encoder, error) {
+			mdOpts := MarkdownOptions{GroupByProject: opts["group"] == "true"}
+			return EncoderFunc(func(w io.Writer, l *List) error { return l.WriteMarkdown(w, mdOpts) }), nil
+		},
+		NewDecoder: func(Options) (Decoder, error) {
+			return DecoderFunc(func(r io.Reader, l *List) (*ImportResult, error) {
+				items, err := ReadMarkdown(r, l.FieldDefs()...)
+				return appendResult(l, &ImportResult{Items: items}, err)
+			}), nil
+		},
+	})
+
+	RegisterFormat(Format{
+		Name:       "ics",
+		Aliases:    []string{"ical", "icalendar"},
+		Extensions: []string{".ics", ".ical"},
+		NewEncoder: func(Options) (Encoder, error) {
+			return EncoderFunc(func(w io.Writer, l *List) error { return l.WriteICS(w) }), nil
+		},
+		NewDecoder: func(Options) (Decoder, error) {
+			return DecoderFunc(func(r io.Reader, l *List) (*ImportResult, error) {
+				result, err := ReadICS(r, l.FieldDefs()...)
+				return appendResult(l, result, err)
+			}), nil
+		},
+	})
+
+	RegisterFormat(Format{
+		Name:    "taskwarrior",
+		Aliases: []string{"tw"},
+		NewEncoder: func(Options) (Encoder, error) {
+			return EncoderFunc(func(w io.Writer, l *List) error { return l.WriteTaskwarrior(w) }), nil
+		},
+		NewDecoder: func(Options) (Decoder, error) {
+			return DecoderFunc(func(r io.Reader, l *List) (*ImportResult, error) {
+				result, err := ReadTaskwarrior(r, l.FieldDefs()...)
+				return appendResult(l, result, err)
+			}), nil
+		},
+	})
+}
@@ -0,0 +1,157 @@
+package todo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLookupFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"json", "json"},
+		{"CSV", "csv"},
+		{"md", "markdown"},
+		{"markdown", "markdown"},
+		{"ical", "ics"},
+		{"tw", "taskwarrior"},
+	}
+
+	for _, tt := range tests {
+		f, err := LookupFormat(tt.name)
+		if err != nil {
+			t.Errorf("LookupFormat(%q) returned error: %v", tt.name, err)
+			continue
+		}
+		if f.Name != tt.expected {
+			t.Errorf("LookupFormat(%q) = %s, expected %s", tt.name, f.Name, tt.expected)
+		}
+	}
+
+	if _, err := LookupFormat("xlsx"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestFormatForFile(t *testing.T) {
+	tests := map[string]string{
+		"tasks.csv":       "csv",
+		"README.MD":       "markdown",
+		"notes.markdown":  "markdown",
+		"calendar.ics":    "ics",
+		"backup.json":     "json",
+		"dir.v2/todo.csv": "csv",
+	}
+
+	for filename, expected := range tests {
+		f, err := FormatForFile(filename)
+		if err != nil {
+			t.Errorf("FormatForFile(%q) returned error: %v", filename, err)
+			continue
+		}
+		if f.Name != expected {
+			t.Errorf("FormatForFile(%q) = %s, expected %s", filename, f.Name, expected)
+		}
+	}
+
+	if _, err := FormatForFile("tasks"); err == nil {
+		t.Error("Expected error for file without extension")
+	}
+	if _, err := FormatForFile("tasks.xlsx"); err == nil {
+		t.Error("Expected error for unregistered extension")
+	}
+}
+
+func TestRegisterCustomFormat(t *testing.T) {
+	RegisterFormat(Format{
+		Name:       "test-lines",
+		Extensions: []string{".lines"},
+		NewEncoder: func(Options) (Encoder, error) {
+			return EncoderFunc(func(w io.Writer, l *List) error {
+				for _, item := range l.Items {
+					fmt.Fprintln(w, item.Text)
+				}
+				return nil
+			}), nil
+		},
+	})
+	defer func() {
+		formatsMu.Lock()
+		delete(formats, "test-lines")
+		formatsMu.Unlock()
+	}()
+
+	f, err := FormatForFile("out.lines")
+	if err != nil {
+		t.Fatalf("Expected custom format to be found: %v", err)
+	}
+
+	enc, err := f.Encoder(nil)
+	if err != nil {
+		t.Fatalf("Failed to create encoder: %v", err)
+	}
+
+	list := NewList()
+	list.Add("One")
+	list.Add("Two")
+
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, list); err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+	if buf.String() != "One\nTwo\n" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+
+	if _, err := f.Decoder(nil); err == nil {
+		t.Error("Expected error for format without a decoder")
+	}
+}
+
+func TestRegisterFormatDuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic when registering a duplicate alias")
+		}
+	}()
+	RegisterFormat(Format{Name: "another", Aliases: []string{"md"}})
+}
+
+func TestFormatRejectsUnknownOptions(t *testing.T) {
+	f, _ := LookupFormat("csv")
+	if _, err := f.Encoder(Options{"group": "true"}); err == nil {
+		t.Error("Expected error for option the format does not support")
+	}
+	if _, err := f.Encoder(Options{"map": "text=Title"}); err != nil {
+		t.Errorf("Expected supported option to be accepted, got %v", err)
+	}
+}
+
+func TestJSONFormatMatchesSave(t *testing.T) {
+	list := NewList()
+	list.Add("Task")
+
+	f, _ := LookupFormat("json")
+	enc, _ := f.Encoder(nil)
+
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, list); err != nil {
+		t.Fatalf("Failed to encode: %v", err)
+	}
+
+	dec, _ := f.Decoder(nil)
+	target := NewList()
+	target.Add("Existing")
+	result, err := dec.Decode(strings.NewReader(buf.String()), target)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+
+	if len(result.Items) != 1 || target.Count() != 2 || target.Items[1].Text != "Task" {
+		t.Errorf("Expected decoded item to be appended, got %+v", target.Items)
+	}
+}
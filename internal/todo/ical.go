@@ -0,0 +1,568 @@
+package todo
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// iCalendar constants used when writing VTODO components.
+const (
+	icsProductID   = "-//kai-xlr//CLI-Task-Manager//EN"
+	icsMaxLineLen  = 75
+	icsDateTimeUTC = "20060102T150405Z"
+	icsDateTime    = "20060102T150405"
+	icsDate        = "20060102"
+)
+
+// icsStamp returns the DTSTAMP written for each component. Tests replace it
+// to produce reproducible output.
+var icsStamp = time.Now
+
+// WriteICS writes the list as an RFC 5545 iCalendar object containing one
+// VTODO component per item. Subtasks are written as separate components
+// linked to their parent with RELATED-TO, and custom fields as
+// X-TODO-FIELD-<NAME> properties.
+func (l *List) WriteICS(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	stamp := icsStamp().UTC().Format(icsDateTimeUTC)
+	defs := l.FieldDefs()
+
+	writeICSLine(bw, "BEGIN:VCALENDAR")
+	writeICSLine(bw, "VERSION:2.0")
+	writeICSLine(bw, "PRODID:"+icsProductID)
+	for _, item := range l.Items {
+		writeVTODO(bw, item, "", stamp, defs)
+	}
+	writeICSLine(bw, "END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+// writeVTODO writes a single item, followed by its subtasks.
+func writeVTODO(w *bufio.Writer, item Item, parentUID, stamp string, fields []FieldDef) {
+	uid := item.UID
+	if uid == "" {
+		uid = derivedUID(item)
+	}
+
+	writeICSLine(w, "BEGIN:VTODO")
+	writeICSLine(w, "UID:"+escapeICSText(uid))
+	writeICSLine(w, "DTSTAMP:"+stamp)
+	if !item.CreatedAt.IsZero() {
+		writeICSLine(w, "CREATED:"+item.CreatedAt.UTC().Format(icsDateTimeUTC))
+	}
+	writeICSLine(w, "SUMMARY:"+escapeICSText(item.Text))
+	if item.Done {
+		writeICSLine(w, "STATUS:COMPLETED")
+	} else {
+		writeICSLine(w, "STATUS:NEEDS-ACTION")
+	}
+	if item.CompletedAt != nil {
+		writeICSLine(w, "COMPLETED:"+item.CompletedAt.UTC().Format(icsDateTimeUTC))
+	}
+	if item.Due != nil {
+		writeICSLine(w, "DUE:"+item.Due.UTC().Format(icsDateTimeUTC))
+	}
+	if p := icsPriority(item.Priority); p != 0 {
+		writeICSLine(w, "PRIORITY:"+strconv.Itoa(p))
+	}
+	if len(item.Tags) > 0 {
+		escaped := make([]string, len(item.Tags))
+		for i, tag := range item.Tags {
+			escaped[i] = escapeICSText(tag)
+		}
+		writeICSLine(w, "CATEGORIES:"+strings.Join(escaped, ","))
+	}
+	if item.Project != "" {
+		writeICSLine(w, "X-TODO-PROJECT:"+escapeICSText(item.Project))
+	}
+	if item.Recurrence != "" {
+		writeICSLine(w, "RRULE:"+item.Recurrence)
+	}
+	for _, def := range fields {
+		if value, ok := item.Fields[def.Name]; ok {
+			writeICSLine(w, icsFieldPrefix+strings.ToUpper(def.Name)+":"+escapeICSText(value))
+		}
+	}
+	if parentUID != "" {
+		writeICSLine(w, "RELATED-TO;RELTYPE=PARENT:"+escapeICSText(parentUID))
+	}
+	writeICSLine(w, "END:VTODO")
+
+	for _, sub := range item.Subtasks {
+		writeVTODO(w, sub, uid, stamp, fields)
+	}
+}
+
+// derivedUID returns a stable identifier for items that do not have one.
+func derivedUID(item Item) string {
+	sum := sha1.Sum([]byte(itemKey(item)))
+	return hex.EncodeToString(sum[:10]) + "@todo"
+}
+
+// itemKey identifies an item without a UID by its creation time and text.
+func itemKey(item Item) string {
+	return item.CreatedAt.UTC().Format(time.RFC3339Nano) + "\x00" + item.Text
+}
+
+// writeICSLine writes a content line terminated by CRLF, folding it so that
+// no physical line exceeds 75 octets. Folds never split a UTF-8 sequence.
+func writeICSLine(w *bufio.Writer, line string) {
+	limit := icsMaxLineLen
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space, which counts towards the limit.
+		limit = icsMaxLineLen - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+// escapeICSText escapes a TEXT value as described in RFC 5545 section 3.3.11.
+func escapeICSText(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case ';':
+			b.WriteString(`\;`)
+		case ',':
+			b.WriteString(`\,`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// unescapeICSText reverses escapeICSText.
+func unescapeICSText(s string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		if escaped {
+			switch r {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteRune(r)
+			}
+			escaped = false
+			continue
+		}
+		if r == '\\' {
+			escaped = true
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// splitICSList splits an escaped comma-separated TEXT list.
+func splitICSList(s string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			parts = append(parts, unescapeICSText(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, unescapeICSText(s[start:]))
+}
+
+// icsPriority maps a Priority onto the RFC 5545 1-9 scale (0 is undefined).
+func icsPriority(p Priority) int {
+	switch p {
+	case PriorityHigh:
+		return 1
+	case PriorityMedium:
+		return 5
+	case PriorityLow:
+		return 9
+	}
+	return 0
+}
+
+// priorityFromICS maps an RFC 5545 PRIORITY value onto a Priority.
+func priorityFromICS(n int) Priority {
+	switch {
+	case n >= 1 && n <= 4:
+		return PriorityHigh
+	case n == 5:
+		return PriorityMedium
+	case n >= 6 && n <= 9:
+		return PriorityLow
+	}
+	return PriorityNone
+}
+
+// icsProperty is a parsed iCalendar content line.
+type icsProperty struct {
+	line   int
+	name   string
+	params map[string]string
+	value  string
+}
+
+// icsFieldPrefix starts the name of the property holding a custom field.
+const icsFieldPrefix = "X-TODO-FIELD-"
+
+// ReadICS reads the VTODO components of an iCalendar object. Other
+// components such as VEVENT are skipped. A component with an invalid
+// property value is reported in the result and omitted from Items.
+// X-TODO-FIELD properties set the given custom fields; properties for
+// other fields are counted in Unmapped.
+func ReadICS(r io.Reader, fields ...FieldDef) (*ImportResult, error) {
+	props, err := readICSProperties(r)
+	if err != nil {
+		return nil, err
+	}
+
+	type parsed struct {
+		item   Item
+		parent string
+	}
+
+	result := &ImportResult{}
+	var todos []*parsed
+	var current *parsed
+	var currentErr error
+	begin := 0
+	depth := 0
+
+	for _, p := range props {
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VTODO"):
+			if current != nil {
+				return nil, fmt.Errorf("line %d: nested VTODO component", p.line)
+			}
+			current = &parsed{}
+			currentErr = nil
+			depth = 0
+			begin = p.line
+			continue
+		case p.name == "END" && strings.EqualFold(p.value, "VTODO"):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: END:VTODO without BEGIN:VTODO", p.line)
+			}
+			if currentErr == nil {
+				current.item, currentErr = validateFields(current.item, fields)
+			}
+			switch {
+			case currentErr != nil:
+				result.Errors = append(result.Errors, RowError{Line: begin, Err: currentErr})
+			case strings.TrimSpace(current.item.Text) == "":
+				result.Errors = append(result.Errors, RowError{Line: begin, Err: errors.New("VTODO has no SUMMARY")})
+			default:
+				if current.item.CreatedAt.IsZero() {
+					current.item.CreatedAt = time.Now()
+				}
+				todos = append(todos, current)
+			}
+			current = nil
+			continue
+		}
+
+		if current == nil || currentErr != nil {
+			continue
+		}
+
+		// Skip properties of sub-components such as VALARM.
+		if p.name == "BEGIN" {
+			depth++
+			continue
+		}
+		if p.name == "END" {
+			depth--
+			continue
+		}
+		if depth > 0 {
+			continue
+		}
+
+		if name := strings.TrimPrefix(p.name, icsFieldPrefix); name != p.name {
+			name = strings.ToLower(name)
+			if _, ok := findFieldDef(fields, name); !ok {
+				if result.Unmapped == nil {
+					result.Unmapped = make(map[string]int)
+				}
+				result.Unmapped[p.name]++
+				continue
+			}
+			if current.item.Fields == nil {
+				current.item.Fields = make(map[string]string)
+			}
+			current.item.Fields[name] = unescapeICSText(p.value)
+			continue
+		}
+
+		if err := applyICSProperty(&current.item, &current.parent, p); err != nil {
+			currentErr = fmt.Errorf("%s: %w", p.name, err)
+		}
+	}
+	if current != nil {
+		return nil, fmt.Errorf("line %d: VTODO is not terminated by END:VTODO", begin)
+	}
+
+	// Rebuild the subtask hierarchy from RELATED-TO links.
+	byUID := make(map[string]*parsed)
+	for _, t := range todos {
+		if t.item.UID != "" {
+			byUID[t.item.UID] = t
+		}
+	}
+	children := make(map[*parsed][]*parsed)
+	var roots []*parsed
+	for _, t := range todos {
+		parent, ok := byUID[t.parent]
+		if t.parent == "" || !ok || parent == t {
+			roots = append(roots, t)
+			continue
+		}
+		children[parent] = append(children[parent], t)
+	}
+
+	var build func(ts []*parsed, seen map[*parsed]bool) []Item
+	build = func(ts []*parsed, seen map[*parsed]bool) []Item {
+		var items []Item
+		for _, t := range ts {
+			if seen[t] {
+				continue
+			}
+			seen[t] = true
+			item := t.item
+			item.Subtasks = build(children[t], seen)
+			items = append(items, item)
+		}
+		return items
+	}
+	result.Items = build(roots, make(map[*parsed]bool))
+
+	return result, nil
+}
+
+// applyICSProperty stores a VTODO property on the item.
+func applyICSProperty(item *Item, parent *string, p icsProperty) error {
+	switch p.name {
+	case "UID":
+		item.UID = unescapeICSText(p.value)
+	case "SUMMARY":
+		item.Text = strings.TrimSpace(unescapeICSText(p.value))
+	case "STATUS":
+		item.Done = strings.EqualFold(p.value, "COMPLETED")
+	case "CREATED":
+		t, err := parseICSTime(p)
+		if err != nil {
+			return err
+		}
+		item.CreatedAt = t
+	case "COMPLETED":
+		t, err := parseICSTime(p)
+		if err != nil {
+			return err
+		}
+		item.CompletedAt = &t
+		item.Done = true
+	case "DUE":
+		t, err := parseICSTime(p)
+		if err != nil {
+			return err
+		}
+		item.Due = &t
+	case "PRIORITY":
+		n, err := strconv.Atoi(strings.TrimSpace(p.value))
+		if err != nil || n < 0 || n > 9 {
+			return fmt.Errorf("invalid priority %q", p.value)
+		}
+		item.Priority = priorityFromICS(n)
+	case "CATEGORIES":
+		for _, tag := range splitICSList(p.value) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				item.Tags = append(item.Tags, tag)
+			}
+		}
+	case "X-TODO-PROJECT":
+		item.Project = unescapeICSText(p.value)
+	case "RRULE":
+		item.Recurrence = p.value
+	case "RELATED-TO":
+		if rel := p.params["RELTYPE"]; rel == "" || strings.EqualFold(rel, "PARENT") {
+			*parent = unescapeICSText(p.value)
+		}
+	}
+	return nil
+}
+
+// parseICSTime parses a DATE or DATE-TIME property value, honouring the
+// TZID parameter. Floating times are interpreted in the local time zone.
+func parseICSTime(p icsProperty) (time.Time, error) {
+	value := strings.TrimSpace(p.value)
+
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(value) == len(icsDate) {
+		t, err := time.ParseInLocation(icsDate, value, time.Local)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", value)
+		}
+		return t, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icsDateTimeUTC, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date-time %q", value)
+		}
+		return t, nil
+	}
+
+	loc := time.Local
+	if tzid := p.params["TZID"]; tzid != "" {
+		l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %q", tzid)
+		}
+		loc = l
+	}
+
+	t, err := time.ParseInLocation(icsDateTime, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date-time %q", value)
+	}
+	return t, nil
+}
+
+// readICSProperties unfolds and parses every content line of the input.
+func readICSProperties(r io.Reader) ([]icsProperty, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var props []icsProperty
+	var current strings.Builder
+	start, lineNo := 0, 0
+
+	flush := func() error {
+		if current.Len() == 0 {
+			return nil
+		}
+		p, err := parseICSLine(current.String())
+		if err != nil {
+			return fmt.Errorf("line %d: %w", start, err)
+		}
+		p.line = start
+		props = append(props, p)
+		current.Reset()
+		return nil
+	}
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if current.Len() == 0 {
+				return nil, fmt.Errorf("line %d: continuation line without a property", lineNo)
+			}
+			current.WriteString(line[1:])
+			continue
+		}
+		if err := flush(); err != nil {
+			return nil, err
+		}
+		if line == "" {
+			continue
+		}
+		start = lineNo
+		current.WriteString(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read iCalendar data: %w", err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if len(props) == 0 || props[0].name != "BEGIN" || !strings.EqualFold(props[0].value, "VCALENDAR") {
+		return nil, errors.New("not an iCalendar object (missing BEGIN:VCALENDAR)")
+	}
+	return props, nil
+}
+
+// parseICSLine splits an unfolded content line into name, parameters and value.
+func parseICSLine(line string) (icsProperty, error) {
+	p := icsProperty{params: make(map[string]string)}
+
+	inQuotes := false
+	nameEnd, valueStart := -1, -1
+	for i := 0; i < len(line) && valueStart < 0; i++ {
+		switch line[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ';':
+			if !inQuotes && nameEnd < 0 {
+				nameEnd = i
+			}
+		case ':':
+			if !inQuotes {
+				valueStart = i + 1
+				if nameEnd < 0 {
+					nameEnd = i
+				}
+			}
+		}
+	}
+	if valueStart < 0 {
+		return p, fmt.Errorf("malformed content line %q", line)
+	}
+
+	p.name = strings.ToUpper(line[:nameEnd])
+	p.value = line[valueStart:]
+
+	if nameEnd < valueStart-1 {
+		for _, param := range splitICSParams(line[nameEnd+1 : valueStart-1]) {
+			key, value, _ := strings.Cut(param, "=")
+			p.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+
+	return p, nil
+}
+
+// splitICSParams splits a parameter list on semicolons outside quotes.
+func splitICSParams(s string) []string {
+	var params []string
+	inQuotes := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			inQuotes = !inQuotes
+		case ';':
+			if !inQuotes {
+				params = append(params, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(params, s[start:])
+}
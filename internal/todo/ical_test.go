@@ -0,0 +1,192 @@
+package todo
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// goldenList builds a list exercising every VTODO property WriteICS emits.
+func goldenList() *List {
+	created := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	completed := time.Date(2024, 1, 16, 8, 0, 0, 0, time.UTC)
+	due := time.Date(2024, 2, 1, 17, 0, 0, 0, time.UTC)
+
+	list := NewList()
+	list.Items = []Item{
+		{
+			UID:        "release-1@example.com",
+			Text:       "Prepare release; update CHANGELOG, tag v1.2 and announce it on the mailing list\\forum",
+			CreatedAt:  created,
+			Due:        &due,
+			Priority:   PriorityHigh,
+			Project:    "Release",
+			Tags:       []string{"release", "docs,web"},
+			Recurrence: "FREQ=MONTHLY;BYMONTHDAY=1",
+			Subtasks: []Item{
+				{UID: "release-1a@example.com", Text: "Write notes\nwith two lines", Done: true, CreatedAt: created, CompletedAt: &completed},
+			},
+		},
+		{
+			Text:      "Überprüfen der Übersetzungen für die Benutzeroberfläche — größere Änderungen",
+			CreatedAt: created,
+			Priority:  PriorityLow,
+		},
+	}
+	return list
+}
+
+func TestWriteICSGolden(t *testing.T) {
+	defer func(orig func() time.Time) { icsStamp = orig }(icsStamp)
+	icsStamp = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	var buf bytes.Buffer
+	if err := goldenList().WriteICS(&buf); err != nil {
+		t.Fatalf("Failed to write iCalendar: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "export.ics")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if buf.String() != string(expected) {
+		t.Errorf("Output does not match %s (run go test -update to regenerate)\ngot:\n%s", golden, buf.String())
+	}
+
+	for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > icsMaxLineLen {
+			t.Errorf("Line %d is %d octets, exceeding the 75 octet limit", i+1, len(line))
+		}
+	}
+}
+
+func TestReadICSGolden(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "import.ics"))
+	if err != nil {
+		t.Fatalf("Failed to open golden file: %v", err)
+	}
+	defer f.Close()
+
+	result, err := ReadICS(f)
+	if err != nil {
+		t.Fatalf("Failed to read iCalendar: %v", err)
+	}
+
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected 1 component error, got %v", result.Errors)
+	}
+	if result.Errors[0].Line != 37 {
+		t.Errorf("Expected error for the component starting on line 37, got line %d", result.Errors[0].Line)
+	}
+
+	if len(result.Items) != 2 {
+		t.Fatalf("Expected 2 top-level items, got %d", len(result.Items))
+	}
+
+	first := result.Items[0]
+	if first.Text != "Folded summary, with escaped; characters\\and a very long tail that wraps" {
+		t.Errorf("Unexpected summary %q", first.Text)
+	}
+	if first.Priority != PriorityMedium {
+		t.Errorf("Expected medium priority, got %q", first.Priority)
+	}
+	if len(first.Tags) != 2 || first.Tags[0] != "work" || first.Tags[1] != "a,b" {
+		t.Errorf("Unexpected tags %q", first.Tags)
+	}
+	if first.Due == nil {
+		t.Fatal("Expected due date")
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	if want := time.Date(2024, 2, 1, 9, 0, 0, 0, berlin); !first.Due.Equal(want) {
+		t.Errorf("Expected due %v, got %v", want, first.Due)
+	}
+	if first.Recurrence != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Errorf("Unexpected recurrence %q", first.Recurrence)
+	}
+
+	if len(first.Subtasks) != 1 {
+		t.Fatalf("Expected 1 subtask, got %d", len(first.Subtasks))
+	}
+	sub := first.Subtasks[0]
+	if !sub.Done || sub.CompletedAt == nil {
+		t.Error("Expected subtask to be completed")
+	}
+	if sub.Text != "Line one\nLine two" {
+		t.Errorf("Unexpected subtask summary %q", sub.Text)
+	}
+
+	if result.Items[1].Text != "Date-only due" || result.Items[1].Due == nil || result.Items[1].Due.Day() != 3 {
+		t.Errorf("Unexpected second item %+v", result.Items[1])
+	}
+}
+
+func TestICSRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	original := goldenList()
+	if err := original.WriteICS(&buf); err != nil {
+		t.Fatalf("Failed to write iCalendar: %v", err)
+	}
+
+	result, err := ReadICS(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read iCalendar: %v", err)
+	}
+	if len(result.Errors) != 0 {
+		t.Fatalf("Expected no errors, got %v", result.Errors)
+	}
+	if len(result.Items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(result.Items))
+	}
+
+	for i, item := range result.Items {
+		if item.Text != original.Items[i].Text {
+			t.Errorf("Expected text %q, got %q", original.Items[i].Text, item.Text)
+		}
+	}
+
+	got := result.Items[0]
+	if got.Project != "Release" || got.Recurrence != "FREQ=MONTHLY;BYMONTHDAY=1" {
+		t.Errorf("Expected project and recurrence to survive, got %+v", got)
+	}
+	if len(got.Subtasks) != 1 || got.Subtasks[0].Text != "Write notes\nwith two lines" {
+		t.Errorf("Expected subtask to survive, got %+v", got.Subtasks)
+	}
+	if result.Items[1].UID == "" {
+		t.Error("Expected a UID to be generated for items without one")
+	}
+}
+
+func TestReadICSRejectsNonCalendar(t *testing.T) {
+	if _, err := ReadICS(strings.NewReader("hello: world\n")); err == nil {
+		t.Error("Expected error for input without BEGIN:VCALENDAR")
+	}
+
+	unterminated := "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:x\r\n"
+	if _, err := ReadICS(strings.NewReader(unterminated)); err == nil {
+		t.Error("Expected error for unterminated VTODO")
+	}
+}
+
+func TestEscapeICSText(t *testing.T) {
+	in := "a\\b;c,d\ne"
+	escaped := escapeICSText(in)
+	if escaped != `a\\b\;c\,d\ne` {
+		t.Errorf("Unexpected escaping %q", escaped)
+	}
+	if unescapeICSText(escaped) != in {
+		t.Errorf("Expected unescape to reverse escape, got %q", unescapeICSText(escaped))
+	}
+}
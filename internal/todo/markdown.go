@@ -0,0 +1,316 @@
+package todo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Markers delimiting the section of a Markdown file kept in sync by SyncMarkdown.
+const (
+	MarkdownBeginMarker = "<!-- todo:begin -->"
+	MarkdownEndMarker   = "<!-- todo:end -->"
+)
+
+// MarkdownOptions controls how a list is rendered as Markdown.
+type MarkdownOptions struct {
+	// GroupByProject emits a heading for each project, followed by its items.
+	// Items without a project are listed first, without a heading.
+	GroupByProject bool
+	// Hook, if set, is called by SyncMarkdown for each item it adds,
+	// completes or reopens, before the section is rewritten. An error
+	// stops the sync.
+	Hook HookFunc
+}
+
+var (
+	checklistPattern = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+\[([ xX])\]\s+(.*)$`)
+	headingPattern   = regexp.MustCompile(`^#{1,6}\s+(.*?)\s*#*\s*$`)
+	fieldSpanPattern = regexp.MustCompile("\\s+`([a-z][a-z0-9-]*)=([^`]*)`$")
+)
+
+// WriteMarkdown writes the list as a GitHub-flavoured Markdown task list.
+// Subtasks are written as nested items indented by two spaces per level,
+// and custom fields are appended to the item text as `name=value` spans.
+func (l *List) WriteMarkdown(w io.Writer, opts MarkdownOptions) error {
+	bw := bufio.NewWriter(w)
+
+	if !opts.GroupByProject {
+		writeMarkdownItems(bw, l.Items, 0, l.FieldDefs())
+		return bw.Flush()
+	}
+
+	var projects []string
+	groups := make(map[string][]Item)
+	for _, item := range l.Items {
+		if _, seen := groups[item.Project]; !seen && item.Project != "" {
+			projects = append(projects, item.Project)
+		}
+		groups[item.Project] = append(groups[item.Project], item)
+	}
+
+	writeMarkdownItems(bw, groups[""], 0, l.FieldDefs())
+	for i, project := range projects {
+		if i > 0 || len(groups[""]) > 0 {
+			fmt.Fprintln(bw)
+		}
+		fmt.Fprintf(bw, "## %s\n\n", project)
+		writeMarkdownItems(bw, groups[project], 0, l.FieldDefs())
+	}
+
+	return bw.Flush()
+}
+
+// writeMarkdownItems writes items and their subtasks at the given depth.
+func writeMarkdownItems(w io.Writer, items []Item, depth int, fields []FieldDef) {
+	for _, item := range items {
+		mark := " "
+		if item.Done {
+			mark = "x"
+		}
+		text := markdownText(item.Text)
+		for _, def := range fields {
+			if value, ok := item.Fields[def.Name]; ok {
+				text += fmt.Sprintf(" `%s=%s`", def.Name, strings.ReplaceAll(value, "`", "'"))
+			}
+		}
+		fmt.Fprintf(w, "%s- [%s] %s\n", strings.Repeat("  ", depth), mark, text)
+		writeMarkdownItems(w, item.Subtasks, depth+1, fields)
+	}
+}
+
+// markdownText returns text as it is written on a checklist line, with
+// runs of whitespace, including newlines, collapsed to a single space.
+func markdownText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// ReadMarkdown reads every checklist item from a Markdown document.
+// Items indented below another item become its subtasks, and items following
+// a heading are assigned the heading text as their project. Checklist items
+// inside fenced code blocks are ignored. Trailing `name=value` spans naming
+// one of the given custom fields set that field instead of being kept in the
+// item text.
+func ReadMarkdown(r io.Reader, fields ...FieldDef) ([]Item, error) {
+	type node struct {
+		indent int
+		item   *Item
+	}
+
+	var roots []*Item
+	var stack []node
+	children := make(map[*Item][]*Item)
+	project := ""
+	inFence := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			project = m[1]
+			stack = stack[:0]
+			continue
+		}
+
+		m := checklistPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		item := markdownItem(m[3], fields)
+		item.Project = project
+		if m[2] != " " {
+			item.Complete()
+		}
+
+		indent := len(strings.ReplaceAll(m[1], "\t", "    "))
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) == 0 {
+			roots = append(roots, &item)
+		} else {
+			parent := stack[len(stack)-1].item
+			item.Project = ""
+			children[parent] = append(children[parent], &item)
+		}
+		stack = append(stack, node{indent: indent, item: &item})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Markdown: %w", err)
+	}
+
+	var build func(items []*Item) []Item
+	build = func(items []*Item) []Item {
+		if len(items) == 0 {
+			return nil
+		}
+		result := make([]Item, len(items))
+		for i, item := range items {
+			result[i] = *item
+			result[i].Subtasks = build(children[item])
+		}
+		return result
+	}
+
+	items := build(roots)
+	if items == nil {
+		items = make([]Item, 0)
+	}
+	return items, nil
+}
+
+// markdownItem creates an item from checklist text, moving trailing custom
+// field spans into Fields. Spans with an unknown field or an invalid value
+// are left in the text.
+func markdownItem(text string, fields []FieldDef) Item {
+	values := make(map[string]string)
+	for {
+		m := fieldSpanPattern.FindStringSubmatchIndex(text)
+		if m == nil {
+			break
+		}
+		name, raw := text[m[2]:m[3]], text[m[4]:m[5]]
+		def, ok := findFieldDef(fields, name)
+		if !ok {
+			break
+		}
+		value, err := def.Parse(raw)
+		if err != nil {
+			break
+		}
+		values[name] = value
+		text = text[:m[0]]
+	}
+
+	item := NewItem(text)
+	if len(values) > 0 {
+		item.Fields = values
+	}
+	return item
+}
+
+// MarkdownSync summarises the changes made to a list by SyncMarkdown.
+type MarkdownSync struct {
+	Added   int
+	Updated int
+}
+
+// SyncMarkdown keeps the marked section of a Markdown document in sync with
+// the list. Checkbox changes made in the document are applied to items with
+// the same text, checklist items that only exist in the document are added
+// to the list, and the section is then rewritten from the list. If the
+// document has no markers, a new section is appended to it.
+// It returns the updated document and a summary of the list changes.
+func (l *List) SyncMarkdown(doc string, opts MarkdownOptions) (string, MarkdownSync, error) {
+	var sync MarkdownSync
+
+	before, section, after, found, err := splitMarkdownSection(doc)
+	if err != nil {
+		return "", sync, err
+	}
+
+	if found {
+		items, err := ReadMarkdown(strings.NewReader(section), l.FieldDefs()...)
+		if err != nil {
+			return "", sync, err
+		}
+
+		// Items are matched by their text as written in the document
+		index := make(map[string]int, len(l.Items))
+		for i, item := range l.Items {
+			index[markdownText(item.Text)] = i
+		}
+
+		for _, item := range items {
+			i, ok := index[markdownText(item.Text)]
+			if !ok {
+				l.Append(item)
+				i = len(l.Items) - 1
+				index[markdownText(item.Text)] = i
+				sync.Added++
+				if err := l.runHook(opts.Hook, ItemAdded, i, nil); err != nil {
+					return "", sync, err
+				}
+				continue
+			}
+			if item.Done != l.Items[i].Done {
+				before := l.Items[i].Clone()
+				change := ItemCompleted
+				if item.Done {
+					l.Complete(i)
+				} else {
+					l.Uncomplete(i)
+					change = ItemUncompleted
+				}
+				sync.Updated++
+				if err := l.runHook(opts.Hook, change, i, &before); err != nil {
+					return "", sync, err
+				}
+			}
+		}
+	} else {
+		before = doc
+		if before != "" && !strings.HasSuffix(before, "\n") {
+			before += "\n"
+		}
+		if before != "" {
+			before += "\n"
+		}
+		after = "\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(before)
+	b.WriteString(MarkdownBeginMarker)
+	b.WriteString("\n")
+	if err := l.WriteMarkdown(&b, opts); err != nil {
+		return "", sync, err
+	}
+	b.WriteString(MarkdownEndMarker)
+	b.WriteString(after)
+
+	return b.String(), sync, nil
+}
+
+// runHook calls hook, if set, for a change to the item at index.
+func (l *List) runHook(hook HookFunc, change EventType, index int, before *Item) error {
+	if hook == nil {
+		return nil
+	}
+	return hook(l, change, index, before)
+}
+
+// splitMarkdownSection splits a document around the sync markers.
+// The returned before part ends with the begin marker's preceding text and
+// after starts immediately after the end marker.
+func splitMarkdownSection(doc string) (before, section, after string, found bool, err error) {
+	begin := strings.Index(doc, MarkdownBeginMarker)
+	if begin < 0 {
+		if strings.Contains(doc, MarkdownEndMarker) {
+			return "", "", "", false, fmt.Errorf("found %s without %s", MarkdownEndMarker, MarkdownBeginMarker)
+		}
+		return "", "", "", false, nil
+	}
+
+	rest := doc[begin+len(MarkdownBeginMarker):]
+	end := strings.Index(rest, MarkdownEndMarker)
+	if end < 0 {
+		return "", "", "", false, fmt.Errorf("found %s without %s", MarkdownBeginMarker, MarkdownEndMarker)
+	}
+
+	return doc[:begin], rest[:end], rest[end+len(MarkdownEndMarker):], true, nil
+}
@@ -0,0 +1,241 @@
+package todo
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	list := NewList()
+	list.Add("Write docs")
+	list.Add("Ship release")
+	list.Complete(1)
+	list.Items[0].Subtasks = []Item{NewItem("Draft README")}
+
+	var buf bytes.Buffer
+	if err := list.WriteMarkdown(&buf, MarkdownOptions{}); err != nil {
+		t.Fatalf("Failed to write Markdown: %v", err)
+	}
+
+	expected := "- [ ] Write docs\n  - [ ] Draft README\n- [x] Ship release\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWriteMarkdownGroupByProject(t *testing.T) {
+	list := NewList()
+	list.Add("Inbox task")
+	list.Add("Fix login")
+	list.Add("Buy milk")
+	list.Add("Fix logout")
+	list.Items[1].Project = "Work"
+	list.Items[2].Project = "Home"
+	list.Items[3].Project = "Work"
+
+	var buf bytes.Buffer
+	if err := list.WriteMarkdown(&buf, MarkdownOptions{GroupByProject: true}); err != nil {
+		t.Fatalf("Failed to write Markdown: %v", err)
+	}
+
+	expected := "- [ ] Inbox task\n\n## Work\n\n- [ ] Fix login\n- [ ] Fix logout\n\n## Home\n\n- [ ] Buy milk\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestReadMarkdown(t *testing.T) {
+	doc := `# Notes
+
+Some text that is not a task.
+- a plain bullet
+
+## Work
+
+- [ ] Fix login
+  - [x] Reproduce bug
+    - [ ] Write failing test
+  - [ ] Patch handler
+* [X] Deploy
+1. [ ] Numbered task
+
+` + "```" + `
+- [ ] Not a task, inside a code block
+` + "```" + `
+`
+
+	items, err := ReadMarkdown(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Failed to read Markdown: %v", err)
+	}
+
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 top-level items, got %d", len(items))
+	}
+
+	fix := items[0]
+	if fix.Text != "Fix login" || fix.Project != "Work" {
+		t.Errorf("Unexpected first item: %+v", fix)
+	}
+	if len(fix.Subtasks) != 2 {
+		t.Fatalf("Expected 2 subtasks, got %d", len(fix.Subtasks))
+	}
+	if !fix.Subtasks[0].Done {
+		t.Error("Expected first subtask to be done")
+	}
+	if len(fix.Subtasks[0].Subtasks) != 1 || fix.Subtasks[0].Subtasks[0].Text != "Write failing test" {
+		t.Errorf("Expected nested subtask, got %+v", fix.Subtasks[0].Subtasks)
+	}
+
+	if !items[1].Done || items[1].CompletedAt == nil {
+		t.Error("Expected '[X]' item to be completed")
+	}
+	if items[2].Text != "Numbered task" {
+		t.Errorf("Expected 'Numbered task', got '%s'", items[2].Text)
+	}
+}
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	list := NewList()
+	list.Add("Parent")
+	list.Items[0].Subtasks = []Item{NewItem("Child")}
+	list.Items[0].Subtasks[0].Complete()
+
+	var buf bytes.Buffer
+	list.WriteMarkdown(&buf, MarkdownOptions{})
+
+	items, err := ReadMarkdown(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read Markdown: %v", err)
+	}
+
+	if len(items) != 1 || len(items[0].Subtasks) != 1 {
+		t.Fatalf("Expected parent with one subtask, got %+v", items)
+	}
+	if !items[0].Subtasks[0].Done {
+		t.Error("Expected subtask completion to survive round trip")
+	}
+}
+
+func TestSyncMarkdown(t *testing.T) {
+	list := NewList()
+	list.Add("Task A")
+	list.Add("Task B")
+
+	doc := "# Project\n\n" + MarkdownBeginMarker + "\n- [x] Task A\n- [ ] Task B\n- [ ] Task C\n" +
+		MarkdownEndMarker + "\n\nFooter\n"
+
+	updated, sync, err := list.SyncMarkdown(doc, MarkdownOptions{})
+	if err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+
+	if sync.Added != 1 || sync.Updated != 1 {
+		t.Errorf("Expected 1 added and 1 updated, got %+v", sync)
+	}
+	if !list.Items[0].Done {
+		t.Error("Expected checkbox change to be applied to the list")
+	}
+	if list.Count() != 3 || list.Items[2].Text != "Task C" {
+		t.Error("Expected new checklist item to be added to the list")
+	}
+
+	expected := "# Project\n\n" + MarkdownBeginMarker + "\n- [x] Task A\n- [ ] Task B\n- [ ] Task C\n" +
+		MarkdownEndMarker + "\n\nFooter\n"
+	if updated != expected {
+		t.Errorf("Expected %q, got %q", expected, updated)
+	}
+}
+
+func TestSyncMarkdownMultiLineItem(t *testing.T) {
+	list := NewList()
+	list.Add("line one\nline two")
+	list.Add("spaced   out")
+
+	updated, _, err := list.SyncMarkdown("", MarkdownOptions{})
+	if err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+	updated = strings.Replace(updated, "- [ ] line one", "- [x] line one", 1)
+
+	_, sync, err := list.SyncMarkdown(updated, MarkdownOptions{})
+	if err != nil {
+		t.Fatalf("Failed to sync again: %v", err)
+	}
+	if sync.Added != 0 || sync.Updated != 1 {
+		t.Errorf("Expected 0 added and 1 updated, got %+v", sync)
+	}
+	if list.Count() != 2 || !list.Items[0].Done {
+		t.Errorf("Expected the multi-line item to be matched and completed, got %v", list.Items)
+	}
+}
+
+func TestSyncMarkdownHook(t *testing.T) {
+	list := NewList()
+	list.Add("Task A")
+
+	var changes []EventType
+	hook := func(l *List, change EventType, index int, before *Item) error {
+		changes = append(changes, change)
+		if change == ItemAdded {
+			item := l.Items[index]
+			item.Text = strings.ToUpper(item.Text)
+			return l.Replace(index, item)
+		}
+		return nil
+	}
+
+	doc := MarkdownBeginMarker + "\n- [x] Task A\n- [ ] Task B\n" + MarkdownEndMarker + "\n"
+	updated, _, err := list.SyncMarkdown(doc, MarkdownOptions{Hook: hook})
+	if err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+
+	if len(changes) != 2 || changes[0] != ItemCompleted || changes[1] != ItemAdded {
+		t.Errorf("Expected hooks for a completion and an addition, got %v", changes)
+	}
+	expected := MarkdownBeginMarker + "\n- [x] Task A\n- [ ] TASK B\n" + MarkdownEndMarker + "\n"
+	if updated != expected {
+		t.Errorf("Expected the section to show the hook's change %q, got %q", expected, updated)
+	}
+
+	veto := func(*List, EventType, int, *Item) error { return errors.New("vetoed") }
+	doc = MarkdownBeginMarker + "\n- [ ] Task C\n" + MarkdownEndMarker + "\n"
+	if _, _, err := list.SyncMarkdown(doc, MarkdownOptions{Hook: veto}); err == nil {
+		t.Error("Expected the hook's error to stop the sync")
+	}
+}
+
+func TestSyncMarkdownAppendsSection(t *testing.T) {
+	list := NewList()
+	list.Add("Task A")
+
+	updated, _, err := list.SyncMarkdown("# Readme", MarkdownOptions{})
+	if err != nil {
+		t.Fatalf("Failed to sync: %v", err)
+	}
+
+	expected := "# Readme\n\n" + MarkdownBeginMarker + "\n- [ ] Task A\n" + MarkdownEndMarker + "\n"
+	if updated != expected {
+		t.Errorf("Expected %q, got %q", expected, updated)
+	}
+}
+
+func TestSyncMarkdownUnbalancedMarkers(t *testing.T) {
+	list := NewList()
+	if _, _, err := list.SyncMarkdown(MarkdownBeginMarker+"\n- [ ] x\n", MarkdownOptions{}); err == nil {
+		t.Error("Expected error for missing end marker")
+	}
+}
+
+func TestListStringShowsSubtasks(t *testing.T) {
+	list := NewList()
+	list.Add("Parent")
+	list.Items[0].Subtasks = []Item{NewItem("Child")}
+
+	if !strings.Contains(list.String(), "   - [ ] Child\n") {
+		t.Errorf("Expected indented subtask in output, got %q", list.String())
+	}
+}
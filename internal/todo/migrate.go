@@ -0,0 +1,212 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CurrentVersion is the schema version written by Save. Files without a
+// version field are treated as version 0. It only needs to change when
+// existing data must be rewritten; new fields are carried through older
+// releases in Item.Extra and List.Extra.
+const CurrentVersion = 1
+
+// ErrNewerVersion is returned when a file was written by a newer release
+// with a schema this package does not understand.
+var ErrNewerVersion = errors.New("todo file was written by a newer version of todo")
+
+// migrationContext carries information about the file being migrated.
+type migrationContext struct {
+	// ModTime is the modification time of the file, used as a best guess
+	// for timestamps that older schemas did not record.
+	ModTime time.Time
+}
+
+// migration upgrades a raw document from one schema version to the next.
+type migration struct {
+	from        int
+	description string
+	apply       func(doc map[string]interface{}, ctx migrationContext) error
+}
+
+// migrations is the upgrade chain, ordered by the version it upgrades from.
+var migrations = []migration{
+	{
+		from:        0,
+		description: "backfill missing creation timestamps",
+		apply:       backfillCreatedAt,
+	},
+}
+
+// Migration describes the upgrade a file needs to reach CurrentVersion.
+type Migration struct {
+	From  int
+	To    int
+	Steps []string
+}
+
+// Needed reports whether the file must be migrated.
+func (m Migration) Needed() bool {
+	return m.From < m.To
+}
+
+// CheckMigration reports the schema version of a todo file and the
+// migration steps Load would apply to it, without modifying anything.
+func CheckMigration(filename string) (Migration, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return Migration{}, fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+
+	version, err := documentVersion(data)
+	if err != nil {
+		return Migration{}, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+
+	m := Migration{From: version, To: CurrentVersion}
+	if version > CurrentVersion {
+		return m, newerVersionError(version)
+	}
+	for _, step := range migrations {
+		if step.from >= version {
+			m.Steps = append(m.Steps, step.description)
+		}
+	}
+	return m, nil
+}
+
+// BackupFilename returns the name of the copy Save keeps of a file Load
+// migrated from the given version.
+func BackupFilename(filename string, version int) string {
+	return fmt.Sprintf("%s.v%d.bak", filename, version)
+}
+
+// migratedFile is the original content of a file Load upgraded.
+type migratedFile struct {
+	path    string
+	version int
+	data    []byte
+}
+
+// backUp writes the original of a migrated list before Save first replaces
+// it. An existing backup is kept, as it is older.
+func (l *List) backUp(filename string) error {
+	m := l.migrated
+	if m == nil || absPath(filename) != m.path {
+		return nil
+	}
+	backup := BackupFilename(filename, m.version)
+	if _, err := os.Stat(backup); os.IsNotExist(err) {
+		if err := os.WriteFile(backup, m.data, 0644); err != nil {
+			return fmt.Errorf("failed to back up %s before migration: %w", filename, err)
+		}
+	}
+	l.migrated = nil
+	return nil
+}
+
+// absPath returns filename as an absolute path, or unchanged if that fails.
+func absPath(filename string) string {
+	if abs, err := filepath.Abs(filename); err == nil {
+		return abs
+	}
+	return filename
+}
+
+// decodeVersioned unmarshals a JSON document into the list, upgrading it to
+// CurrentVersion first. It returns the version the document was stored as.
+func decodeVersioned(data []byte, l *List, ctx migrationContext) (int, error) {
+	version, err := documentVersion(data)
+	if err != nil {
+		return 0, err
+	}
+	if version > CurrentVersion {
+		return version, newerVersionError(version)
+	}
+
+	if version < CurrentVersion {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return version, fmt.Errorf("failed to unmarshal todo list: %w", err)
+		}
+
+		for _, step := range migrations {
+			if step.from < version {
+				continue
+			}
+			if err := step.apply(doc, ctx); err != nil {
+				return version, fmt.Errorf("failed to migrate from version %d (%s): %w", step.from, step.description, err)
+			}
+		}
+		doc["version"] = CurrentVersion
+
+		if data, err = json.Marshal(doc); err != nil {
+			return version, fmt.Errorf("failed to marshal migrated todo list: %w", err)
+		}
+	}
+
+	if err := json.Unmarshal(data, l); err != nil {
+		return version, fmt.Errorf("failed to unmarshal todo list: %w", err)
+	}
+	return version, nil
+}
+
+// documentVersion extracts the schema version of a raw JSON document.
+func documentVersion(data []byte) (int, error) {
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal todo list: %w", err)
+	}
+	if header.Version < 0 {
+		return 0, fmt.Errorf("invalid schema version %d", header.Version)
+	}
+	return header.Version, nil
+}
+
+// newerVersionError wraps ErrNewerVersion with the versions involved.
+func newerVersionError(version int) error {
+	return fmt.Errorf("%w (file version %d, supported up to %d); upgrade todo to open it",
+		ErrNewerVersion, version, CurrentVersion)
+}
+
+// backfillCreatedAt sets a creation time on items saved before timestamps
+// were recorded, preferring the completion time and falling back to the
+// file's modification time.
+func backfillCreatedAt(doc map[string]interface{}, ctx migrationContext) error {
+	items, _ := doc["items"].([]interface{})
+	return backfillItems(items, ctx)
+}
+
+// backfillItems applies backfillCreatedAt to items and their subtasks.
+func backfillItems(items []interface{}, ctx migrationContext) error {
+	for _, raw := range items {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			return errors.New("item is not a JSON object")
+		}
+
+		created, _ := item["created_at"].(string)
+		t, err := time.Parse(time.RFC3339Nano, created)
+		if created == "" || (err == nil && t.IsZero()) {
+			stamp := ctx.ModTime
+			if completed, ok := item["completed_at"].(string); ok {
+				if c, err := time.Parse(time.RFC3339Nano, completed); err == nil && !c.IsZero() {
+					stamp = c
+				}
+			}
+			item["created_at"] = stamp.Format(time.RFC3339Nano)
+		}
+
+		subtasks, _ := item["subtasks"].([]interface{})
+		if err := backfillItems(subtasks, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package todo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const legacyList = `{
+  "items": [
+    {"text": "Old task", "done": false, "created_at": "0001-01-01T00:00:00Z"},
+    {"text": "Old done task", "done": true, "created_at": "0001-01-01T00:00:00Z",
+     "completed_at": "2024-01-16T08:00:00Z",
+     "subtasks": [{"text": "Old subtask", "done": false}]},
+    {"text": "Recent task", "done": false, "created_at": "2024-01-15T10:30:00Z"}
+  ]
+}`
+
+func writeTestFile(t *testing.T, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "todos.json")
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("Could not write test file: %v", err)
+	}
+	return filename
+}
+
+func TestLoadMigratesLegacyFile(t *testing.T) {
+	filename := writeTestFile(t, legacyList)
+	modTime := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(filename, modTime, modTime); err != nil {
+		t.Fatalf("Could not set file time: %v", err)
+	}
+
+	list := NewList()
+	if err := list.Load(filename); err != nil {
+		t.Fatalf("Failed to load legacy file: %v", err)
+	}
+
+	if list.Version != CurrentVersion {
+		t.Errorf("Expected version %d after migration, got %d", CurrentVersion, list.Version)
+	}
+	if !list.Items[0].CreatedAt.Equal(modTime) {
+		t.Errorf("Expected zero created_at to be backfilled with file time, got %v", list.Items[0].CreatedAt)
+	}
+	if !list.Items[1].CreatedAt.Equal(*list.Items[1].CompletedAt) {
+		t.Errorf("Expected created_at to be backfilled with completed_at, got %v", list.Items[1].CreatedAt)
+	}
+	if list.Items[1].Subtasks[0].CreatedAt.IsZero() {
+		t.Error("Expected subtask created_at to be backfilled")
+	}
+	if want := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC); !list.Items[2].CreatedAt.Equal(want) {
+		t.Errorf("Expected existing created_at to be kept, got %v", list.Items[2].CreatedAt)
+	}
+
+	if _, err := os.Stat(BackupFilename(filename, 0)); !os.IsNotExist(err) {
+		t.Error("Loading should not write a backup")
+	}
+	if err := list.Save(filename); err != nil {
+		t.Fatalf("Failed to save migrated list: %v", err)
+	}
+	backup, err := os.ReadFile(BackupFilename(filename, 0))
+	if err != nil {
+		t.Fatalf("Expected backup of the pre-migration file: %v", err)
+	}
+	if string(backup) != legacyList {
+		t.Error("Backup should contain the original file unchanged")
+	}
+}
+
+func TestSaveKeepsFirstBackup(t *testing.T) {
+	filename := writeTestFile(t, legacyList)
+	backupName := BackupFilename(filename, 0)
+	if err := os.WriteFile(backupName, []byte("original"), 0644); err != nil {
+		t.Fatalf("Could not write backup: %v", err)
+	}
+
+	list := NewList()
+	if err := list.Load(filename); err != nil {
+		t.Fatalf("Failed to load legacy file: %v", err)
+	}
+	if err := list.Save(filename); err != nil {
+		t.Fatalf("Failed to save migrated list: %v", err)
+	}
+
+	backup, _ := os.ReadFile(backupName)
+	if string(backup) != "original" {
+		t.Error("Existing backup should not be overwritten")
+	}
+}
+
+func TestSaveElsewhereWritesNoBackup(t *testing.T) {
+	filename := writeTestFile(t, legacyList)
+	other := filepath.Join(t.TempDir(), "copy.json")
+
+	list := NewList()
+	if err := list.Load(filename); err != nil {
+		t.Fatalf("Failed to load legacy file: %v", err)
+	}
+	if err := list.Save(other); err != nil {
+		t.Fatalf("Failed to save copy: %v", err)
+	}
+
+	for _, name := range []string{BackupFilename(filename, 0), BackupFilename(other, 0)} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("Expected no backup %s when the original is not replaced", name)
+		}
+	}
+}
+
+func TestLoadRejectsNewerVersion(t *testing.T) {
+	filename := writeTestFile(t, `{"version": 99, "items": [], "future_field": true}`)
+
+	err := NewList().Load(filename)
+	if !errors.Is(err, ErrNewerVersion) {
+		t.Fatalf("Expected ErrNewerVersion, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "99") {
+		t.Errorf("Expected error to mention the file version, got %v", err)
+	}
+}
+
+func TestSaveWritesCurrentVersion(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "todos.json")
+
+	list := NewList()
+	list.Version = 0
+	list.Add("Task")
+	if err := list.Save(filename); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	m, err := CheckMigration(filename)
+	if err != nil {
+		t.Fatalf("Failed to check migration: %v", err)
+	}
+	if m.From != CurrentVersion || m.Needed() {
+		t.Errorf("Expected saved file to be current, got %+v", m)
+	}
+	if _, err := os.Stat(BackupFilename(filename, 0)); !os.IsNotExist(err) {
+		t.Error("Loading a current file should not create a backup")
+	}
+}
+
+func TestCheckMigration(t *testing.T) {
+	filename := writeTestFile(t, legacyList)
+
+	m, err := CheckMigration(filename)
+	if err != nil {
+		t.Fatalf("Failed to check migration: %v", err)
+	}
+	if !m.Needed() || m.From != 0 || len(m.Steps) != 1 {
+		t.Errorf("Expected one pending step from version 0, got %+v", m)
+	}
+
+	if _, err := os.Stat(BackupFilename(filename, 0)); !os.IsNotExist(err) {
+		t.Error("CheckMigration should not modify anything")
+	}
+
+	newer := writeTestFile(t, `{"version": 5}`)
+	if _, err := CheckMigration(newer); !errors.Is(err, ErrNewerVersion) {
+		t.Errorf("Expected ErrNewerVersion, got %v", err)
+	}
+}
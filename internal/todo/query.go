@@ -0,0 +1,346 @@
+package todo
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter reports whether an item matches a query.
+type Filter func(item Item) bool
+
+// valueKind determines how field values are compared.
+type valueKind int
+
+const (
+	kindString valueKind = iota
+	kindNumber
+	kindDate
+	kindBool
+)
+
+// value is a comparable field value. Ordered kinds use num, strings use str.
+type value struct {
+	kind valueKind
+	str  string
+	num  float64
+}
+
+// compare orders two values of the same kind.
+func (v value) compare(o value) int {
+	if v.kind == kindString {
+		return strings.Compare(strings.ToLower(v.str), strings.ToLower(o.str))
+	}
+	switch {
+	case v.num < o.num:
+		return -1
+	case v.num > o.num:
+		return 1
+	}
+	return 0
+}
+
+// priorityRank orders priorities from none to high.
+var priorityRank = map[Priority]float64{
+	PriorityNone:   0,
+	PriorityLow:    1,
+	PriorityMedium: 2,
+	PriorityHigh:   3,
+}
+
+var termPattern = regexp.MustCompile(`^([a-z][a-z0-9_-]*)(<=|>=|!=|=|<|>|~|:)(.*)$`)
+
+// ParseFilter parses a query made of whitespace-separated terms, all of
+// which must match. Each term has the form field<op>value, where op is one
+// of = != < <= > >= (comparison), ~ (case-insensitive substring) or : (an
+// alias for =). The bare words done and pending are shorthand for done=true
+// and done=false. Fields are text, done, project, priority, tag, due,
+// created, completed, or any custom field of the list. Dates accept
+// YYYY-MM-DD, today, tomorrow and yesterday, and are compared by day.
+func (l *List) ParseFilter(expr string) (Filter, error) {
+	var filters []Filter
+	for _, term := range strings.Fields(expr) {
+		f, err := l.parseTerm(term)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	return func(item Item) bool {
+		for _, f := range filters {
+			if !f(item) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// parseTerm parses a single filter term.
+func (l *List) parseTerm(term string) (Filter, error) {
+	switch strings.ToLower(term) {
+	case "done":
+		term = "done=true"
+	case "pending":
+		term = "done=false"
+	}
+
+	m := termPattern.FindStringSubmatch(term)
+	if m == nil {
+		return nil, fmt.Errorf("invalid filter term %q (expected field=value)", term)
+	}
+	name, op, raw := m[1], m[2], m[3]
+	if op == ":" {
+		op = "="
+	}
+
+	if name == "tag" || name == "tags" {
+		return tagFilter(op, raw)
+	}
+
+	get, err := l.getter(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if op == "~" {
+		needle := strings.ToLower(raw)
+		return func(item Item) bool {
+			v, ok := get(item)
+			return ok && strings.Contains(strings.ToLower(v.str), needle)
+		}, nil
+	}
+
+	operand, err := l.operand(name, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(item Item) bool {
+		v, ok := get(item)
+		if !ok {
+			return op == "!="
+		}
+		c := v.compare(operand)
+		switch op {
+		case "=":
+			return c == 0
+		case "!=":
+			return c != 0
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		case ">=":
+			return c >= 0
+		}
+		return false
+	}, nil
+}
+
+// tagFilter matches items by tag membership.
+func tagFilter(op, tag string) (Filter, error) {
+	has := func(item Item, match func(string) bool) bool {
+		for _, t := range item.Tags {
+			if match(t) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch op {
+	case "=":
+		return func(item Item) bool { return has(item, func(t string) bool { return strings.EqualFold(t, tag) }) }, nil
+	case "!=":
+		return func(item Item) bool { return !has(item, func(t string) bool { return strings.EqualFold(t, tag) }) }, nil
+	case "~":
+		needle := strings.ToLower(tag)
+		return func(item Item) bool {
+			return has(item, func(t string) bool { return strings.Contains(strings.ToLower(t), needle) })
+		}, nil
+	}
+	return nil, fmt.Errorf("tags only support =, != and ~")
+}
+
+// getter returns a function extracting the named field from an item.
+func (l *List) getter(name string) (func(Item) (value, bool), error) {
+	day := func(t *time.Time) (value, bool) {
+		if t == nil || t.IsZero() {
+			return value{}, false
+		}
+		local := t.Local()
+		d := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+		return value{kind: kindDate, num: float64(d.Unix())}, true
+	}
+
+	switch name {
+	case "text":
+		return func(item Item) (value, bool) { return value{str: item.Text}, true }, nil
+	case "project":
+		return func(item Item) (value, bool) { return value{str: item.Project}, item.Project != "" }, nil
+	case "done":
+		return func(item Item) (value, bool) {
+			v := value{kind: kindBool}
+			if item.Done {
+				v.num = 1
+			}
+			return v, true
+		}, nil
+	case "priority":
+		return func(item Item) (value, bool) {
+			return value{kind: kindNumber, num: priorityRank[item.Priority], str: string(item.Priority)}, true
+		}, nil
+	case "due":
+		return func(item Item) (value, bool) { return day(item.Due) }, nil
+	case "created", "created_at":
+		return func(item Item) (value, bool) { return day(&item.CreatedAt) }, nil
+	case "completed", "completed_at":
+		return func(item Item) (value, bool) { return day(item.CompletedAt) }, nil
+	}
+
+	def, ok := l.FieldDef(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", name)
+	}
+	return func(item Item) (value, bool) {
+		raw, ok := item.Fields[name]
+		if !ok {
+			return value{}, false
+		}
+		v, err := fieldValue(def, raw)
+		return v, err == nil
+	}, nil
+}
+
+// operand parses the right-hand side of a comparison for the named field.
+func (l *List) operand(name, raw string) (value, error) {
+	switch name {
+	case "text", "project":
+		return value{str: raw}, nil
+	case "done":
+		done, err := parseDone(raw)
+		if err != nil {
+			return value{}, err
+		}
+		v := value{kind: kindBool}
+		if done {
+			v.num = 1
+		}
+		return v, nil
+	case "priority":
+		p, err := ParsePriority(raw)
+		if err != nil {
+			return value{}, err
+		}
+		return value{kind: kindNumber, num: priorityRank[p]}, nil
+	case "due", "created", "created_at", "completed", "completed_at":
+		t, err := ParseDate(raw)
+		if err != nil {
+			return value{}, err
+		}
+		t = t.Local()
+		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+		return value{kind: kindDate, num: float64(d.Unix())}, nil
+	}
+
+	def, _ := l.FieldDef(name)
+	canonical, err := def.Parse(raw)
+	if err != nil {
+		return value{}, err
+	}
+	return fieldValue(def, canonical)
+}
+
+// fieldValue converts a canonical custom field value into a comparable value.
+func fieldValue(def FieldDef, raw string) (value, error) {
+	switch def.Type {
+	case FieldNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		return value{kind: kindNumber, num: n, str: raw}, err
+	case FieldDate:
+		t, err := time.ParseInLocation(DateLayout, raw, time.Local)
+		return value{kind: kindDate, num: float64(t.Unix()), str: raw}, err
+	case FieldEnum:
+		for i, v := range def.Values {
+			if v == raw {
+				return value{kind: kindNumber, num: float64(i), str: raw}, nil
+			}
+		}
+		return value{}, fmt.Errorf("field %s: %q is not one of %s", def.Name, raw, strings.Join(def.Values, ", "))
+	}
+	return value{str: raw}, nil
+}
+
+// Query returns the indices of the items matching the filter expression,
+// ordered by the comma-separated sort keys. A key prefixed with '-' sorts
+// in descending order; items without a value for a key sort last. With an
+// empty sort specification the list order is kept.
+func (l *List) Query(where, sortBy string) ([]int, error) {
+	filter, err := l.ParseFilter(where)
+	if err != nil {
+		return nil, err
+	}
+
+	type sortKey struct {
+		get  func(Item) (value, bool)
+		desc bool
+	}
+	var keys []sortKey
+	for _, key := range strings.Split(sortBy, ",") {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		desc := strings.HasPrefix(key, "-")
+		key = strings.TrimPrefix(key, "-")
+		if key == "tag" || key == "tags" {
+			return nil, fmt.Errorf("cannot sort by %s", key)
+		}
+		get, err := l.getter(key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, sortKey{get: get, desc: desc})
+	}
+
+	var indices []int
+	for i, item := range l.Items {
+		if filter(item) {
+			indices = append(indices, i)
+		}
+	}
+
+	sort.SliceStable(indices, func(a, b int) bool {
+		ia, ib := l.Items[indices[a]], l.Items[indices[b]]
+		for _, key := range keys {
+			va, oka := key.get(ia)
+			vb, okb := key.get(ib)
+			switch {
+			case !oka && !okb:
+				continue
+			case !oka:
+				return false
+			case !okb:
+				return true
+			}
+			c := va.compare(vb)
+			if c == 0 {
+				continue
+			}
+			if key.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+
+	return indices, nil
+}
@@ -0,0 +1,122 @@
+package todo
+
+import (
+	"testing"
+	"time"
+)
+
+func newQueryList(t *testing.T) *List {
+	t.Helper()
+
+	list := newFieldList(t)
+	list.Add("Fix login")
+	list.Add("Write docs")
+	list.Add("Plan sprint")
+	list.Add("Deploy")
+
+	list.Set(0, "ticket", "OPS-123")
+	list.Set(0, "points", "5")
+	list.Set(0, "sprint", "s2")
+	list.Set(0, "priority", "high")
+	list.Set(1, "points", "1")
+	list.Set(1, "sprint", "s1")
+	list.Set(1, "tags", "docs,easy")
+	list.Set(2, "ticket", "OPS-7")
+	list.Set(2, "sprint", "s3")
+	list.Set(2, "due", "2026-03-01")
+	list.Complete(3)
+	return list
+}
+
+func TestQueryFilter(t *testing.T) {
+	list := newQueryList(t)
+
+	tests := []struct {
+		where    string
+		expected []int
+	}{
+		{"", []int{0, 1, 2, 3}},
+		{"pending", []int{0, 1, 2}},
+		{"done", []int{3}},
+		{"ticket~ops", []int{0, 2}},
+		{"ticket=OPS-7", []int{2}},
+		{"ticket!=OPS-7", []int{0, 1, 3}},
+		{"points>=2", []int{0}},
+		{"points<10 sprint:s1", []int{1}},
+		{"sprint>s1", []int{0, 2}},
+		{"priority>=medium", []int{0}},
+		{"tag=docs", []int{1}},
+		{"due<2026-03-02", []int{2}},
+		{"text~DOC", []int{1}},
+	}
+	for _, tc := range tests {
+		got, err := list.Query(tc.where, "")
+		if err != nil {
+			t.Errorf("%q: expected no error, got %v", tc.where, err)
+			continue
+		}
+		if !equalInts(got, tc.expected) {
+			t.Errorf("%q: expected %v, got %v", tc.where, tc.expected, got)
+		}
+	}
+}
+
+func TestQuerySort(t *testing.T) {
+	list := newQueryList(t)
+
+	tests := []struct {
+		sortBy   string
+		expected []int
+	}{
+		{"sprint", []int{1, 0, 2, 3}},
+		{"-sprint", []int{2, 0, 1, 3}},
+		{"-points,text", []int{0, 1, 3, 2}},
+		{"ticket", []int{0, 2, 1, 3}},
+	}
+	for _, tc := range tests {
+		got, err := list.Query("", tc.sortBy)
+		if err != nil {
+			t.Errorf("%q: expected no error, got %v", tc.sortBy, err)
+			continue
+		}
+		if !equalInts(got, tc.expected) {
+			t.Errorf("%q: expected %v, got %v", tc.sortBy, tc.expected, got)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	list := newQueryList(t)
+
+	for _, where := range []string{"customer=ACME", "points>many", "sprint=s9", "due<someday", "ticket", "tag>a"} {
+		if _, err := list.Query(where, ""); err == nil {
+			t.Errorf("Expected error for filter %q", where)
+		}
+	}
+	if _, err := list.Query("", "tags"); err == nil {
+		t.Error("Expected error sorting by tags")
+	}
+}
+
+func TestParseDateKeywords(t *testing.T) {
+	today, err := ParseDate("today")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	tomorrow, _ := ParseDate("tomorrow")
+	if got := tomorrow.Sub(today); got < 23*time.Hour || got > 25*time.Hour {
+		t.Errorf("Expected tomorrow to be a day after today, got %v", got)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+package todo
+
+import (
+	"sync"
+)
+
+// SyncList is a List that is safe for use by several goroutines, for
+// programs such as servers that share one list between requests. Methods
+// that change the list take a write lock and the others a read lock.
+// Items are returned as copies, so callers never share memory with the
+// list.
+//
+// Item indices are those of the underlying list and change when items are
+// deleted; use Update for a change that depends on what it reads.
+type SyncList struct {
+	mu   sync.RWMutex
+	list *List
+	// saveMu keeps concurrent saves in order, so that the last save writes
+	// the newest state
+	saveMu sync.Mutex
+}
+
+// NewSyncList wraps list, which must not be used directly afterwards. A
+// nil list is replaced by an empty one.
+func NewSyncList(list *List) *SyncList {
+	if list == nil {
+		list = NewList()
+	}
+	return &SyncList{list: list}
+}
+
+// Add adds a new item and returns its index.
+func (s *SyncList) Add(text string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list.Add(text)
+}
+
+// Complete marks the item at index as done.
+func (s *SyncList) Complete(index int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list.Complete(index)
+}
+
+// Uncomplete marks the item at index as not done.
+func (s *SyncList) Uncomplete(index int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list.Uncomplete(index)
+}
+
+// Delete removes the item at index.
+func (s *SyncList) Delete(index int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list.Delete(index)
+}
+
+// Edit changes the text of the item at index.
+func (s *SyncList) Edit(index int, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list.Edit(index, text)
+}
+
+// Set assigns a field of the item at index, as List.Set does.
+func (s *SyncList) Set(index int, name, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list.Set(index, name, value)
+}
+
+// Clear removes all items.
+func (s *SyncList) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.list.Clear()
+}
+
+// Update runs fn in a transaction while holding the write lock, so that it
+// sees and changes the list with no other goroutine in between. The
+// changes are rolled back if fn fails. fn must not keep the list or call
+// other methods of s.
+func (s *SyncList) Update(fn func(tx *Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list.Transaction(fn)
+}
+
+// Subscribe calls fn with each change to the list, as List.Subscribe does.
+// fn is called while the list is locked, so it must not call methods of s;
+// use Events to handle changes in another goroutine.
+func (s *SyncList) Subscribe(fn func(Event)) (cancel func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list.Subscribe(fn)
+}
+
+// Events returns a channel receiving the changes to the list, as
+// List.Events does.
+func (s *SyncList) Events(size int) (events <-chan Event, cancel func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list.Events(size)
+}
+
+// Count returns the number of items.
+func (s *SyncList) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.list.Count()
+}
+
+// CountCompleted returns the number of completed items.
+func (s *SyncList) CountCompleted() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.list.CountCompleted()
+}
+
+// CountPending returns the number of items not yet completed.
+func (s *SyncList) CountPending() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.list.CountPending()
+}
+
+// Item returns a copy of the item at index.
+func (s *SyncList) Item(index int) (Item, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.list.validateIndex(index); err != nil {
+		return Item{}, err
+	}
+	return s.list.Items[index].Clone(), nil
+}
+
+// Items returns a copy of all items.
+func (s *SyncList) Items() []Item {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return cloneItems(s.list.Items)
+}
+
+// Range calls fn with the index and a copy of each item in turn until fn
+// returns false. It works on a copy taken when it starts, so fn may change
+// the list.
+func (s *SyncList) Range(fn func(index int, item Item) bool) {
+	for i, item := range s.Items() {
+		if !fn(i, item) {
+			return
+		}
+	}
+}
+
+// Query returns the indices of the items matching the filter expression,
+// ordered by the sort keys as List.Query does, with copies of the items.
+func (s *SyncList) Query(where, sortBy string) ([]int, []Item, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	indices, err := s.list.Query(where, sortBy)
+	if err != nil {
+		return nil, nil, err
+	}
+	items := make([]Item, len(indices))
+	for i, index := range indices {
+		items[i] = s.list.Items[index].Clone()
+	}
+	return indices, items, nil
+}
+
+// Snapshot returns a copy of the whole list.
+func (s *SyncList) Snapshot() *List {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.list.Clone()
+}
+
+// String formats the list for display, as List.String does.
+func (s *SyncList) String() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.list.String()
+}
+
+// Save writes the list to a JSON file. The list is copied first, so other
+// goroutines can go on using it while the file is written.
+func (s *SyncList) Save(filename string) error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	return s.Snapshot().Save(filename)
+}
+
+// Load replaces the list with the one read from a JSON file. The list is
+// unchanged if loading fails. Subscribers are told of the differences, as
+// List.Reset does.
+func (s *SyncList) Load(filename string) error {
+	list := NewList()
+	if err := list.Load(filename); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.list.Reset(list)
+	return nil
+}
@@ -0,0 +1,145 @@
+package todo
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSyncListConcurrentUse(t *testing.T) {
+	s := NewSyncList(nil)
+	filename := filepath.Join(t.TempDir(), "todos.json")
+
+	const workers, perWorker = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				index := s.Add(fmt.Sprintf("task %d-%d", w, i))
+				// Other goroutines may have deleted items in the meantime,
+				// so the index can be out of range
+				s.Complete(index)
+				s.Uncomplete(index / 2)
+				if i%5 == 0 {
+					s.Delete(0)
+				}
+				s.Count()
+				s.CountPending()
+				s.Items()
+				s.Query("pending", "text")
+				if i%10 == 0 {
+					if err := s.Save(filename); err != nil {
+						t.Errorf("Failed to save: %v", err)
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	deleted := workers * perWorker / 5
+	if got := s.Count(); got != workers*perWorker-deleted {
+		t.Errorf("Expected %d items, got %d", workers*perWorker-deleted, got)
+	}
+	if s.CountCompleted()+s.CountPending() != s.Count() {
+		t.Errorf("Expected completed and pending items to add up to %d", s.Count())
+	}
+
+	if err := s.Save(filename); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+	loaded := NewSyncList(nil)
+	if err := loaded.Load(filename); err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if loaded.Count() != s.Count() {
+		t.Errorf("Expected %d items after loading, got %d", s.Count(), loaded.Count())
+	}
+}
+
+func TestSyncListReturnsCopies(t *testing.T) {
+	s := NewSyncList(nil)
+	s.Add("Buy milk")
+	s.Set(0, "tags", "shop")
+
+	item, err := s.Item(0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	item.Text = "changed"
+	item.Tags[0] = "changed"
+	s.Items()[0].Tags[0] = "changed"
+	s.Snapshot().Items[0].Text = "changed"
+
+	item, _ = s.Item(0)
+	if item.Text != "Buy milk" || item.Tags[0] != "shop" {
+		t.Errorf("Expected the list to be unchanged, got %+v", item)
+	}
+
+	if _, err := s.Item(5); err == nil {
+		t.Errorf("Expected an error for an index out of range")
+	}
+}
+
+func TestSyncListUpdate(t *testing.T) {
+	s := NewSyncList(nil)
+	s.Add("one")
+	s.Add("two")
+
+	// Complete everything pending, atomically
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Update(func(tx *Tx) error {
+				indices, err := tx.Query("pending", "")
+				if err != nil {
+					return err
+				}
+				for _, index := range indices {
+					tx.Complete(index)
+				}
+				tx.Add("added")
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if s.Count() != 6 || s.CountCompleted() != 5 {
+		t.Errorf("Expected 6 items with 5 completed, got %d with %d", s.Count(), s.CountCompleted())
+	}
+
+	err := s.Update(func(tx *Tx) error {
+		tx.Clear()
+		return fmt.Errorf("failure")
+	})
+	if err == nil || s.Count() != 6 {
+		t.Errorf("Expected a failed update to be rolled back, got %v with %d items", err, s.Count())
+	}
+}
+
+func TestSyncListRange(t *testing.T) {
+	s := NewSyncList(nil)
+	s.Add("one")
+	s.Add("two")
+	s.Add("three")
+
+	var seen []string
+	s.Range(func(index int, item Item) bool {
+		seen = append(seen, item.Text)
+		// Changing the list while ranging must not deadlock
+		s.Add("more")
+		return index < 1
+	})
+	if len(seen) != 2 || seen[0] != "one" || seen[1] != "two" {
+		t.Errorf("Expected to stop after two items, got %v", seen)
+	}
+	if s.Count() != 5 {
+		t.Errorf("Expected 5 items, got %d", s.Count())
+	}
+}
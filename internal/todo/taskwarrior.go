@@ -0,0 +1,468 @@
+package todo
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// twTimeLayout is the timestamp format used by Taskwarrior's JSON export.
+const twTimeLayout = "20060102T150405Z"
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// twComputed lists attributes Taskwarrior derives on export; they carry no
+// information of their own and are not reported as unmapped.
+var twComputed = map[string]bool{
+	"id":       true,
+	"urgency":  true,
+	"modified": true,
+}
+
+// twRecurrence maps Taskwarrior recurrence periods onto RFC 5545 rules.
+var twRecurrence = map[string]string{
+	"daily":    "FREQ=DAILY",
+	"weekly":   "FREQ=WEEKLY",
+	"biweekly": "FREQ=WEEKLY;INTERVAL=2",
+	"monthly":  "FREQ=MONTHLY",
+	"yearly":   "FREQ=YEARLY",
+	"annual":   "FREQ=YEARLY",
+}
+
+// twPriority maps priorities onto Taskwarrior's H/M/L values.
+var twPriority = map[Priority]string{
+	PriorityHigh:   "H",
+	PriorityMedium: "M",
+	PriorityLow:    "L",
+}
+
+// twPeriod returns the Taskwarrior period for a recurrence rule, or "" if
+// the rule has no Taskwarrior equivalent.
+func twPeriod(rule string) string {
+	for _, period := range []string{"daily", "weekly", "biweekly", "monthly", "yearly"} {
+		if twRecurrence[period] == rule {
+			return period
+		}
+	}
+	return ""
+}
+
+// twTask is a single task in Taskwarrior's `task export` format.
+type twTask struct {
+	UUID        string         `json:"uuid"`
+	Description string         `json:"description"`
+	Status      string         `json:"status"`
+	Entry       string         `json:"entry,omitempty"`
+	End         string         `json:"end,omitempty"`
+	Due         string         `json:"due,omitempty"`
+	Project     string         `json:"project,omitempty"`
+	Tags        []string       `json:"tags,omitempty"`
+	Priority    string         `json:"priority,omitempty"`
+	Recur       string         `json:"recur,omitempty"`
+	Depends     []string       `json:"depends,omitempty"`
+	Annotations []twAnnotation `json:"annotations,omitempty"`
+	// UDA holds user-defined attributes, written as top-level fields.
+	UDA map[string]json.RawMessage `json:"-"`
+}
+
+// jsonTWTask has the fields of twTask without its MarshalJSON method.
+type jsonTWTask twTask
+
+var twTaskKeys = jsonKeys(reflect.TypeOf(twTask{}))
+
+// MarshalJSON encodes the task with its user-defined attributes.
+func (t twTask) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(jsonTWTask(t))
+	if err != nil {
+		return nil, err
+	}
+	return appendFields(data, t.UDA, twTaskKeys)
+}
+
+// twAnnotation is a Taskwarrior annotation.
+type twAnnotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+// twMapped lists the attributes ReadTaskwarrior maps onto Item fields.
+var twMapped = map[string]bool{
+	"uuid": true, "description": true, "status": true, "entry": true, "end": true,
+	"due": true, "project": true, "tags": true, "priority": true, "annotations": true,
+	"depends": true,
+}
+
+// WriteTaskwarrior writes the list as a JSON array accepted by
+// `task import`. Subtasks are exported as separate tasks that their parent
+// depends on. Items whose UID is not a UUID are given a derived one.
+// Custom fields are written as user-defined attributes (UDAs).
+func (l *List) WriteTaskwarrior(w io.Writer) error {
+	tasks := make([]twTask, 0, len(l.Items))
+	defs := l.FieldDefs()
+	var add func(item Item) string
+	add = func(item Item) string {
+		task := twTask{
+			UUID:        twUUID(item),
+			Description: item.Text,
+			Status:      "pending",
+			Project:     item.Project,
+			Tags:        item.Tags,
+			Priority:    twPriority[item.Priority],
+			Recur:       twPeriod(item.Recurrence),
+		}
+		if item.Done {
+			task.Status = "completed"
+		}
+		if !item.CreatedAt.IsZero() {
+			task.Entry = item.CreatedAt.UTC().Format(twTimeLayout)
+		}
+		if item.CompletedAt != nil {
+			task.End = item.CompletedAt.UTC().Format(twTimeLayout)
+		}
+		if item.Due != nil {
+			task.Due = item.Due.UTC().Format(twTimeLayout)
+		}
+		for _, def := range defs {
+			if value, ok := item.Fields[def.Name]; ok {
+				if task.UDA == nil {
+					task.UDA = make(map[string]json.RawMessage)
+				}
+				task.UDA[def.Name] = twUDAValue(def, value)
+			}
+		}
+		for _, note := range item.Notes {
+			task.Annotations = append(task.Annotations, twAnnotation{
+				Entry:       note.Time.UTC().Format(twTimeLayout),
+				Description: note.Text,
+			})
+		}
+
+		i := len(tasks)
+		tasks = append(tasks, task)
+		for _, sub := range item.Subtasks {
+			uuid := add(sub)
+			tasks[i].Depends = append(tasks[i].Depends, uuid)
+		}
+		return task.UUID
+	}
+
+	for _, item := range l.Items {
+		add(item)
+	}
+
+	data, err := json.MarshalIndent(tasks, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal Taskwarrior tasks: %w", err)
+	}
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
+
+// twUDAValue encodes a custom field value as a Taskwarrior UDA: numbers as
+// JSON numbers, dates in Taskwarrior's timestamp format and everything else
+// as a string.
+func twUDAValue(def FieldDef, value string) json.RawMessage {
+	switch def.Type {
+	case FieldNumber:
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.RawMessage(value)
+		}
+	case FieldDate:
+		if t, err := time.ParseInLocation(DateLayout, value, time.Local); err == nil {
+			value = t.UTC().Format(twTimeLayout)
+		}
+	}
+	data, _ := json.Marshal(value)
+	return data
+}
+
+// twUDAField decodes a Taskwarrior UDA into a custom field value.
+func twUDAField(def FieldDef, raw json.RawMessage) (string, error) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		var n json.Number
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return "", fmt.Errorf("field %s: unsupported value %s", def.Name, raw)
+		}
+		value = n.String()
+	}
+	if def.Type == FieldDate {
+		if t, err := time.Parse(twTimeLayout, value); err == nil {
+			value = t.Local().Format(DateLayout)
+		}
+	}
+	return def.Parse(value)
+}
+
+// twUUID returns the item's UID if it is a UUID, or a UUID derived from it.
+func twUUID(item Item) string {
+	switch {
+	case uuidPattern.MatchString(item.UID):
+		return strings.ToLower(item.UID)
+	case item.UID != "":
+		return nameUUID(item.UID)
+	}
+	return nameUUID(itemKey(item))
+}
+
+// nameUUID derives a version 5 style UUID from an arbitrary string.
+func nameUUID(name string) string {
+	sum := sha1.Sum([]byte(name))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	h := hex.EncodeToString(sum[:16])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// ReadTaskwarrior reads the JSON array produced by `task export`.
+// Deleted tasks are skipped and listed in Skipped, and waiting tasks are
+// imported as pending. A task that exactly one other imported task depends
+// on is nested as a subtask of that task. User-defined attributes named
+// after one of the given custom fields set that field; other attributes
+// without an Item equivalent are counted in Unmapped.
+func ReadTaskwarrior(r io.Reader, fields ...FieldDef) (*ImportResult, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Taskwarrior data: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, fmt.Errorf("invalid Taskwarrior export: expected a JSON array of tasks")
+	}
+
+	type parsed struct {
+		item    Item
+		depends []string
+	}
+
+	result := &ImportResult{Unmapped: make(map[string]int)}
+	var tasks []*parsed
+
+	for dec.More() {
+		// InputOffset points just past the previous element; skip to the next one.
+		offset := int(dec.InputOffset())
+		offset += len(data[offset:]) - len(bytes.TrimLeft(data[offset:], ", \t\r\n"))
+		line := 1 + bytes.Count(data[:offset], []byte("\n"))
+
+		var raw map[string]json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("line %d: invalid task: %w", line, err)
+		}
+
+		udas := make(map[string]json.RawMessage)
+		for attr, value := range raw {
+			if _, ok := findFieldDef(fields, attr); ok && !twMapped[attr] {
+				udas[attr] = value
+				continue
+			}
+			if !twMapped[attr] && !twComputed[attr] && !(attr == "recur" && twRecurrence[twString(raw[attr])] != "") {
+				result.Unmapped[attr]++
+			}
+		}
+
+		var task twTask
+		// Older Taskwarrior versions export depends as a comma-separated string.
+		if s := twString(raw["depends"]); s != "" {
+			delete(raw, "depends")
+			task.Depends = strings.Split(s, ",")
+		}
+		if err := remarshal(raw, &task); err != nil {
+			result.Errors = append(result.Errors, RowError{Line: line, Err: err})
+			continue
+		}
+
+		if task.Status == "deleted" {
+			result.Skipped = append(result.Skipped, RowError{Line: line, Err: fmt.Errorf("skipped deleted task %q", task.Description)})
+			continue
+		}
+
+		item, err := twItem(task)
+		if err == nil {
+			err = setUDAFields(&item, udas, fields)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, RowError{Line: line, Err: err})
+			continue
+		}
+		tasks = append(tasks, &parsed{item: item, depends: task.Depends})
+	}
+
+	// Nest dependencies with a single dependant as subtasks.
+	byUUID := make(map[string]*parsed, len(tasks))
+	dependants := make(map[string]int)
+	for _, t := range tasks {
+		byUUID[t.item.UID] = t
+		for _, dep := range t.depends {
+			dependants[dep]++
+		}
+	}
+
+	nested := make(map[*parsed]bool)
+	children := make(map[*parsed][]*parsed)
+	for _, t := range tasks {
+		for _, dep := range t.depends {
+			child, ok := byUUID[dep]
+			if !ok || dependants[dep] != 1 || child == t {
+				result.Unmapped["depends"]++
+				continue
+			}
+			children[t] = append(children[t], child)
+			nested[child] = true
+		}
+	}
+
+	var build func(t *parsed, seen map[*parsed]bool) Item
+	build = func(t *parsed, seen map[*parsed]bool) Item {
+		seen[t] = true
+		item := t.item
+		for _, child := range children[t] {
+			if !seen[child] {
+				item.Subtasks = append(item.Subtasks, build(child, seen))
+			}
+		}
+		return item
+	}
+
+	seen := make(map[*parsed]bool)
+	for _, t := range tasks {
+		if !nested[t] {
+			result.Items = append(result.Items, build(t, seen))
+		}
+	}
+	// Dependency cycles leave tasks that are only reachable from each other.
+	for _, t := range tasks {
+		if !seen[t] {
+			result.Items = append(result.Items, build(t, seen))
+		}
+	}
+
+	if len(result.Unmapped) == 0 {
+		result.Unmapped = nil
+	}
+	return result, nil
+}
+
+// twItem converts a Taskwarrior task into an item.
+func twItem(task twTask) (Item, error) {
+	if strings.TrimSpace(task.Description) == "" {
+		return Item{}, fmt.Errorf("task %s has no description", task.UUID)
+	}
+
+	item := NewItem(task.Description)
+	item.UID = task.UUID
+	item.Project = task.Project
+	item.Tags = task.Tags
+	item.Recurrence = twRecurrence[task.Recur]
+
+	switch task.Status {
+	case "", "pending", "waiting", "recurring":
+	case "completed":
+		item.Done = true
+	default:
+		return Item{}, fmt.Errorf("task %s has unknown status %q", task.UUID, task.Status)
+	}
+
+	priority, err := ParsePriority(task.Priority)
+	if err != nil {
+		return Item{}, fmt.Errorf("task %s: %w", task.UUID, err)
+	}
+	item.Priority = priority
+
+	times := []struct {
+		name  string
+		value string
+		set   func(time.Time)
+	}{
+		{"entry", task.Entry, func(t time.Time) { item.CreatedAt = t }},
+		{"end", task.End, func(t time.Time) {
+			if item.Done {
+				item.CompletedAt = &t
+			}
+		}},
+		{"due", task.Due, func(t time.Time) { item.Due = &t }},
+	}
+	for _, ts := range times {
+		if ts.value == "" {
+			continue
+		}
+		t, err := time.Parse(twTimeLayout, ts.value)
+		if err != nil {
+			return Item{}, fmt.Errorf("task %s has invalid %s %q", task.UUID, ts.name, ts.value)
+		}
+		ts.set(t)
+	}
+
+	for _, a := range task.Annotations {
+		t, err := time.Parse(twTimeLayout, a.Entry)
+		if err != nil {
+			return Item{}, fmt.Errorf("task %s has invalid annotation entry %q", task.UUID, a.Entry)
+		}
+		item.Notes = append(item.Notes, Note{Time: t, Text: a.Description})
+	}
+
+	return item, nil
+}
+
+// setUDAFields stores user-defined attributes as custom fields of the item.
+func setUDAFields(item *Item, udas map[string]json.RawMessage, fields []FieldDef) error {
+	for name, raw := range udas {
+		def, _ := findFieldDef(fields, name)
+		value, err := twUDAField(def, raw)
+		if err != nil {
+			return fmt.Errorf("task %s: %w", item.UID, err)
+		}
+		if item.Fields == nil {
+			item.Fields = make(map[string]string)
+		}
+		item.Fields[name] = value
+	}
+	return nil
+}
+
+// twString returns a JSON string value, or "" if the value is not a string.
+func twString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) != nil {
+		return ""
+	}
+	return s
+}
+
+// remarshal decodes a generic attribute map into a typed value.
+func remarshal(raw map[string]json.RawMessage, v interface{}) error {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid task: %w", err)
+	}
+	return nil
+}
+
+// UnmappedReport formats unmapped attribute counts as "name (count)" pairs,
+// sorted by name.
+func UnmappedReport(unmapped map[string]int) string {
+	names := make([]string, 0, len(unmapped))
+	for name := range unmapped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s (%d)", name, unmapped[name])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,135 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+const twSample = `[
+{"id":1,"description":"Write report","entry":"20240115T103000Z","modified":"20240116T080000Z","project":"Work","status":"pending","tags":["writing","q1"],"priority":"H","due":"20240201T170000Z","uuid":"6c2f7a0e-3f4b-4d8e-9a1c-2b3c4d5e6f70","urgency":9.5,"depends":"0b1d2e3f-4a5b-4c6d-8e7f-8091a2b3c4d5","annotations":[{"entry":"20240115T110000Z","description":"Ask Sam for figures"}]},
+{"id":0,"description":"Collect figures","entry":"20240115T103000Z","end":"20240116T080000Z","status":"completed","uuid":"0b1d2e3f-4a5b-4c6d-8e7f-8091a2b3c4d5","urgency":0},
+{"id":2,"description":"Water plants","entry":"20240110T090000Z","status":"waiting","wait":"20240120T000000Z","recur":"weekly","uuid":"aa0e1f2a-3b4c-4d5e-8f60-718293a4b5c6","estimate":"PT1H"},
+{"id":0,"description":"Old idea","entry":"20240101T090000Z","end":"20240102T090000Z","status":"deleted","uuid":"bb0e1f2a-3b4c-4d5e-8f60-718293a4b5c6"},
+{"id":3,"description":"Broken","entry":"yesterday","status":"pending","uuid":"cc0e1f2a-3b4c-4d5e-8f60-718293a4b5c6"}
+]`
+
+func TestReadTaskwarrior(t *testing.T) {
+	result, err := ReadTaskwarrior(strings.NewReader(twSample))
+	if err != nil {
+		t.Fatalf("Failed to read Taskwarrior export: %v", err)
+	}
+
+	if len(result.Items) != 2 {
+		t.Fatalf("Expected 2 top-level items, got %d", len(result.Items))
+	}
+
+	report := result.Items[0]
+	if report.UID != "6c2f7a0e-3f4b-4d8e-9a1c-2b3c4d5e6f70" {
+		t.Errorf("Expected uuid to map onto UID, got '%s'", report.UID)
+	}
+	if report.Project != "Work" || report.Priority != PriorityHigh {
+		t.Errorf("Unexpected project/priority: %q/%q", report.Project, report.Priority)
+	}
+	if len(report.Tags) != 2 || report.Tags[0] != "writing" {
+		t.Errorf("Unexpected tags %q", report.Tags)
+	}
+	if report.Due == nil || !report.Due.Equal(time.Date(2024, 2, 1, 17, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected due %v", report.Due)
+	}
+	if len(report.Notes) != 1 || report.Notes[0].Text != "Ask Sam for figures" {
+		t.Errorf("Expected annotation to map onto a note, got %+v", report.Notes)
+	}
+
+	if len(report.Subtasks) != 1 {
+		t.Fatalf("Expected dependency to be nested as a subtask, got %d subtasks", len(report.Subtasks))
+	}
+	figures := report.Subtasks[0]
+	if !figures.Done || figures.CompletedAt == nil {
+		t.Error("Expected completed task with end timestamp")
+	}
+
+	plants := result.Items[1]
+	if plants.Done {
+		t.Error("Expected waiting task to be imported as pending")
+	}
+	if plants.Recurrence != "FREQ=WEEKLY" {
+		t.Errorf("Expected weekly recurrence, got '%s'", plants.Recurrence)
+	}
+
+	if len(result.Skipped) != 1 || result.Skipped[0].Line != 5 {
+		t.Errorf("Expected deleted task on line 5 to be skipped, got %v", result.Skipped)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 6 {
+		t.Errorf("Expected invalid entry on line 6 to be reported, got %v", result.Errors)
+	}
+
+	if result.Unmapped["wait"] != 1 || result.Unmapped["estimate"] != 1 {
+		t.Errorf("Expected wait and estimate to be reported as unmapped, got %v", result.Unmapped)
+	}
+	if _, ok := result.Unmapped["urgency"]; ok {
+		t.Error("Computed attributes should not be reported as unmapped")
+	}
+	if got := UnmappedReport(result.Unmapped); got != "estimate (1), wait (1)" {
+		t.Errorf("Unexpected unmapped report '%s'", got)
+	}
+}
+
+func TestWriteTaskwarrior(t *testing.T) {
+	list := NewList()
+	list.Add("Parent")
+	list.Items[0].Priority = PriorityLow
+	list.Items[0].Recurrence = "FREQ=MONTHLY"
+	list.Items[0].Notes = []Note{{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Text: "note"}}
+	list.Items[0].Subtasks = []Item{NewItem("Child")}
+	list.Items[0].Subtasks[0].Complete()
+	list.Items[0].Subtasks[0].UID = "not-a-uuid@example.com"
+
+	var buf bytes.Buffer
+	if err := list.WriteTaskwarrior(&buf); err != nil {
+		t.Fatalf("Failed to write Taskwarrior export: %v", err)
+	}
+
+	var tasks []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &tasks); err != nil {
+		t.Fatalf("Output is not a JSON array: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d", len(tasks))
+	}
+
+	parent, child := tasks[0], tasks[1]
+	if parent["priority"] != "L" || parent["recur"] != "monthly" || parent["status"] != "pending" {
+		t.Errorf("Unexpected parent task %v", parent)
+	}
+	if child["status"] != "completed" || child["end"] == nil {
+		t.Errorf("Unexpected child task %v", child)
+	}
+	if !uuidPattern.MatchString(child["uuid"].(string)) {
+		t.Errorf("Expected a UUID for the child, got %v", child["uuid"])
+	}
+	deps, _ := parent["depends"].([]interface{})
+	if len(deps) != 1 || deps[0] != child["uuid"] {
+		t.Errorf("Expected parent to depend on child, got %v", parent["depends"])
+	}
+
+	// Reading the export back restores the hierarchy.
+	result, err := ReadTaskwarrior(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read Taskwarrior export: %v", err)
+	}
+	if len(result.Items) != 1 || len(result.Items[0].Subtasks) != 1 {
+		t.Fatalf("Expected round trip to keep the subtask, got %+v", result.Items)
+	}
+	if len(result.Unmapped) != 0 {
+		t.Errorf("Expected no unmapped attributes, got %v", result.Unmapped)
+	}
+}
+
+func TestReadTaskwarriorRejectsNonArray(t *testing.T) {
+	if _, err := ReadTaskwarrior(strings.NewReader(`{"description":"x"}`)); err == nil {
+		t.Error("Expected error for input that is not a JSON array")
+	}
+}
@@ -3,20 +3,69 @@
 package todo
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 )
 
+// Priority is the importance of a todo item.
+type Priority string
+
+// Supported priority levels. PriorityNone means no priority has been set.
+const (
+	PriorityNone   Priority = ""
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+)
+
+// ParsePriority converts a priority name (or its first letter) to a Priority.
+func ParsePriority(s string) (Priority, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "":
+		return PriorityNone, nil
+	case "l", "low":
+		return PriorityLow, nil
+	case "m", "medium":
+		return PriorityMedium, nil
+	case "h", "high":
+		return PriorityHigh, nil
+	}
+	return PriorityNone, fmt.Errorf("invalid priority %q (expected low, medium or high)", s)
+}
+
+// Note is a timestamped comment attached to an item.
+type Note struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
 // Item represents a todo item with text, completion status, and metadata.
 type Item struct {
-	Text      string    `json:"text"`
-	Done      bool      `json:"done"`
-	CreatedAt time.Time `json:"created_at"`
+	UID         string     `json:"uid,omitempty"`
+	Text        string     `json:"text"`
+	Done        bool       `json:"done"`
+	CreatedAt   time.Time  `json:"created_at"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Due         *time.Time `json:"due,omitempty"`
+	Priority    Priority   `json:"priority,omitempty"`
+	Project     string     `json:"project,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	// Recurrence is an RFC 5545 recurrence rule such as "FREQ=WEEKLY".
+	Recurrence string `json:"recurrence,omitempty"`
+	Notes      []Note `json:"notes,omitempty"`
+	Subtasks   []Item `json:"subtasks,omitempty"`
+	// Fields holds custom field values keyed by field name, in the
+	// canonical form produced by FieldDef.Parse.
+	Fields map[string]string `json:"fields,omitempty"`
+	// Extra holds fields found when loading that this package does not know
+	// about. They are written back unchanged when the item is saved.
+	Extra map[string]json.RawMessage `json:"-"`
 }
 
 // NewItem creates a new todo item with the specified text.
@@ -59,14 +108,31 @@ func (i Item) String() string {
 }
 
 // List represents a collection of todo items with management operations.
+// A List is not safe for concurrent use; share one between goroutines
+// through a SyncList.
 type List struct {
-	Items []Item `json:"items"`
+	// Version is the schema version of the persisted list. See CurrentVersion.
+	Version int    `json:"version"`
+	Items   []Item `json:"items"`
+	// Fields declares the custom fields items of this list may carry.
+	Fields []FieldDef `json:"fields,omitempty"`
+	// Extra holds unknown list-level fields, written back unchanged by Save.
+	Extra map[string]json.RawMessage `json:"-"`
+
+	// events holds the subscriptions made with Subscribe and Events.
+	events *eventHub
+	// migrated is the original of a file Load upgraded, backed up by the
+	// first Save over it.
+	migrated *migratedFile
+	// declared holds the field definitions given to Declare.
+	declared []FieldDef
 }
 
 // NewList creates a new empty todo list.
 func NewList() *List {
 	return &List{
-		Items: make([]Item, 0),
+		Version: CurrentVersion,
+		Items:   make([]Item, 0),
 	}
 }
 
@@ -75,9 +141,18 @@ func NewList() *List {
 func (l *List) Add(text string) int {
 	item := NewItem(text)
 	l.Items = append(l.Items, item)
+	l.emitAdded(len(l.Items) - 1)
 	return len(l.Items) - 1
 }
 
+// Append adds items to the end of the list.
+func (l *List) Append(items ...Item) {
+	for _, item := range items {
+		l.Items = append(l.Items, item)
+		l.emitAdded(len(l.Items) - 1)
+	}
+}
+
 // Complete marks the item at the specified index as done.
 // Returns an error if the index is out of range.
 func (l *List) Complete(index int) error {
@@ -85,8 +160,10 @@ func (l *List) Complete(index int) error {
 		return err
 	}
 
-	l.Items[index].Complete()
-	return nil
+	return l.change(ItemCompleted, index, func() error {
+		l.Items[index].Complete()
+		return nil
+	})
 }
 
 // Uncomplete marks the item at the specified index as not done.
@@ -96,8 +173,10 @@ func (l *List) Uncomplete(index int) error {
 		return err
 	}
 
-	l.Items[index].Uncomplete()
-	return nil
+	return l.change(ItemUncompleted, index, func() error {
+		l.Items[index].Uncomplete()
+		return nil
+	})
 }
 
 // Delete removes the item at the specified index from the list.
@@ -107,8 +186,17 @@ func (l *List) Delete(index int) error {
 		return err
 	}
 
+	var before *Item
+	if l.observed() {
+		item := l.Items[index].Clone()
+		before = &item
+	}
+
 	// Remove item by slicing around it
 	l.Items = append(l.Items[:index], l.Items[index+1:]...)
+	if before != nil {
+		l.emit(Event{Type: ItemDeleted, Index: index, Before: before})
+	}
 	return nil
 }
 
@@ -119,12 +207,47 @@ func (l *List) Edit(index int, newText string) error {
 		return err
 	}
 
-	newText = strings.TrimSpace(newText)
-	if newText == "" {
+	return l.change(ItemEdited, index, func() error {
+		return l.setText(index, newText)
+	})
+}
+
+// Replace stores item at the specified index in place of the current one.
+// Returns an error if the index is out of range or the item is invalid: its
+// text must not be empty, and its priority and custom field values must be
+// valid for the list.
+func (l *List) Replace(index int, item Item) error {
+	if err := l.validateIndex(index); err != nil {
+		return err
+	}
+
+	item.Text = strings.TrimSpace(item.Text)
+	if item.Text == "" {
+		return errors.New("task text cannot be empty")
+	}
+	if _, err := ParsePriority(string(item.Priority)); err != nil {
+		return err
+	}
+	item, err := validateFields(item, l.FieldDefs())
+	if err != nil {
+		return err
+	}
+
+	return l.change(ItemEdited, index, func() error {
+		l.Items[index] = item
+		return nil
+	})
+}
+
+// setText changes the text of the item at a valid index without emitting
+// an event.
+func (l *List) setText(index int, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
 		return errors.New("task text cannot be empty")
 	}
 
-	l.Items[index].Text = newText
+	l.Items[index].Text = text
 	return nil
 }
 
@@ -151,13 +274,28 @@ func (l *List) CountPending() int {
 
 // Clear removes all items from the list.
 func (l *List) Clear() {
+	cleared := l.Items
 	l.Items = make([]Item, 0)
+	if len(cleared) > 0 && l.observed() {
+		l.emit(Event{Type: ListCleared, Index: -1, Cleared: cloneItems(cleared)})
+	}
+}
+
+// IndexError is returned for an item index outside the list.
+type IndexError struct {
+	Index int
+	// Len is the number of items in the list.
+	Len int
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("item index %d out of range (0-%d)", e.Index, e.Len-1)
 }
 
 // validateIndex checks if the given index is valid for the current list.
 func (l *List) validateIndex(index int) error {
 	if index < 0 || index >= len(l.Items) {
-		return fmt.Errorf("item index %d out of range (0-%d)", index, len(l.Items)-1)
+		return &IndexError{Index: index, Len: len(l.Items)}
 	}
 	return nil
 }
@@ -174,11 +312,22 @@ func (l *List) String() string {
 	result := fmt.Sprintf("Todo List (%d/%d completed):\n", completed, total)
 	for i, item := range l.Items {
 		result += fmt.Sprintf("%d. %s\n", i+1, item.String())
+		result += subtaskString(item.Subtasks, 1)
 	}
 
 	return result
 }
 
+// subtaskString formats nested subtasks indented below their parent.
+func subtaskString(items []Item, depth int) string {
+	result := ""
+	for _, item := range items {
+		result += fmt.Sprintf("%s- %s\n", strings.Repeat("   ", depth), item.String())
+		result += subtaskString(item.Subtasks, depth+1)
+	}
+	return result
+}
+
 // Save writes the todo list to a file in JSON format with proper formatting.
 // Returns an error if the file cannot be written or JSON marshaling fails.
 func (l *List) Save(filename string) error {
@@ -186,19 +335,28 @@ func (l *List) Save(filename string) error {
 		return errors.New("filename cannot be empty")
 	}
 
-	data, err := json.MarshalIndent(l, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal todo list: %w", err)
+	l.Version = CurrentVersion
+
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, l); err != nil {
+		return err
 	}
 
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := l.backUp(filename); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
 		return fmt.Errorf("failed to write file %s: %w", filename, err)
 	}
 
 	return nil
 }
 
-// Load reads a todo list from a JSON file.
+// Load reads a todo list from a JSON file. It does not write to disk.
+// Files written with an older schema are upgraded in memory; the first Save
+// over the file keeps a copy of the original next to it (see
+// BackupFilename). Files written by a newer version fail with
+// ErrNewerVersion.
 // Returns an error if the file cannot be read or JSON unmarshaling fails.
 func (l *List) Load(filename string) error {
 	if filename == "" {
@@ -210,9 +368,54 @@ func (l *List) Load(filename string) error {
 		return fmt.Errorf("failed to read file %s: %w", filename, err)
 	}
 
-	if err := json.Unmarshal(data, l); err != nil {
-		return fmt.Errorf("failed to unmarshal todo list from %s: %w", filename, err)
+	ctx := migrationContext{ModTime: time.Now()}
+	if info, err := os.Stat(filename); err == nil {
+		ctx.ModTime = info.ModTime()
+	}
+
+	version, err := decodeVersioned(data, l, ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", filename, err)
+	}
+
+	if version < CurrentVersion {
+		l.migrated = &migratedFile{path: absPath(filename), version: version, data: data}
 	}
 
 	return nil
 }
+
+// encodeJSON is the Encoder of the "json" format, used by Save.
+func encodeJSON(w io.Writer, l *List) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal todo list: %w", err)
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// decodeJSON is the Decoder of the "json" format. It appends the items of
+// the decoded document to the list.
+func decodeJSON(r io.Reader, l *List) (*ImportResult, error) {
+	doc := NewList()
+	if err := readJSON(r, doc); err != nil {
+		return nil, err
+	}
+
+	l.Append(doc.Items...)
+	return &ImportResult{Items: doc.Items}, nil
+}
+
+// readJSON replaces the contents of the list with a JSON document,
+// migrating it to the current schema if necessary.
+func readJSON(r io.Reader, l *List) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read todo list: %w", err)
+	}
+
+	_, err = decodeVersioned(data, l, migrationContext{ModTime: time.Now()})
+	return err
+}
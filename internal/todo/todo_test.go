@@ -1,6 +1,7 @@
 package todo
 
 import (
+	"errors"
 	"os"
 	"strings"
 	"testing"
@@ -466,3 +467,20 @@ func TestLoadNonexistentFile(t *testing.T) {
 		t.Error("Expected error when loading nonexistent file")
 	}
 }
+
+func TestIndexError(t *testing.T) {
+	list := NewList()
+	list.Add("Task 1")
+
+	err := list.Complete(3)
+	var indexErr *IndexError
+	if !errors.As(err, &indexErr) {
+		t.Fatalf("Expected an IndexError, got %v", err)
+	}
+	if indexErr.Index != 3 || indexErr.Len != 1 {
+		t.Errorf("Expected index 3 of 1 item, got %+v", indexErr)
+	}
+	if err.Error() != "item index 3 out of range (0-0)" {
+		t.Errorf("Expected the usual message, got %q", err.Error())
+	}
+}
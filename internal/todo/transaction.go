@@ -0,0 +1,162 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrTxDone is returned when a transaction that has already been committed
+// or rolled back is used again.
+var ErrTxDone = errors.New("transaction has already been committed or rolled back")
+
+// Tx is a transaction on a List. It embeds the list, so changes are made
+// through the usual List methods and are visible in the list at once;
+// Rollback undoes all of them. Events for the changes are held back until
+// the outermost transaction commits.
+type Tx struct {
+	*List
+	snapshot *List
+	done     bool
+	// hub and mark record where the transaction's events start in the
+	// queue of the list's subscriptions, if it has any
+	hub  *eventHub
+	mark int
+}
+
+// Begin starts a transaction, taking a snapshot of the list to return to
+// on Rollback. Transactions may be nested.
+func (l *List) Begin() *Tx {
+	tx := &Tx{List: l, snapshot: l.Clone(), hub: l.events}
+	if tx.hub != nil {
+		tx.mark = tx.hub.begin()
+	}
+	return tx
+}
+
+// Commit keeps the changes made in the transaction.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	tx.snapshot = nil
+	if tx.hub != nil {
+		tx.hub.end(tx.mark, false)
+	}
+	return nil
+}
+
+// Rollback restores the list to its state when the transaction began.
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	events := tx.events
+	*tx.List = *tx.snapshot
+	tx.events = events
+	tx.snapshot = nil
+	if tx.hub != nil {
+		tx.hub.end(tx.mark, true)
+	}
+	return nil
+}
+
+// Transaction runs fn in a transaction. The changes fn makes are kept if
+// it returns nil, and rolled back if it returns an error, which is then
+// returned, or panics.
+func (l *List) Transaction(fn func(tx *Tx) error) (err error) {
+	tx := l.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Clone returns a deep copy of the list that shares no memory with it.
+// The copy has no subscriptions.
+func (l *List) Clone() *List {
+	c := *l
+	c.events = nil
+	if l.Items != nil {
+		c.Items = cloneItems(l.Items)
+	}
+	if l.Fields != nil {
+		c.Fields = make([]FieldDef, len(l.Fields))
+		for i, def := range l.Fields {
+			def.Values = cloneStrings(def.Values)
+			c.Fields[i] = def
+		}
+	}
+	c.Extra = cloneExtra(l.Extra)
+	return &c
+}
+
+// Clone returns a deep copy of the item that shares no memory with it.
+func (i Item) Clone() Item {
+	c := i
+	c.CompletedAt = cloneTime(i.CompletedAt)
+	c.Due = cloneTime(i.Due)
+	c.Tags = cloneStrings(i.Tags)
+	if i.Notes != nil {
+		c.Notes = append([]Note(nil), i.Notes...)
+	}
+	if i.Subtasks != nil {
+		c.Subtasks = cloneItems(i.Subtasks)
+	}
+	if i.Fields != nil {
+		c.Fields = make(map[string]string, len(i.Fields))
+		for k, v := range i.Fields {
+			c.Fields[k] = v
+		}
+	}
+	c.Extra = cloneExtra(i.Extra)
+	return c
+}
+
+// cloneItems returns a deep copy of items.
+func cloneItems(items []Item) []Item {
+	c := make([]Item, len(items))
+	for i, item := range items {
+		c[i] = item.Clone()
+	}
+	return c
+}
+
+// cloneStrings returns a copy of s, keeping nil as nil.
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string(nil), s...)
+}
+
+// cloneTime returns a copy of the time t points to, or nil.
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// cloneExtra returns a deep copy of unknown JSON fields.
+func cloneExtra(extra map[string]json.RawMessage) map[string]json.RawMessage {
+	if extra == nil {
+		return nil
+	}
+	c := make(map[string]json.RawMessage, len(extra))
+	for k, v := range extra {
+		c[k] = append(json.RawMessage(nil), v...)
+	}
+	return c
+}
@@ -0,0 +1,137 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// newTransactionList returns a list with a custom field, tags, subtasks and
+// unknown fields, so that rollbacks can be checked on all of them.
+func newTransactionList(t *testing.T) *List {
+	list := NewList()
+	if err := list.DefineField(FieldDef{Name: "size", Type: FieldEnum, Values: []string{"s", "m", "l"}}); err != nil {
+		t.Fatalf("Failed to define field: %v", err)
+	}
+	list.Add("Buy milk")
+	list.Add("Write report")
+	if err := list.Set(0, "tags", "shop,home"); err != nil {
+		t.Fatalf("Failed to set tags: %v", err)
+	}
+	if err := list.Set(1, "size", "m"); err != nil {
+		t.Fatalf("Failed to set field: %v", err)
+	}
+	list.Items[1].Subtasks = []Item{NewItem("Outline")}
+	list.Items[1].Extra = map[string]json.RawMessage{"ticket": json.RawMessage(`"OPS-1"`)}
+	return list
+}
+
+func TestTransactionCommits(t *testing.T) {
+	list := newTransactionList(t)
+
+	err := list.Transaction(func(tx *Tx) error {
+		tx.Add("Call mum")
+		return tx.Complete(0)
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if list.Count() != 3 || !list.Items[0].Done {
+		t.Errorf("Expected the changes to be kept, got %d items, first done: %v", list.Count(), list.Items[0].Done)
+	}
+}
+
+func TestTransactionRollsBack(t *testing.T) {
+	list := newTransactionList(t)
+	before := list.Clone()
+
+	failure := errors.New("failure")
+	err := list.Transaction(func(tx *Tx) error {
+		tx.Add("Call mum")
+		tx.Complete(0)
+		tx.Delete(1)
+		tx.Items[0].Tags[0] = "changed"
+		tx.Fields[0].Values[0] = "xs"
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the function's error, got %v", err)
+	}
+	if !reflect.DeepEqual(list, before) {
+		t.Errorf("Expected the list to be restored, got %+v, want %+v", list, before)
+	}
+}
+
+func TestTransactionRollsBackOnPanic(t *testing.T) {
+	list := newTransactionList(t)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Expected the panic to be passed on")
+			}
+		}()
+		list.Transaction(func(tx *Tx) error {
+			tx.Clear()
+			panic("boom")
+		})
+	}()
+	if list.Count() != 2 {
+		t.Errorf("Expected the list to be restored after a panic, got %d items", list.Count())
+	}
+}
+
+func TestBeginCommitRollback(t *testing.T) {
+	list := newTransactionList(t)
+
+	outer := list.Begin()
+	outer.Add("Outer")
+	inner := list.Begin()
+	inner.Add("Inner")
+	if err := inner.Rollback(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if list.Count() != 3 {
+		t.Errorf("Expected the inner rollback to keep the outer change, got %d items", list.Count())
+	}
+	if err := outer.Commit(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := outer.Commit(); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Expected ErrTxDone, got %v", err)
+	}
+	if err := outer.Rollback(); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Expected ErrTxDone, got %v", err)
+	}
+	if list.Count() != 3 {
+		t.Errorf("Expected a finished transaction to leave the list alone, got %d items", list.Count())
+	}
+}
+
+func TestClone(t *testing.T) {
+	list := newTransactionList(t)
+	list.Complete(0)
+	c := list.Clone()
+
+	if !reflect.DeepEqual(list, c) {
+		t.Fatalf("Expected an equal copy, got %+v", c)
+	}
+
+	c.Items[0].Tags[0] = "changed"
+	*c.Items[0].CompletedAt = c.Items[0].CompletedAt.AddDate(1, 0, 0)
+	c.Items[1].Fields["size"] = "l"
+	c.Items[1].Subtasks[0].Text = "changed"
+	c.Items[1].Extra["ticket"][1] = 'X'
+	c.Fields[0].Values[0] = "xs"
+
+	if list.Items[0].Tags[0] != "shop" ||
+		list.Items[0].CompletedAt.Equal(*c.Items[0].CompletedAt) ||
+		list.Items[1].Fields["size"] != "m" ||
+		list.Items[1].Subtasks[0].Text != "Outline" ||
+		string(list.Items[1].Extra["ticket"]) != `"OPS-1"` ||
+		list.Fields[0].Values[0] != "s" {
+		t.Errorf("Expected changes to the copy to leave the original alone, got %+v", list)
+	}
+}
@@ -0,0 +1,471 @@
+// Package tui implements the full-screen terminal interface for a todo
+// list: a navigable list with vim-style keys, a detail pane for the
+// selected item, a filter bar and a status line. The screen is redrawn when
+// the terminal is resized and the list is reloaded when its file is
+// changed by another program.
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/term"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// Options controls how the interface shows and changes items.
+type Options struct {
+	// DateFormat is the Go time layout used to display dates.
+	DateFormat string
+	// Color enables colours for done and overdue items.
+	Color bool
+	// Hook, if set, is called for each change before the list is saved,
+	// and may veto it.
+	Hook todo.HookFunc
+}
+
+// inputKind says what the text being entered is for.
+type inputKind int
+
+const (
+	inputNone inputKind = iota
+	inputAdd
+	inputEdit
+	inputSearch
+	inputFilter
+)
+
+// App holds the state of the interface. Keys are applied with HandleKey,
+// so the interface can be driven without a terminal; Run connects it to
+// one.
+type App struct {
+	store Store
+	opts  Options
+	list  *todo.List
+
+	// visible holds the indices of the items shown, after filtering
+	visible []int
+	// cursor is the position of the selected item in visible, and offset
+	// the position of the first one on screen
+	cursor int
+	offset int
+
+	filter string
+	search string
+
+	input     *field
+	inputKind inputKind
+	// origin is the cursor position when a search started, restored if
+	// it is cancelled
+	origin int
+
+	// pending is the first key of a two-key command such as dd
+	pending rune
+	message string
+	help    bool
+	quit    bool
+
+	width  int
+	height int
+}
+
+// New creates an interface for the list in store.
+func New(store Store, opts Options) (*App, error) {
+	if opts.DateFormat == "" {
+		opts.DateFormat = todo.DateLayout
+	}
+	list, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	a := &App{store: store, opts: opts, list: list, width: 80, height: 24}
+	a.refresh(-1)
+	return a, nil
+}
+
+// Resize sets the size of the screen.
+func (a *App) Resize(width, height int) {
+	a.width, a.height = max(width, 1), max(height, 1)
+	a.scroll()
+}
+
+// Done reports whether the user has quit.
+func (a *App) Done() bool {
+	return a.quit
+}
+
+// CheckExternal reloads the list if its store was changed elsewhere. It
+// reports whether the list was reloaded.
+func (a *App) CheckExternal() bool {
+	reloaded, err := a.reload()
+	if err != nil {
+		a.message = "Error: " + err.Error()
+		return true
+	}
+	return reloaded
+}
+
+// reload loads the list from its store if it was changed elsewhere,
+// keeping the selected item selected, and reports whether it did.
+func (a *App) reload() (bool, error) {
+	if !a.store.Changed() {
+		return false, nil
+	}
+	list, err := a.store.Load()
+	if err != nil {
+		return false, err
+	}
+
+	selected := -1
+	if index := a.selected(); index >= 0 {
+		selected = findItem(list, a.list.Items[index])
+	}
+	a.list = list
+	a.refresh(selected)
+	a.message = "Reloaded: the todo file was changed elsewhere"
+	return true, nil
+}
+
+// target returns the index of the selected item, or -1 if none is shown,
+// for a change about to be made. Changes made to the store elsewhere are
+// loaded first, so that saving does not overwrite them and the change is
+// made to the item as it is now. It reports false, with a message, if the
+// list cannot be loaded or the item is no longer there.
+func (a *App) target() (int, bool) {
+	index := a.selected()
+	var item todo.Item
+	if index >= 0 {
+		item = a.list.Items[index]
+	}
+
+	reloaded, err := a.reload()
+	if err != nil {
+		a.message = "Error: " + err.Error()
+		return -1, false
+	}
+	if reloaded && index >= 0 {
+		if index = findItem(a.list, item); index < 0 {
+			a.message = "Not changed: the item was changed or deleted elsewhere"
+			return -1, false
+		}
+	}
+	return index, true
+}
+
+// HandleKey applies a key press.
+func (a *App) HandleKey(k rune) {
+	if a.inputKind != inputNone {
+		a.handleInput(k)
+		return
+	}
+
+	a.message = ""
+	if a.help {
+		a.help = false
+		return
+	}
+
+	pending := a.pending
+	a.pending = 0
+
+	switch k {
+	case 'q', term.Ctrl('C'):
+		a.quit = true
+
+	case 'j', term.KeyDown, term.Ctrl('N'):
+		a.move(1)
+	case 'k', term.KeyUp, term.Ctrl('P'):
+		a.move(-1)
+	case 'g', term.KeyHome:
+		a.move(-len(a.visible))
+	case 'G', term.KeyEnd:
+		a.move(len(a.visible))
+	case term.Ctrl('D'):
+		a.move(a.pageSize() / 2)
+	case term.Ctrl('U'):
+		a.move(-a.pageSize() / 2)
+	case term.KeyPageDown, term.Ctrl('F'):
+		a.move(a.pageSize())
+	case term.KeyPageUp, term.Ctrl('B'):
+		a.move(-a.pageSize())
+
+	case 'x', ' ':
+		a.toggle()
+	case 'd':
+		if pending == 'd' {
+			a.delete()
+		} else {
+			a.pending = 'd'
+		}
+
+	case 'e', 'i':
+		if index := a.selected(); index >= 0 {
+			a.startInput(inputEdit, a.list.Items[index].Text)
+		}
+	case 'a', 'o':
+		a.startInput(inputAdd, "")
+	case '/':
+		a.origin = a.cursor
+		a.startInput(inputSearch, "")
+	case 'n':
+		a.findNext(1)
+	case 'N':
+		a.findNext(-1)
+	case 'f':
+		a.startInput(inputFilter, a.filter)
+	case 'F', term.KeyEscape:
+		if a.filter != "" {
+			a.setFilter("")
+		}
+
+	case '?':
+		a.help = true
+	case term.Ctrl('L'):
+		// Render redraws the whole screen anyway
+	}
+}
+
+// startInput opens a field for entering text.
+func (a *App) startInput(kind inputKind, text string) {
+	a.inputKind = kind
+	a.input = newField(text)
+}
+
+// handleInput applies a key to the open field.
+func (a *App) handleInput(k rune) {
+	result := a.input.handle(k)
+	text := a.input.String()
+
+	switch result {
+	case fieldCancelled:
+		if a.inputKind == inputSearch {
+			a.cursor = a.origin
+			a.scroll()
+		}
+		a.closeInput()
+		return
+	case fieldEdited:
+		if a.inputKind == inputSearch {
+			a.cursor = a.origin
+			if text != "" && !a.find(text, a.origin, 1) {
+				a.message = "No match for " + text
+			} else {
+				a.message = ""
+			}
+		}
+		return
+	}
+
+	switch a.inputKind {
+	case inputAdd:
+		if _, ok := a.target(); ok && strings.TrimSpace(text) != "" {
+			var index int
+			err := a.list.Transaction(func(tx *todo.Tx) error {
+				index = tx.Add(text)
+				return a.commit(tx, todo.ItemAdded, index, nil)
+			})
+			if err != nil {
+				a.message = "Error: " + err.Error()
+				break
+			}
+			a.refresh(index)
+			if a.selected() != index {
+				a.message = "Added item hidden by the filter"
+			}
+		}
+	case inputEdit:
+		if index, ok := a.target(); ok && index >= 0 && strings.TrimSpace(text) != "" {
+			err := a.list.Transaction(func(tx *todo.Tx) error {
+				before := tx.Items[index].Clone()
+				if err := tx.Edit(index, text); err != nil {
+					return err
+				}
+				return a.commit(tx, todo.ItemEdited, index, &before)
+			})
+			if err != nil {
+				a.message = "Error: " + err.Error()
+			} else {
+				a.refresh(index)
+			}
+		}
+	case inputSearch:
+		a.search = text
+	case inputFilter:
+		if err := a.setFilter(text); err != nil {
+			// Keep the bar open to correct the filter
+			a.message = "Error: " + err.Error()
+			return
+		}
+	}
+	a.closeInput()
+}
+
+// closeInput closes the open field.
+func (a *App) closeInput() {
+	a.input = nil
+	a.inputKind = inputNone
+}
+
+// selected returns the index of the selected item, or -1 if none is shown.
+func (a *App) selected() int {
+	if a.cursor < 0 || a.cursor >= len(a.visible) {
+		return -1
+	}
+	return a.visible[a.cursor]
+}
+
+// move moves the cursor by n items, stopping at either end.
+func (a *App) move(n int) {
+	a.cursor = max(0, min(a.cursor+n, len(a.visible)-1))
+	a.scroll()
+}
+
+// toggle completes the selected item, or reopens it if it is done.
+func (a *App) toggle() {
+	index, ok := a.target()
+	if !ok || index < 0 {
+		return
+	}
+	err := a.list.Transaction(func(tx *todo.Tx) error {
+		before := tx.Items[index].Clone()
+		change := todo.ItemCompleted
+		if before.Done {
+			tx.Uncomplete(index)
+			change = todo.ItemUncompleted
+		} else {
+			tx.Complete(index)
+		}
+		return a.commit(tx, change, index, &before)
+	})
+	if err != nil {
+		a.message = "Error: " + err.Error()
+		return
+	}
+	a.refresh(index)
+}
+
+// delete removes the selected item.
+func (a *App) delete() {
+	index, ok := a.target()
+	if !ok || index < 0 {
+		return
+	}
+	text := a.list.Items[index].Text
+	err := a.list.Transaction(func(tx *todo.Tx) error {
+		if a.opts.Hook != nil {
+			if err := a.opts.Hook(tx.List, todo.ItemDeleted, index, nil); err != nil {
+				return err
+			}
+		}
+		if err := tx.Delete(index); err != nil {
+			return err
+		}
+		return a.store.Save(tx.List)
+	})
+	if err != nil {
+		a.message = "Error: " + err.Error()
+		return
+	}
+	a.refresh(-1)
+	if a.message == "" {
+		a.message = fmt.Sprintf("Deleted %q", text)
+	}
+}
+
+// commit runs the hook for a change made in tx and saves the list. The
+// change is undone if either fails.
+func (a *App) commit(tx *todo.Tx, change todo.EventType, index int, before *todo.Item) error {
+	if a.opts.Hook != nil {
+		if err := a.opts.Hook(tx.List, change, index, before); err != nil {
+			return err
+		}
+	}
+	return a.store.Save(tx.List)
+}
+
+// setFilter shows only the items matching the filter expression.
+func (a *App) setFilter(expr string) error {
+	expr = strings.TrimSpace(expr)
+	if _, err := a.list.Query(expr, ""); err != nil {
+		return err
+	}
+	selected := a.selected()
+	a.filter = expr
+	a.refresh(selected)
+	return nil
+}
+
+// refresh recomputes the visible items after the list or filter changed,
+// keeping the item with index keep selected if it is still shown and the
+// cursor position otherwise.
+func (a *App) refresh(keep int) {
+	visible, err := a.list.Query(a.filter, "")
+	if err != nil {
+		// A reloaded list may no longer have the fields the filter uses
+		a.message = "Filter cleared: " + err.Error()
+		a.filter = ""
+		visible, _ = a.list.Query("", "")
+	}
+	a.visible = visible
+
+	for pos, index := range a.visible {
+		if index == keep {
+			a.cursor = pos
+			a.scroll()
+			return
+		}
+	}
+	a.move(0)
+}
+
+// findItem returns the index of item in list, identified by its UID or
+// else its creation time and text, or -1 if it is not there.
+func findItem(list *todo.List, item todo.Item) int {
+	for i, other := range list.Items {
+		if item.UID != "" && other.UID == item.UID ||
+			item.UID == "" && other.CreatedAt.Equal(item.CreatedAt) && other.Text == item.Text {
+			return i
+		}
+	}
+	return -1
+}
+
+// find moves the cursor to the first item at or after position from,
+// searching in direction dir and wrapping around, whose text contains
+// query regardless of case. It reports whether one was found.
+func (a *App) find(query string, from, dir int) bool {
+	n := len(a.visible)
+	query = strings.ToLower(query)
+	for i := 0; i < n; i++ {
+		pos := ((from+dir*i)%n + n) % n
+		if strings.Contains(strings.ToLower(a.list.Items[a.visible[pos]].Text), query) {
+			a.cursor = pos
+			a.scroll()
+			return true
+		}
+	}
+	return false
+}
+
+// findNext repeats the last search in direction dir.
+func (a *App) findNext(dir int) {
+	if a.search == "" {
+		a.message = "No previous search"
+		return
+	}
+	if !a.find(a.search, a.cursor+dir, dir) {
+		a.message = "No match for " + a.search
+	}
+}
+
+// scroll adjusts the offset so that the cursor is on screen.
+func (a *App) scroll() {
+	page := a.pageSize()
+	if a.cursor < a.offset {
+		a.offset = a.cursor
+	}
+	if a.cursor >= a.offset+page {
+		a.offset = a.cursor - page + 1
+	}
+	a.offset = max(0, min(a.offset, len(a.visible)-page))
+}
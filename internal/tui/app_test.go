@@ -0,0 +1,384 @@
+package tui
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/term"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// memStore keeps the list in memory, counting saves.
+type memStore struct {
+	list    *todo.List
+	saves   int
+	changed bool
+}
+
+func (s *memStore) Load() (*todo.List, error) {
+	s.changed = false
+	return s.list, nil
+}
+
+func (s *memStore) Save(list *todo.List) error {
+	s.list = list
+	s.saves++
+	return nil
+}
+
+func (s *memStore) Changed() bool {
+	return s.changed
+}
+
+// newTestApp returns an app showing a list with the given items on an
+// 80x24 screen.
+func newTestApp(t *testing.T, texts ...string) (*App, *memStore) {
+	list := todo.NewList()
+	for _, text := range texts {
+		list.Add(text)
+	}
+	store := &memStore{list: list}
+	a, err := New(store, Options{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	a.Resize(80, 24)
+	return a, store
+}
+
+// keys sends each rune of s to the app.
+func keys(a *App, s string) {
+	for _, k := range s {
+		a.HandleKey(k)
+	}
+}
+
+// items returns the texts of the items in the list.
+func items(l *todo.List) string {
+	var texts []string
+	for _, item := range l.Items {
+		texts = append(texts, item.Text)
+	}
+	return strings.Join(texts, ",")
+}
+
+func TestNavigation(t *testing.T) {
+	a, _ := newTestApp(t, "one", "two", "three")
+
+	tests := []struct {
+		keys     string
+		expected int
+	}{
+		{"j", 1},
+		{"jj", 2},
+		{"k", 1},
+		{"G", 2},
+		{"g", 0},
+		{"k", 0},
+	}
+	for _, tc := range tests {
+		keys(a, tc.keys)
+		if a.cursor != tc.expected {
+			t.Errorf("After %q: expected cursor at %d, got %d", tc.keys, tc.expected, a.cursor)
+		}
+	}
+
+	a.HandleKey(term.KeyDown)
+	if a.cursor != 1 {
+		t.Errorf("Expected Down to move to the next item, got %d", a.cursor)
+	}
+}
+
+func TestToggleAndDelete(t *testing.T) {
+	a, store := newTestApp(t, "one", "two", "three")
+
+	keys(a, "jx")
+	if !store.list.Items[1].Done {
+		t.Errorf("Expected x to complete the selected item")
+	}
+	keys(a, "x")
+	if store.list.Items[1].Done {
+		t.Errorf("Expected x to reopen a completed item")
+	}
+
+	keys(a, "d")
+	if store.list.Count() != 3 {
+		t.Errorf("Expected a single d to delete nothing, got %d items", store.list.Count())
+	}
+	keys(a, "d")
+	if got := items(store.list); got != "one,three" {
+		t.Errorf("Expected dd to delete the selected item, got %q", got)
+	}
+	if a.selected() != 1 {
+		t.Errorf("Expected the next item to be selected, got %d", a.selected())
+	}
+	if store.saves != 3 {
+		t.Errorf("Expected 3 saves, got %d", store.saves)
+	}
+
+	keys(a, "djd")
+	if store.list.Count() != 2 {
+		t.Errorf("Expected d followed by another key to delete nothing, got %d items", store.list.Count())
+	}
+}
+
+func TestEditAndAdd(t *testing.T) {
+	a, store := newTestApp(t, "buy milk")
+
+	keys(a, "e")
+	a.HandleKey(term.Ctrl('W'))
+	keys(a, "bread\r")
+	if got := items(store.list); got != "buy bread" {
+		t.Errorf("Expected the item to be edited, got %q", got)
+	}
+
+	keys(a, "eoops")
+	a.HandleKey(term.KeyEscape)
+	if got := items(store.list); got != "buy bread" {
+		t.Errorf("Expected Esc to cancel the edit, got %q", got)
+	}
+
+	keys(a, "acall mum\r")
+	if got := items(store.list); got != "buy bread,call mum" {
+		t.Errorf("Expected an item to be added, got %q", got)
+	}
+	if a.selected() != 1 {
+		t.Errorf("Expected the new item to be selected, got %d", a.selected())
+	}
+}
+
+func TestHook(t *testing.T) {
+	a, store := newTestApp(t, "one", "two")
+	var changes []todo.EventType
+	a.opts.Hook = func(list *todo.List, change todo.EventType, index int, before *todo.Item) error {
+		changes = append(changes, change)
+		if change == todo.ItemDeleted {
+			return errors.New("not allowed")
+		}
+		if change == todo.ItemAdded {
+			item := list.Items[index]
+			item.Text = strings.ToUpper(item.Text)
+			return list.Replace(index, item)
+		}
+		return nil
+	}
+
+	keys(a, "xdd")
+	if store.list.Count() != 2 || !store.list.Items[0].Done {
+		t.Errorf("Expected the item to be completed and not deleted, got %q", items(store.list))
+	}
+	if !strings.Contains(a.message, "not allowed") {
+		t.Errorf("Expected the hook's error to be shown, got %q", a.message)
+	}
+	keys(a, "athree\r")
+	if got := items(store.list); got != "one,two,THREE" {
+		t.Errorf("Expected the item as changed by the hook, got %q", got)
+	}
+
+	expected := []todo.EventType{todo.ItemCompleted, todo.ItemDeleted, todo.ItemAdded}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected hooks for %v, got %v", expected, changes)
+	}
+	if store.saves != 2 {
+		t.Errorf("Expected 2 saves, got %d", store.saves)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	a, _ := newTestApp(t, "buy milk", "write report", "buy bread", "call mum")
+
+	keys(a, "/buy")
+	if a.cursor != 0 {
+		t.Errorf("Expected the first match to be selected, got %d", a.cursor)
+	}
+	keys(a, "\r")
+	keys(a, "n")
+	if a.cursor != 2 {
+		t.Errorf("Expected n to select the next match, got %d", a.cursor)
+	}
+	keys(a, "n")
+	if a.cursor != 0 {
+		t.Errorf("Expected n to wrap around, got %d", a.cursor)
+	}
+	keys(a, "N")
+	if a.cursor != 2 {
+		t.Errorf("Expected N to select the previous match, got %d", a.cursor)
+	}
+
+	keys(a, "/CALL")
+	if a.cursor != 3 {
+		t.Errorf("Expected search to ignore case, got %d", a.cursor)
+	}
+	a.HandleKey(term.KeyEscape)
+	if a.cursor != 2 {
+		t.Errorf("Expected Esc to restore the cursor, got %d", a.cursor)
+	}
+
+	keys(a, "/nothing")
+	if !strings.Contains(a.message, "No match") {
+		t.Errorf("Expected a message about no match, got %q", a.message)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	a, store := newTestApp(t, "one", "two", "three")
+	store.list.Complete(1)
+
+	keys(a, "fpending\r")
+	if a.filter != "pending" || len(a.visible) != 2 {
+		t.Errorf("Expected 2 pending items, got %v with filter %q", a.visible, a.filter)
+	}
+
+	keys(a, "jx")
+	if len(a.visible) != 1 || a.selected() != 0 {
+		t.Errorf("Expected the completed item to be hidden, got %v selecting %d", a.visible, a.selected())
+	}
+
+	keys(a, "F")
+	if a.filter != "" || len(a.visible) != 3 {
+		t.Errorf("Expected F to clear the filter, got %v with filter %q", a.visible, a.filter)
+	}
+
+	keys(a, "fnosuchfield=1\r")
+	if a.inputKind != inputFilter || !strings.HasPrefix(a.message, "Error:") {
+		t.Errorf("Expected an invalid filter to keep the bar open with an error, got %q", a.message)
+	}
+	a.HandleKey(term.KeyEscape)
+	if a.filter != "" {
+		t.Errorf("Expected Esc to keep the previous filter, got %q", a.filter)
+	}
+}
+
+func TestCheckExternal(t *testing.T) {
+	a, store := newTestApp(t, "one", "two", "three")
+	keys(a, "jj")
+
+	if a.CheckExternal() {
+		t.Errorf("Expected no reload without a change")
+	}
+
+	changed := todo.NewList()
+	changed.Add("zero")
+	changed.Items = append(changed.Items, store.list.Items...)
+	store.list, store.changed = changed, true
+
+	if !a.CheckExternal() {
+		t.Fatalf("Expected a reload after a change")
+	}
+	if a.list.Items[a.selected()].Text != "three" {
+		t.Errorf("Expected the selected item to stay selected, got %q", a.list.Items[a.selected()].Text)
+	}
+	if !strings.HasPrefix(a.message, "Reloaded") {
+		t.Errorf("Expected a message about the reload, got %q", a.message)
+	}
+}
+
+func TestChangesKeepExternalChanges(t *testing.T) {
+	a, store := newTestApp(t, "one", "two", "three")
+	keys(a, "j")
+
+	// Another program adds an item at the top before the key is pressed
+	changed := todo.NewList()
+	changed.Add("zero")
+	changed.Items = append(changed.Items, store.list.Clone().Items...)
+	store.list, store.changed = changed, true
+
+	keys(a, "x")
+	if items(store.list) != "zero,one,two,three" {
+		t.Fatalf("Expected the external change to be kept, got %s", items(store.list))
+	}
+	if !store.list.Items[2].Done || store.list.Items[1].Done {
+		t.Errorf("Expected the selected item two to be toggled, got %+v", store.list.Items)
+	}
+
+	// Another program deletes the selected item
+	changed = store.list.Clone()
+	changed.Delete(2)
+	store.list, store.changed = changed, true
+
+	keys(a, "dd")
+	if items(store.list) != "zero,one,three" {
+		t.Errorf("Expected no item to be deleted, got %s", items(store.list))
+	}
+	if !strings.Contains(a.message, "elsewhere") {
+		t.Errorf("Expected a message about the external change, got %q", a.message)
+	}
+
+	// Adding keeps external changes too
+	changed = store.list.Clone()
+	changed.Add("four")
+	store.list, store.changed = changed, true
+
+	keys(a, "afive\r")
+	if items(store.list) != "zero,one,three,four,five" {
+		t.Errorf("Expected the added item after the external one, got %s", items(store.list))
+	}
+}
+
+func TestRender(t *testing.T) {
+	a, store := newTestApp(t, "buy milk", "write report")
+	store.list.Items[0].Project = "home"
+	store.list.Complete(1)
+
+	s := a.render()
+	if len(s.rows) != 24 {
+		t.Fatalf("Expected 24 rows, got %d", len(s.rows))
+	}
+	for i := range s.rows {
+		if w := term.Width(s.text(i)); w != 80 {
+			t.Errorf("Expected row %d to be 80 columns wide, got %d: %q", i, w, s.text(i))
+		}
+	}
+
+	if !strings.Contains(s.text(1), "1. [ ] buy milk") || !strings.Contains(s.text(2), "2. [✓] write report") {
+		t.Errorf("Expected the items in the list pane, got %q and %q", s.text(1), s.text(2))
+	}
+	if !strings.Contains(s.text(1), "│ buy milk") {
+		t.Errorf("Expected the detail pane beside the list, got %q", s.text(1))
+	}
+	detail := ""
+	for i := range s.rows {
+		detail += s.text(i) + "\n"
+	}
+	if !strings.Contains(detail, "Project:  home") {
+		t.Errorf("Expected the project in the detail pane, got:\n%s", detail)
+	}
+	if !strings.Contains(s.text(22), "1 pending, 1 completed") {
+		t.Errorf("Expected the counts on the status line, got %q", s.text(22))
+	}
+	if s.cursorRow != -1 {
+		t.Errorf("Expected a hidden cursor, got row %d", s.cursorRow)
+	}
+
+	a.Resize(60, 20)
+	s = a.render()
+	if len(s.rows) != 20 || strings.Contains(s.text(1), "│") {
+		t.Errorf("Expected the detail pane below the list on a narrow screen, got %q", s.text(1))
+	}
+
+	keys(a, "e")
+	s = a.render()
+	if s.cursorRow != 1 || s.cursorCol != len(" 1. [ ] buy milk") {
+		t.Errorf("Expected the cursor at the end of the edited item, got %d,%d", s.cursorRow, s.cursorCol)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		s        string
+		width    int
+		expected string
+	}{
+		{"buy milk and bread", 10, "buy milk|and bread"},
+		{"buy", 10, "buy"},
+		{"", 10, ""},
+		{"abcdefghij", 4, "abcd|efgh|ij"},
+	}
+	for _, tc := range tests {
+		if got := strings.Join(wrap(tc.s, tc.width), "|"); got != tc.expected {
+			t.Errorf("wrap(%q, %d): expected %q, got %q", tc.s, tc.width, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"unicode"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/term"
+)
+
+// fieldResult says what a key did to a field.
+type fieldResult int
+
+const (
+	fieldEdited fieldResult = iota
+	fieldAccepted
+	fieldCancelled
+)
+
+// field is a single-line text input with the basic emacs editing keys.
+type field struct {
+	text []rune
+	pos  int
+}
+
+// newField creates a field holding text, with the cursor at its end.
+func newField(text string) *field {
+	f := &field{text: []rune(text)}
+	f.pos = len(f.text)
+	return f
+}
+
+// String returns the text of the field.
+func (f *field) String() string {
+	return string(f.text)
+}
+
+// handle applies a key to the field.
+func (f *field) handle(k rune) fieldResult {
+	switch k {
+	case '\r', '\n':
+		return fieldAccepted
+	case term.KeyEscape, term.Ctrl('C'), term.Ctrl('G'):
+		return fieldCancelled
+
+	case term.KeyBackspace, term.Ctrl('H'):
+		if f.pos > 0 {
+			f.delete(f.pos-1, f.pos)
+		}
+	case term.KeyDelete, term.Ctrl('D'):
+		f.delete(f.pos, f.pos+1)
+	case term.KeyLeft, term.Ctrl('B'):
+		f.pos = max(f.pos-1, 0)
+	case term.KeyRight, term.Ctrl('F'):
+		f.pos = min(f.pos+1, len(f.text))
+	case term.KeyHome, term.Ctrl('A'):
+		f.pos = 0
+	case term.KeyEnd, term.Ctrl('E'):
+		f.pos = len(f.text)
+	case term.Ctrl('U'):
+		f.delete(0, f.pos)
+	case term.Ctrl('K'):
+		f.delete(f.pos, len(f.text))
+	case term.Ctrl('W'), term.KeyKillWordBack:
+		start := f.pos
+		for start > 0 && unicode.IsSpace(f.text[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(f.text[start-1]) {
+			start--
+		}
+		f.delete(start, f.pos)
+
+	default:
+		if k >= ' ' && unicode.IsPrint(k) {
+			f.text = append(f.text[:f.pos], append([]rune{k}, f.text[f.pos:]...)...)
+			f.pos++
+		}
+	}
+	return fieldEdited
+}
+
+// delete removes the runes from start up to end and moves the cursor to
+// start.
+func (f *field) delete(start, end int) {
+	end = min(end, len(f.text))
+	if start >= end {
+		return
+	}
+	f.text = append(f.text[:start], f.text[end:]...)
+	f.pos = start
+}
+
+// view returns the part of the text shown in width columns, scrolled so
+// that the cursor is visible, and the column of the cursor within it.
+func (f *field) view(width int) (string, int) {
+	if width <= 0 {
+		return "", 0
+	}
+	start := 0
+	for term.Width(string(f.text[start:f.pos])) >= width {
+		start++
+	}
+	visible := string(f.text[start:])
+	if term.Width(visible) > width {
+		visible = term.Truncate(visible, width)
+	}
+	return visible, term.Width(string(f.text[start:f.pos]))
+}
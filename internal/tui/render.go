@@ -0,0 +1,396 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/term"
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// ANSI escape sequences used for styling
+const (
+	styleReset   = "\x1b[0m"
+	styleBold    = "\x1b[1m"
+	styleDim     = "\x1b[2m"
+	styleReverse = "\x1b[7m"
+	styleGreen   = "\x1b[32m"
+	styleRed     = "\x1b[31m"
+)
+
+// hint lists the main keys on the bottom row.
+const hint = "j/k move  x toggle  dd delete  e edit  a add  / search  f filter  ? help  q quit"
+
+// helpText is shown in place of the list when ? is pressed.
+var helpText = []string{
+	"Keys",
+	"",
+	"  j, Down          next item          k, Up            previous item",
+	"  g, Home          first item         G, End           last item",
+	"  Ctrl-D           half page down     Ctrl-U           half page up",
+	"  Ctrl-F, PgDn     page down          Ctrl-B, PgUp     page up",
+	"  x, Space         toggle done        dd               delete item",
+	"  e, i             edit text          a, o             add item",
+	"  /                search             n, N             next, previous match",
+	"  f                edit filter        F, Esc           clear filter",
+	"  ?                this help          q, Ctrl-C        quit",
+	"",
+	"While entering text, Enter accepts and Esc cancels. Filters use the",
+	"query language of 'todo list --where', e.g. done=false and priority=high.",
+	"",
+	"Press any key to return to the list.",
+}
+
+// segment is a piece of a screen row with its style.
+type segment struct {
+	text  string
+	style string
+}
+
+// screen is the content of the terminal, row by row. Every row is exactly
+// as wide as the terminal.
+type screen struct {
+	rows [][]segment
+	// cursorRow and cursorCol place the cursor; cursorRow is -1 when it
+	// is hidden
+	cursorRow int
+	cursorCol int
+}
+
+// text returns the text of a row without styling.
+func (s *screen) text(row int) string {
+	var b strings.Builder
+	for _, seg := range s.rows[row] {
+		b.WriteString(seg.text)
+	}
+	return b.String()
+}
+
+// layout describes where the parts of the screen go.
+type layout struct {
+	listRows    int
+	listWidth   int
+	detailRows  int // rows below the list, for narrow terminals
+	detailWidth int // columns beside the list, for wide terminals
+}
+
+// layout divides the screen between the list and the detail pane, which is
+// beside the list on wide terminals, below it on tall ones and left out
+// otherwise. The first row holds the filter bar and the last two the
+// status line and prompt.
+func (a *App) layout() layout {
+	body := max(1, a.height-3)
+	l := layout{listRows: body, listWidth: a.width}
+	switch {
+	case a.width >= 80:
+		l.detailWidth = a.width * 2 / 5
+		l.listWidth = a.width - l.detailWidth - 1
+	case body >= 14:
+		l.detailRows = body / 3
+		l.listRows = body - l.detailRows
+	}
+	return l
+}
+
+// pageSize returns the number of items shown at once.
+func (a *App) pageSize() int {
+	return a.layout().listRows
+}
+
+// render lays out the screen.
+func (a *App) render() *screen {
+	s := &screen{cursorRow: -1}
+	l := a.layout()
+
+	s.rows = append(s.rows, a.filterBar(s))
+
+	var body [][]segment
+	if a.help {
+		for i := 0; i < l.listRows+l.detailRows; i++ {
+			line := ""
+			if i < len(helpText) {
+				line = " " + helpText[i]
+			}
+			body = append(body, []segment{{text: pad(line, a.width)}})
+		}
+		s.rows = append(s.rows, body...)
+	} else {
+		list := a.listRows(s, l)
+		var detail []string
+		if l.detailWidth > 0 {
+			detail = a.detailLines(l.detailWidth - 2)
+		} else if l.detailRows > 0 {
+			detail = a.detailLines(a.width - 2)
+		}
+
+		for i, row := range list {
+			if l.detailWidth > 0 {
+				row = append(row, segment{text: "│", style: styleDim})
+				row = append(row, segment{text: pad(" "+line(detail, i), l.detailWidth)})
+			}
+			s.rows = append(s.rows, row)
+		}
+		if l.detailRows > 0 {
+			s.rows = append(s.rows, []segment{{text: strings.Repeat("─", a.width), style: styleDim}})
+			for i := 0; i < l.detailRows-1; i++ {
+				s.rows = append(s.rows, []segment{{text: pad(" "+line(detail, i), a.width)}})
+			}
+		}
+	}
+
+	s.rows = append(s.rows, a.statusLine())
+	s.rows = append(s.rows, a.promptLine(s))
+	return s
+}
+
+// filterBar returns the first row, showing the filter or the field for
+// editing it.
+func (a *App) filterBar(s *screen) []segment {
+	label := " Filter: "
+	if a.inputKind == inputFilter {
+		text, col := a.input.view(a.width - len(label))
+		s.cursorRow, s.cursorCol = 0, len(label)+col
+		return []segment{{text: label, style: styleBold}, {text: pad(text, a.width-len(label))}}
+	}
+	if a.filter == "" {
+		return []segment{{text: label, style: styleBold}, {text: pad("none (press f to filter)", a.width-len(label)), style: styleDim}}
+	}
+	return []segment{{text: label, style: styleBold}, {text: pad(a.filter, a.width-len(label))}}
+}
+
+// listRows returns the rows of the list pane.
+func (a *App) listRows(s *screen, l layout) [][]segment {
+	rows := make([][]segment, l.listRows)
+	for i := range rows {
+		rows[i] = []segment{{text: pad("", l.listWidth)}}
+	}
+	if len(a.visible) == 0 {
+		text := " No items in the todo list"
+		if a.filter != "" {
+			text = " No items match the filter"
+		}
+		rows[0] = []segment{{text: pad(text, l.listWidth), style: styleDim}}
+		return rows
+	}
+
+	now := time.Now()
+	for i := 0; i < l.listRows && a.offset+i < len(a.visible); i++ {
+		pos := a.offset + i
+		index := a.visible[pos]
+		item := a.list.Items[index]
+		prefix := fmt.Sprintf(" %d. ", index+1)
+
+		if pos == a.cursor && a.inputKind == inputEdit {
+			prefix += strings.TrimSuffix(item.String(), item.Text)
+			text, col := a.input.view(l.listWidth - term.Width(prefix))
+			s.cursorRow, s.cursorCol = 1+i, term.Width(prefix)+col
+			rows[i] = []segment{{text: prefix}, {text: pad(text, l.listWidth-term.Width(prefix))}}
+			continue
+		}
+
+		text := prefix + item.String()
+		if item.Due != nil {
+			text += " (due: " + item.Due.Local().Format(a.opts.DateFormat) + ")"
+		}
+
+		style := ""
+		if a.opts.Color {
+			switch {
+			case item.Done:
+				style = styleDim + styleGreen
+			case item.Due != nil && item.Due.Before(now):
+				style = styleRed
+			}
+		}
+		if pos == a.cursor {
+			style = styleReverse + style
+		}
+		rows[i] = []segment{{text: pad(text, l.listWidth), style: style}}
+	}
+	return rows
+}
+
+// detailLines describes the selected item in lines of at most width
+// columns.
+func (a *App) detailLines(width int) []string {
+	index := a.selected()
+	if index < 0 || width <= 0 {
+		return nil
+	}
+	item := a.list.Items[index]
+	date := func(t time.Time) string {
+		return t.Local().Format(a.opts.DateFormat)
+	}
+
+	lines := wrap(item.Text, width)
+	lines = append(lines, "")
+
+	var props [][2]string
+	status := "pending"
+	if item.Done {
+		status = "done"
+		if item.CompletedAt != nil {
+			status += " " + date(*item.CompletedAt)
+		}
+	}
+	props = append(props, [2]string{"Status", status})
+	if item.Priority != todo.PriorityNone {
+		props = append(props, [2]string{"Priority", string(item.Priority)})
+	}
+	if item.Project != "" {
+		props = append(props, [2]string{"Project", item.Project})
+	}
+	if len(item.Tags) > 0 {
+		props = append(props, [2]string{"Tags", strings.Join(item.Tags, ", ")})
+	}
+	if item.Due != nil {
+		props = append(props, [2]string{"Due", date(*item.Due)})
+	}
+	if item.Recurrence != "" {
+		props = append(props, [2]string{"Repeats", item.Recurrence})
+	}
+	for _, def := range a.list.FieldDefs() {
+		value, ok := item.Fields[def.Name]
+		if !ok {
+			continue
+		}
+		if def.Type == todo.FieldDate {
+			if t, err := time.ParseInLocation(todo.DateLayout, value, time.Local); err == nil {
+				value = t.Format(a.opts.DateFormat)
+			}
+		}
+		props = append(props, [2]string{def.Name, value})
+	}
+	if len(item.Subtasks) > 0 {
+		done := 0
+		for _, sub := range item.Subtasks {
+			if sub.Done {
+				done++
+			}
+		}
+		props = append(props, [2]string{"Subtasks", fmt.Sprintf("%d/%d done", done, len(item.Subtasks))})
+	}
+	props = append(props, [2]string{"Created", date(item.CreatedAt)})
+
+	// Values are wrapped beside their labels when there is room
+	const labelWidth = 10
+	for _, p := range props {
+		if width <= labelWidth*2 {
+			lines = append(lines, wrap(p[0]+": "+p[1], width)...)
+			continue
+		}
+		for i, value := range wrap(p[1], width-labelWidth) {
+			label := ""
+			if i == 0 {
+				label = p[0] + ":"
+			}
+			lines = append(lines, fmt.Sprintf("%-*s%s", labelWidth, label, value))
+		}
+	}
+
+	if len(item.Notes) > 0 {
+		lines = append(lines, "", "Notes:")
+		for _, note := range item.Notes {
+			lines = append(lines, wrap(date(note.Time)+"  "+note.Text, width)...)
+		}
+	}
+	return lines
+}
+
+// statusLine returns the row with the item counts.
+func (a *App) statusLine() []segment {
+	left := fmt.Sprintf(" %d pending, %d completed", a.list.CountPending(), a.list.CountCompleted())
+	right := fmt.Sprintf("%d items ", a.list.Count())
+	if a.filter != "" {
+		right = fmt.Sprintf("%d of %d shown ", len(a.visible), a.list.Count())
+	}
+	gap := a.width - term.Width(left) - term.Width(right)
+	if gap < 1 {
+		return []segment{{text: pad(left, a.width), style: styleReverse}}
+	}
+	return []segment{{text: left + strings.Repeat(" ", gap) + right, style: styleReverse}}
+}
+
+// promptLine returns the last row: the field being entered, a message or a
+// reminder of the keys.
+func (a *App) promptLine(s *screen) []segment {
+	row := len(s.rows)
+	label := ""
+	switch a.inputKind {
+	case inputAdd:
+		label = "Add: "
+	case inputSearch:
+		label = "/"
+	}
+	if label != "" {
+		width := a.width - len(label)
+		if a.message != "" {
+			// Leave room for the message after the field
+			width -= min(term.Width(a.message)+2, width/2)
+		}
+		text, col := a.input.view(width)
+		s.cursorRow, s.cursorCol = row, len(label)+col
+		return []segment{{text: label + pad(text, width)}, {text: pad("  "+a.message, a.width-len(label)-width), style: styleDim}}
+	}
+
+	if a.message != "" {
+		style := ""
+		if strings.HasPrefix(a.message, "Error:") && a.opts.Color {
+			style = styleRed
+		}
+		return []segment{{text: pad(a.message, a.width), style: style}}
+	}
+	return []segment{{text: pad(hint, a.width), style: styleDim}}
+}
+
+// line returns lines[i], or "" past the end.
+func line(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return ""
+}
+
+// pad truncates or pads s with spaces to exactly width columns.
+func pad(s string, width int) string {
+	s = term.Truncate(s, width)
+	return s + strings.Repeat(" ", max(0, width-term.Width(s)))
+}
+
+// wrap breaks s into lines of at most width columns at spaces, cutting
+// words that are longer than a line.
+func wrap(s string, width int) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(s) {
+		for term.Width(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			n := 0
+			for n < len(runes) && term.Width(string(runes[:n+1])) <= width {
+				n++
+			}
+			n = max(n, 1)
+			lines = append(lines, string(runes[:n]))
+			word = string(runes[n:])
+		}
+		switch {
+		case word == "":
+		case current == "":
+			current = word
+		case term.Width(current)+1+term.Width(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" || len(lines) == 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
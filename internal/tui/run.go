@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/kai-xlr/CLI-Task-Manager/internal/term"
+)
+
+// PollInterval is how often Run checks the store for changes made
+// elsewhere.
+const PollInterval = time.Second
+
+// Terminal control sequences
+const (
+	enterScreen = "\x1b[?1049h\x1b[H"
+	leaveScreen = "\x1b[?1049l"
+	hideCursor  = "\x1b[?25l"
+	showCursor  = "\x1b[?25h"
+)
+
+// Run shows the interface on the terminal in and out until the user quits.
+// It switches the terminal to raw mode and the alternate screen, and
+// restores both when it returns.
+func (a *App) Run(in, out *os.File) error {
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("the full-screen interface needs a terminal")
+	}
+	restore, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	defer restore()
+
+	io.WriteString(out, enterScreen+hideCursor)
+	defer io.WriteString(out, showCursor+leaveScreen)
+
+	// Keys are read in the background so that resizes and changes to the
+	// store are handled while waiting for one. The reader is left blocked
+	// on input when Run returns.
+	keys := make(chan rune)
+	readErr := make(chan error, 1)
+	go func() {
+		r := bufio.NewReader(in)
+		for {
+			k, err := term.ReadKey(r)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			keys <- k
+		}
+	}()
+
+	resized := make(chan os.Signal, 1)
+	term.NotifyResize(resized)
+	defer signal.Stop(resized)
+
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	a.resizeTo(fd)
+	for {
+		a.draw(out)
+		select {
+		case k := <-keys:
+			a.HandleKey(k)
+			if a.Done() {
+				return nil
+			}
+		case err := <-readErr:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read from the terminal: %w", err)
+		case <-resized:
+			a.resizeTo(fd)
+		case <-ticker.C:
+			if !a.CheckExternal() {
+				// Nothing to redraw
+				continue
+			}
+		}
+	}
+}
+
+// resizeTo sets the size of the screen to that of the terminal fd.
+func (a *App) resizeTo(fd int) {
+	if cols, rows, err := term.Size(fd); err == nil && cols > 0 && rows > 0 {
+		a.Resize(cols, rows)
+	}
+}
+
+// draw writes the whole screen to out in one go.
+func (a *App) draw(out io.Writer) {
+	s := a.render()
+	var b strings.Builder
+	b.WriteString(hideCursor + "\x1b[H")
+	for i, row := range s.rows[:min(len(s.rows), a.height)] {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		for _, seg := range row {
+			if seg.style == "" {
+				b.WriteString(seg.text)
+			} else {
+				b.WriteString(seg.style + seg.text + styleReset)
+			}
+		}
+	}
+	if s.cursorRow >= 0 {
+		fmt.Fprintf(&b, "\x1b[%d;%dH%s", s.cursorRow+1, s.cursorCol+1, showCursor)
+	}
+	io.WriteString(out, b.String())
+}
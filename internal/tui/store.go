@@ -0,0 +1,17 @@
+package tui
+
+import (
+	"github.com/kai-xlr/CLI-Task-Manager/internal/todo"
+)
+
+// Store loads and saves the list shown by an App. todo.FileStore keeps it
+// in a todo file.
+type Store interface {
+	// Load returns the current list.
+	Load() (*todo.List, error)
+	// Save replaces the stored list.
+	Save(list *todo.List) error
+	// Changed reports whether the list was changed elsewhere since it was
+	// last loaded or saved.
+	Changed() bool
+}